@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleFormat     string
+	bundlePassphrase string
+	importMode       string
+	importDryRun     bool
+)
+
+// ExportCmd represents the export command
+var ExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Export storages, metas, users and settings into a bundle",
+	Long: `Export storages, metas, users and settings into a bundle, which can be imported by [alist import].
+The bundle is written to stdout if file is - or omitted.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file := "-"
+		if len(args) > 0 {
+			file = args[0]
+		}
+		format := bundleFormat
+		if format == "" {
+			format = bundle.FormatOf(file)
+		}
+		Init()
+		defer Release()
+		b, err := bundle.Export(bundlePassphrase)
+		if err != nil {
+			utils.Log.Errorf("failed to export bundle: %+v", err)
+			return
+		}
+		data, err := bundle.Marshal(b, format)
+		if err != nil {
+			utils.Log.Errorf("failed to marshal bundle: %+v", err)
+			return
+		}
+		if file == "-" {
+			_, err = os.Stdout.Write(data)
+		} else {
+			err = os.WriteFile(file, data, 0600)
+		}
+		if err != nil {
+			utils.Log.Errorf("failed to write bundle: %+v", err)
+			return
+		}
+		if file != "-" {
+			utils.Log.Infof("Exported %d storages, %d metas, %d users and %d settings to %s",
+				len(b.Storages), len(b.Metas), len(b.Users), len(b.Settings)+len(b.OfflineDownload), file)
+		}
+	},
+}
+
+// ImportCmd represents the import command
+var ImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a bundle exported by [alist export]",
+	Long: `Import a bundle exported by [alist export], the bundle is read from stdin if file is -.
+The running alist needs to be restarted to apply the imported bundle.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			data []byte
+			err  error
+		)
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			utils.Log.Errorf("failed to read bundle: %+v", err)
+			return
+		}
+		b, err := bundle.Unmarshal(data)
+		if err != nil {
+			utils.Log.Errorf("failed to parse bundle: %v", err)
+			return
+		}
+		Init()
+		defer Release()
+		diff, err := bundle.Import(b, bundle.ImportOptions{
+			Mode:       importMode,
+			Passphrase: bundlePassphrase,
+			DryRun:     importDryRun,
+		})
+		if err != nil {
+			utils.Log.Errorf("failed to import bundle: %v", err)
+			return
+		}
+		for _, c := range diff.Changes {
+			line := fmt.Sprintf("%-6s %-7s %s", c.Action, c.Type, c.Key)
+			if len(c.Fields) > 0 {
+				line += " (" + strings.Join(c.Fields, ", ") + ")"
+			}
+			fmt.Println(line)
+		}
+		fmt.Printf("%d changed, %d unchanged\n", len(diff.Changes), diff.Unchanged)
+		if importDryRun {
+			utils.Log.Infof("Dry run, nothing is imported")
+		} else if len(diff.Changes) > 0 {
+			utils.Log.Infof("Imported, restart alist to apply the changes")
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(ExportCmd)
+	RootCmd.AddCommand(ImportCmd)
+	ExportCmd.Flags().StringVar(&bundleFormat, "format", "", "format of the bundle, json or yaml, by the extension of file by default")
+	for _, c := range []*cobra.Command{ExportCmd, ImportCmd} {
+		c.Flags().StringVar(&bundlePassphrase, "passphrase", "", "passphrase to encrypt or decrypt the secrets in the bundle")
+	}
+	ImportCmd.Flags().StringVar(&importMode, "mode", bundle.ModeMerge, "merge keeps the storages, metas and users not in the bundle, replace deletes them")
+	ImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "only show the changes without importing")
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// MetaCmd represents the meta command
+var MetaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Manage metas",
+	Long: `Manage metas, which are the password, the hidden files, the readme and the header of the paths.
+The running alist needs to be restarted to apply the changes.`,
+}
+
+var metaFlags model.Meta
+
+var listMetaCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all metas",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		metas, _, err := op.GetMetas(1, -1)
+		if err != nil {
+			return err
+		}
+		var rows [][]string
+		for _, m := range metas {
+			rows = append(rows, []string{strconv.Itoa(int(m.ID)), m.Path,
+				strconv.FormatBool(m.Password != ""), strconv.FormatBool(m.Write), strconv.FormatBool(m.Managed)})
+		}
+		return printTable(metas, []string{"ID", "PATH", "PASSWORD", "WRITE", "MANAGED"}, rows)
+	},
+}
+
+var getMetaCmd = &cobra.Command{
+	Use:   "get <id|path>",
+	Short: "Show a meta in json",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		meta, err := getMetaById(args[0])
+		if err != nil {
+			return err
+		}
+		return printJSON(meta)
+	},
+}
+
+var createMetaCmd = &cobra.Command{
+	Use:   "create <path>",
+	Short: "Create a meta",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		meta := metaFlags
+		meta.Path = utils.FixAndCleanPath(args[0])
+		if err := checkHide(meta.Hide); err != nil {
+			return err
+		}
+		Init()
+		defer Release()
+		if err := op.CreateMeta(&meta); err != nil {
+			return err
+		}
+		return printResult(meta, "Meta of [%s] has been created, id: %d", meta.Path, meta.ID)
+	},
+}
+
+var updateMetaCmd = &cobra.Command{
+	Use:   "update <id|path>",
+	Short: "Update a meta with the given flags, the others are kept",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		meta, err := getMeta(args[0])
+		if err != nil {
+			return err
+		}
+		flags := cmd.Flags()
+		for name, v := range map[string][2]*string{
+			"path":     {&meta.Path, &metaFlags.Path},
+			"password": {&meta.Password, &metaFlags.Password},
+			"hide":     {&meta.Hide, &metaFlags.Hide},
+			"readme":   {&meta.Readme, &metaFlags.Readme},
+			"header":   {&meta.Header, &metaFlags.Header},
+		} {
+			if flags.Changed(name) {
+				*v[0] = *v[1]
+			}
+		}
+		for name, v := range map[string][2]*bool{
+			"p-sub":      {&meta.PSub, &metaFlags.PSub},
+			"write":      {&meta.Write, &metaFlags.Write},
+			"w-sub":      {&meta.WSub, &metaFlags.WSub},
+			"h-sub":      {&meta.HSub, &metaFlags.HSub},
+			"r-sub":      {&meta.RSub, &metaFlags.RSub},
+			"header-sub": {&meta.HeaderSub, &metaFlags.HeaderSub},
+		} {
+			if flags.Changed(name) {
+				*v[0] = *v[1]
+			}
+		}
+		meta.Path = utils.FixAndCleanPath(meta.Path)
+		if err := checkHide(meta.Hide); err != nil {
+			return err
+		}
+		if err := op.UpdateMeta(meta); err != nil {
+			return err
+		}
+		return printResult(meta, "Meta of [%s] has been updated", meta.Path)
+	},
+}
+
+var deleteMetaCmd = &cobra.Command{
+	Use:   "delete <id|path>",
+	Short: "Delete a meta",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		meta, err := getMeta(args[0])
+		if err != nil {
+			return err
+		}
+		if err := op.DeleteMetaById(meta.ID); err != nil {
+			return err
+		}
+		return printResult(meta, "Meta of [%s] has been deleted", meta.Path)
+	},
+}
+
+func checkHide(hide string) error {
+	if r, err := op.CheckHide(hide); err != nil {
+		return fmt.Errorf("%s is illegal: %s", r, err.Error())
+	}
+	return nil
+}
+
+// getMetaById gets the meta by the id, or by the path if s isn't a number
+func getMetaById(s string) (*model.Meta, error) {
+	if id, err := strconv.Atoi(s); err == nil {
+		return op.GetMetaById(uint(id))
+	}
+	meta, err := op.GetMetaByPath(utils.FixAndCleanPath(s))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get meta %s", s)
+	}
+	return meta, nil
+}
+
+// getMeta gets the meta to change, the meta managed by the storages file is refused
+func getMeta(s string) (*model.Meta, error) {
+	meta, err := getMetaById(s)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Managed {
+		return nil, errors.WithMessagef(errs.ManagedByFile, "meta %s", meta.Path)
+	}
+	return meta, nil
+}
+
+func init() {
+	RootCmd.AddCommand(MetaCmd)
+	MetaCmd.AddCommand(listMetaCmd, getMetaCmd, createMetaCmd, updateMetaCmd, deleteMetaCmd)
+	manageCommand(MetaCmd)
+	for _, c := range []*cobra.Command{createMetaCmd, updateMetaCmd} {
+		f := c.Flags()
+		f.StringVar(&metaFlags.Password, "password", "", "password of the path")
+		f.BoolVar(&metaFlags.PSub, "p-sub", false, "apply the password to the sub folders")
+		f.BoolVar(&metaFlags.Write, "write", false, "allow the guest and the users without the permission to upload")
+		f.BoolVar(&metaFlags.WSub, "w-sub", false, "apply the write to the sub folders")
+		f.StringVar(&metaFlags.Hide, "hide", "", "regexps of the hidden files, one per line")
+		f.BoolVar(&metaFlags.HSub, "h-sub", false, "apply the hide to the sub folders")
+		f.StringVar(&metaFlags.Readme, "readme", "", "readme in markdown")
+		f.BoolVar(&metaFlags.RSub, "r-sub", false, "apply the readme to the sub folders")
+		f.StringVar(&metaFlags.Header, "header", "", "header in markdown")
+		f.BoolVar(&metaFlags.HeaderSub, "header-sub", false, "apply the header to the sub folders")
+	}
+	updateMetaCmd.Flags().StringVar(&metaFlags.Path, "path", "", "new path of the meta")
+}
@@ -0,0 +1,61 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/bootstrap"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fuse"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountSrc       string
+	mountOptions   []string
+	mountBlockSize int64
+	mountCacheSize int64
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount <path>",
+	Short: "Mount the storages to a local path with FUSE",
+	Long: `Mount the storages to a local path with FUSE,
+read and write requests are routed to the storages the same way as the web and webdav do.
+It blocks until the path is unmounted (e.g. by fusermount -u or Ctrl+C)`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		bootstrap.LoadStorages()
+		for !conf.StoragesLoaded {
+			time.Sleep(100 * time.Millisecond)
+		}
+		fs := &fuse.Fs{
+			RootFolder: utils.FixAndCleanPath(mountSrc),
+			BlockSize:  mountBlockSize * 1024 * 1024,
+			CacheSize:  mountCacheSize * 1024 * 1024,
+		}
+		utils.Log.Infof("mount [%s] to %s", fs.RootFolder, args[0])
+		var opts []string
+		for _, o := range mountOptions {
+			opts = append(opts, "-o", o)
+		}
+		if !fuse.Mount(fs, args[0], opts) {
+			utils.Log.Errorf("failed to mount %s", args[0])
+			return
+		}
+		utils.Log.Infof("%s unmounted", args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().StringVar(&mountSrc, "src", "/", "the alist path to mount")
+	MountCmd.Flags().StringSliceVarP(&mountOptions, "option", "o", nil, "fuse mount options, e.g. -o allow_other")
+	MountCmd.Flags().Int64Var(&mountBlockSize, "block-size", 4, "block size of the local read cache in MB")
+	MountCmd.Flags().Int64Var(&mountCacheSize, "cache-size", 1024, "max size of the local read cache in MB")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// jsonOutput is set by the --json flag of the management commands, the results are printed
+// to stdout as json for scripting, and the logs go to stderr or the log file
+var jsonOutput bool
+
+// printResult prints v as json with --json, or logs the message otherwise
+func printResult(v interface{}, format string, args ...interface{}) error {
+	if jsonOutput {
+		return printJSON(v)
+	}
+	utils.Log.Infof(format, args...)
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printTable prints the rows as json with --json, or as a table otherwise
+func printTable(v interface{}, header []string, rows [][]string) error {
+	if jsonOutput {
+		return printJSON(v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// manageCommand adds the --json flag to cmd, and makes it and its subcommands exit with 1 on errors
+// without the usage, the error is printed once by Execute
+func manageCommand(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print the results as json")
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
+	"github.com/alist-org/alist/v3/server/ftp"
+	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,8 +38,16 @@ the address is defined in config file`,
 			time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 		}
 		bootstrap.InitOfflineDownloadTools()
+		bootstrap.InitCache()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
+		bootstrap.InitTrash()
+		bootstrap.InitAudit()
+		bootstrap.InitSyncJobs()
+		bootstrap.InitSearchIndexes()
+		bootstrap.InitIndexRefresh()
+		bootstrap.InitHealthCheck()
+		bootstrap.InitDeclarative()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
@@ -112,6 +122,34 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		var ftpSrv *ftp.Server
+		if conf.Conf.FTP.Enable {
+			var err error
+			ftpSrv, err = ftp.NewServer()
+			if err != nil {
+				utils.Log.Fatalf("failed to init ftp server: %+v", err)
+			}
+			utils.Log.Infof("start FTP server @ %s", ftpSrv.Addr)
+			go func() {
+				if err := ftpSrv.ListenAndServe(); err != nil {
+					utils.Log.Fatalf("failed to start ftp server: %s", err.Error())
+				}
+			}()
+		}
+		var sftpSrv *sftp.Server
+		if conf.Conf.SFTP.Enable {
+			var err error
+			sftpSrv, err = sftp.NewServer()
+			if err != nil {
+				utils.Log.Fatalf("failed to init sftp server: %+v", err)
+			}
+			utils.Log.Infof("start SFTP server @ %s", sftpSrv.Addr)
+			go func() {
+				if err := sftpSrv.ListenAndServe(); err != nil {
+					utils.Log.Fatalf("failed to start sftp server: %s", err.Error())
+				}
+			}()
+		}
 		// Wait for interrupt signal to gracefully shutdown the server with
 		// a timeout of 1 second.
 		quit := make(chan os.Signal, 1)
@@ -152,6 +190,24 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		if ftpSrv != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := ftpSrv.Shutdown(); err != nil {
+					utils.Log.Error("FTP server shutdown err: ", err)
+				}
+			}()
+		}
+		if sftpSrv != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := sftpSrv.Shutdown(); err != nil {
+					utils.Log.Error("SFTP server shutdown err: ", err)
+				}
+			}()
+		}
 		wg.Wait()
 		utils.Log.Println("Server exit")
 	},
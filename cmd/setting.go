@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// SettingCmd represents the setting command
+var SettingCmd = &cobra.Command{
+	Use:   "setting",
+	Short: "Manage settings",
+	Long:  `Manage settings, the running alist needs to be restarted to apply the changes.`,
+}
+
+var settingGroup int
+
+var listSettingCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the settings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		var (
+			items []model.SettingItem
+			err   error
+		)
+		if cmd.Flags().Changed("group") {
+			items, err = op.GetSettingItemsByGroup(settingGroup)
+		} else {
+			items, err = op.GetSettingItems()
+		}
+		if err != nil {
+			return err
+		}
+		var rows [][]string
+		for _, item := range items {
+			value := item.Value
+			if item.Flag == model.PRIVATE {
+				value = "******"
+			}
+			rows = append(rows, []string{item.Key, strconv.Itoa(item.Group), item.Type, value})
+		}
+		return printTable(items, []string{"KEY", "GROUP", "TYPE", "VALUE"}, rows)
+	},
+}
+
+var getSettingCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Show the value of a setting, or the setting in json with --json",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		item, err := op.GetSettingItemByKey(args[0])
+		if err != nil {
+			return errors.WithMessagef(err, "failed to get setting %s", args[0])
+		}
+		if jsonOutput {
+			return printJSON(item)
+		}
+		_, err = cmd.OutOrStdout().Write([]byte(item.Value + "\n"))
+		return err
+	},
+}
+
+var setSettingCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set the value of a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		item, err := op.GetSettingItemByKey(args[0])
+		if err != nil {
+			return errors.WithMessagef(err, "failed to get setting %s", args[0])
+		}
+		if item.Flag == model.READONLY || item.IsDeprecated() {
+			return errors.Errorf("setting %s is readonly or deprecated", item.Key)
+		}
+		if err := checkSettingValue(item, args[1]); err != nil {
+			return err
+		}
+		// the cached item isn't changed if the hook fails
+		newItem := *item
+		newItem.Value = args[1]
+		if err := op.SaveSettingItem(&newItem); err != nil {
+			return err
+		}
+		return printResult(newItem, "Setting [%s] has been set", newItem.Key)
+	},
+}
+
+// checkSettingValue checks the value against the type and the options of the setting
+func checkSettingValue(item *model.SettingItem, value string) error {
+	switch item.Type {
+	case conf.TypeBool:
+		if value != "true" && value != "false" {
+			return errors.Errorf("%s must be true or false", item.Key)
+		}
+	case conf.TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.Errorf("%s must be a number", item.Key)
+		}
+	case conf.TypeSelect:
+		if item.Options != "" && !strings.Contains(","+item.Options+",", ","+value+",") {
+			return errors.Errorf("%s must be one of %s", item.Key, item.Options)
+		}
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(SettingCmd)
+	SettingCmd.AddCommand(listSettingCmd, getSettingCmd, setSettingCmd)
+	manageCommand(SettingCmd)
+	listSettingCmd.Flags().IntVar(&settingGroup, "group", 0, "only list the settings in the group")
+}
@@ -4,14 +4,22 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -22,42 +30,344 @@ var storageCmd = &cobra.Command{
 }
 
 var disableStorageCmd = &cobra.Command{
-	Use:   "disable",
+	Use:   "disable <id|mount path>",
 	Short: "Disable a storage",
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) < 1 {
-			utils.Log.Errorf("mount path is required")
-			return
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		storage, err := getStorage(args[0])
+		if err != nil {
+			return err
+		}
+		storage.Disabled = true
+		if err := db.UpdateStorage(storage); err != nil {
+			return errors.WithMessage(err, "failed to update storage")
 		}
-		mountPath := args[0]
+		return printResult(storage, "Storage with mount path [%s] have been disabled", storage.MountPath)
+	},
+}
+
+var enableStorageCmd = &cobra.Command{
+	Use:   "enable <id|mount path>",
+	Short: "Enable a storage",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		Init()
 		defer Release()
-		storage, err := db.GetStorageByMountPath(mountPath)
+		storage, err := getStorage(args[0])
 		if err != nil {
-			utils.Log.Errorf("failed to query storage: %+v", err)
-		} else {
-			storage.Disabled = true
-			err = db.UpdateStorage(storage)
-			if err != nil {
-				utils.Log.Errorf("failed to update storage: %+v", err)
-			} else {
-				utils.Log.Infof("Storage with mount path [%s] have been disabled", mountPath)
+			return err
+		}
+		if err := op.EnableStorage(context.Background(), storage.ID); err != nil {
+			return err
+		}
+		storage, err = db.GetStorageById(storage.ID)
+		if err != nil {
+			return err
+		}
+		return printResult(storage, "Storage with mount path [%s] have been enabled, status: %s", storage.MountPath, storage.Status)
+	},
+}
+
+var storageFlags model.Storage
+
+var createStorageCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a storage",
+	Long: `Create a storage with the driver and the addition in json, the addition is checked against
+the items of the driver, which can be shown by [alist storage driver <name>].`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		storage := storageFlags
+		if storage.Driver == "" || storage.MountPath == "" {
+			return errors.New("--driver and --mount-path are required")
+		}
+		Init()
+		defer Release()
+		if err := checkManagedMountPath(storage.MountPath); err != nil {
+			return err
+		}
+		if err := op.ValidateStorage(&storage); err != nil {
+			return err
+		}
+		ctx := context.Background()
+		// op.CreateStorage loads the storage even if it's disabled
+		id, err := op.CreateStorage(ctx, storage)
+		if err == nil && storage.Disabled {
+			err = op.DisableStorage(ctx, id)
+		}
+		if id == 0 {
+			return err
+		}
+		if err != nil {
+			utils.Log.Warnf("storage is created with error: %v", err)
+		}
+		created, err := db.GetStorageById(id)
+		if err != nil {
+			return err
+		}
+		return printResult(created, "Storage with mount path [%s] has been created, id: %d, status: %s",
+			created.MountPath, created.ID, created.Status)
+	},
+}
+
+var updateStorageCmd = &cobra.Command{
+	Use:   "update <id|mount path>",
+	Short: "Update a storage",
+	Long: `Update a storage with the given flags, the others are kept.
+The addition is merged into the current one, and a key set to null is removed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		storage, err := getStorage(args[0])
+		if err != nil {
+			return err
+		}
+		if err := mergeStorageFlags(cmd, storage); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("mount-path") {
+			if err := checkManagedMountPath(storage.MountPath); err != nil {
+				return err
+			}
+		}
+		if err := op.ValidateStorage(storage); err != nil {
+			return err
+		}
+		old, err := db.GetStorageById(storage.ID)
+		if err != nil {
+			return err
+		}
+		// op.UpdateStorage reloads the enabled storage, so it's loaded first, and the disabled
+		// one is enabled or disabled after the update, like the storages file does
+		ctx := context.Background()
+		disabled := storage.Disabled
+		storage.Disabled = old.Disabled
+		if !old.Disabled {
+			_ = op.LoadStorage(ctx, *old)
+			if disabled {
+				if err := op.DisableStorage(ctx, old.ID); err != nil {
+					return err
+				}
+				storage.Disabled = true
+				storage.SetStatus(op.DISABLED)
 			}
 		}
+		err = op.UpdateStorage(ctx, *storage)
+		if err == nil && old.Disabled && !disabled {
+			err = op.EnableStorage(ctx, old.ID)
+		}
+		if err != nil {
+			utils.Log.Warnf("storage is updated with error: %v", err)
+		}
+		updated, err := db.GetStorageById(storage.ID)
+		if err != nil {
+			return err
+		}
+		return printResult(updated, "Storage with mount path [%s] has been updated, status: %s", updated.MountPath, updated.Status)
 	},
 }
 
+var deleteStorageCmd = &cobra.Command{
+	Use:   "delete <id|mount path>",
+	Short: "Delete a storage",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		storage, err := getStorage(args[0])
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		// op.DeleteStorageById drops the enabled storage
+		if !storage.Disabled {
+			_ = op.LoadStorage(ctx, *storage)
+		}
+		if err := op.DeleteStorageById(ctx, storage.ID); err != nil {
+			return err
+		}
+		return printResult(storage, "Storage with mount path [%s] has been deleted", storage.MountPath)
+	},
+}
+
+var copyStorageCmd = &cobra.Command{
+	Use:   "copy <id|mount path>",
+	Short: "Copy a storage, the copy is mounted with the suffix _copyed2",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		storage, err := getStorageById(args[0])
+		if err != nil {
+			return err
+		}
+		id, err := op.CopyStorageById(context.Background(), storage.ID)
+		if id == 0 {
+			return err
+		}
+		if err != nil {
+			utils.Log.Warnf("storage is copied with error: %v", err)
+		}
+		copied, err := db.GetStorageById(id)
+		if err != nil {
+			return err
+		}
+		return printResult(copied, "Storage with mount path [%s] has been copied to [%s], id: %d",
+			storage.MountPath, copied.MountPath, copied.ID)
+	},
+}
+
+var getStorageCmd = &cobra.Command{
+	Use:   "get <id|mount path>",
+	Short: "Show a storage in json",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		storage, err := getStorageById(args[0])
+		if err != nil {
+			return err
+		}
+		return printJSON(storage)
+	},
+}
+
+var driverStorageCmd = &cobra.Command{
+	Use:   "driver [name]",
+	Short: "List the drivers, or show the config and the items of a driver in json",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			names := op.GetDriverNames()
+			sort.Strings(names)
+			if jsonOutput {
+				return printJSON(names)
+			}
+			fmt.Println(strings.Join(names, "\n"))
+			return nil
+		}
+		info, ok := op.GetDriverInfoMap()[args[0]]
+		if !ok {
+			return errors.Errorf("no driver named: %s", args[0])
+		}
+		return printJSON(info)
+	},
+}
+
+// getStorageById gets the storage by the id, or by the mount path if s isn't a number
+func getStorageById(s string) (*model.Storage, error) {
+	if id, err := strconv.Atoi(s); err == nil {
+		return db.GetStorageById(uint(id))
+	}
+	storage, err := db.GetStorageByMountPath(utils.FixAndCleanPath(s))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get storage %s", s)
+	}
+	return storage, nil
+}
+
+// getStorage gets the storage to change, the storage managed by the storages file is refused
+func getStorage(s string) (*model.Storage, error) {
+	storage, err := getStorageById(s)
+	if err != nil {
+		return nil, err
+	}
+	if storage.Managed {
+		return nil, errors.WithMessagef(errs.ManagedByFile, "storage %s", storage.MountPath)
+	}
+	return storage, nil
+}
+
+// checkManagedMountPath refuses to mount a storage at the mount path of a storage managed by the storages file,
+// the balanced ones sharing the mount path included
+func checkManagedMountPath(mountPath string) error {
+	storages, err := db.GetAllStorages()
+	if err != nil {
+		return err
+	}
+	actual := utils.GetActualMountPath(utils.FixAndCleanPath(mountPath))
+	for _, s := range storages {
+		if s.Managed && utils.GetActualMountPath(s.MountPath) == actual {
+			return errors.WithMessagef(errs.ManagedByFile, "storage %s", s.MountPath)
+		}
+	}
+	return nil
+}
+
+// mergeStorageFlags sets the changed flags to the storage, the addition is merged
+func mergeStorageFlags(cmd *cobra.Command, storage *model.Storage) error {
+	flags := cmd.Flags()
+	if flags.Changed("driver") && storageFlags.Driver != storage.Driver {
+		return errors.New("driver cannot be changed")
+	}
+	set := func(name string, dst, src interface{}) {
+		if !flags.Changed(name) {
+			return
+		}
+		switch d := dst.(type) {
+		case *string:
+			*d = *src.(*string)
+		case *int:
+			*d = *src.(*int)
+		case *bool:
+			*d = *src.(*bool)
+		}
+	}
+	set("mount-path", &storage.MountPath, &storageFlags.MountPath)
+	set("order", &storage.Order, &storageFlags.Order)
+	set("remark", &storage.Remark, &storageFlags.Remark)
+	set("cache-expiration", &storage.CacheExpiration, &storageFlags.CacheExpiration)
+	set("webdav-policy", &storage.WebdavPolicy, &storageFlags.WebdavPolicy)
+	set("down-proxy-url", &storage.DownProxyUrl, &storageFlags.DownProxyUrl)
+	set("web-proxy", &storage.WebProxy, &storageFlags.WebProxy)
+	set("enable-sign", &storage.EnableSign, &storageFlags.EnableSign)
+	set("disabled", &storage.Disabled, &storageFlags.Disabled)
+	set("weight", &storage.Weight, &storageFlags.Weight)
+	set("balance-strategy", &storage.BalanceStrategy, &storageFlags.BalanceStrategy)
+	set("order-by", &storage.OrderBy, &storageFlags.OrderBy)
+	set("order-direction", &storage.OrderDirection, &storageFlags.OrderDirection)
+	if !flags.Changed("addition") {
+		return nil
+	}
+	addition := make(map[string]interface{})
+	if storage.Addition != "" {
+		if err := utils.Json.UnmarshalFromString(storage.Addition, &addition); err != nil {
+			return errors.Wrap(err, "current addition is not a json object")
+		}
+	}
+	var changed map[string]interface{}
+	if err := utils.Json.UnmarshalFromString(storageFlags.Addition, &changed); err != nil {
+		return errors.Wrap(err, "addition is not a json object")
+	}
+	for k, v := range changed {
+		if v == nil {
+			delete(addition, k)
+		} else {
+			addition[k] = v
+		}
+	}
+	data, err := utils.Json.MarshalToString(addition)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	storage.Addition = data
+	return nil
+}
+
 var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
-type model struct {
+type tableModel struct {
 	table table.Model
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (m tableModel) Init() tea.Cmd { return nil }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m tableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -80,7 +390,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m model) View() string {
+func (m tableModel) View() string {
 	return baseStyle.Render(m.table.View()) + "\n"
 }
 
@@ -94,6 +404,10 @@ var listStorageCmd = &cobra.Command{
 		storages, _, err := db.GetStorages(1, -1)
 		if err != nil {
 			utils.Log.Errorf("failed to query storages: %+v", err)
+		} else if jsonOutput {
+			if err := printJSON(storages); err != nil {
+				utils.Log.Errorf("failed to print storages: %+v", err)
+			}
 		} else {
 			utils.Log.Infof("Found %d storages", len(storages))
 			columns := []table.Column{
@@ -136,7 +450,7 @@ var listStorageCmd = &cobra.Command{
 				Bold(false)
 			t.SetStyles(s)
 
-			m := model{t}
+			m := tableModel{t}
 			if _, err := tea.NewProgram(m).Run(); err != nil {
 				utils.Log.Errorf("failed to run program: %+v", err)
 				os.Exit(1)
@@ -148,8 +462,27 @@ var listStorageCmd = &cobra.Command{
 func init() {
 
 	RootCmd.AddCommand(storageCmd)
-	storageCmd.AddCommand(disableStorageCmd)
-	storageCmd.AddCommand(listStorageCmd)
+	storageCmd.AddCommand(disableStorageCmd, enableStorageCmd, listStorageCmd, createStorageCmd,
+		updateStorageCmd, deleteStorageCmd, copyStorageCmd, getStorageCmd, driverStorageCmd)
+	manageCommand(storageCmd)
+	for _, c := range []*cobra.Command{createStorageCmd, updateStorageCmd} {
+		f := c.Flags()
+		f.StringVar(&storageFlags.Driver, "driver", "", "driver of the storage")
+		f.StringVar(&storageFlags.MountPath, "mount-path", "", "mount path of the storage")
+		f.StringVar(&storageFlags.Addition, "addition", "{}", "addition of the driver in json")
+		f.IntVar(&storageFlags.Order, "order", 0, "order of the storage")
+		f.StringVar(&storageFlags.Remark, "remark", "", "remark of the storage")
+		f.IntVar(&storageFlags.CacheExpiration, "cache-expiration", 30, "cache expiration in minutes")
+		f.StringVar(&storageFlags.WebdavPolicy, "webdav-policy", "", "webdav policy, the default of the driver if empty")
+		f.StringVar(&storageFlags.DownProxyUrl, "down-proxy-url", "", "download proxy url")
+		f.BoolVar(&storageFlags.WebProxy, "web-proxy", false, "proxy the downloads of the web")
+		f.BoolVar(&storageFlags.EnableSign, "enable-sign", false, "sign the download links")
+		f.BoolVar(&storageFlags.Disabled, "disabled", false, "disable the storage")
+		f.IntVar(&storageFlags.Weight, "weight", 0, "weight in the weighted balance")
+		f.StringVar(&storageFlags.BalanceStrategy, "balance-strategy", "", "balance strategy of the storages sharing the mount path")
+		f.StringVar(&storageFlags.OrderBy, "order-by", "", "order by, only for the drivers sorting locally")
+		f.StringVar(&storageFlags.OrderDirection, "order-direction", "", "order direction, only for the drivers sorting locally")
+	}
 	storageCmd.PersistentFlags().IntVarP(&storageTableHeight, "height", "H", 10, "Table height")
 	// Here you will define your flags and configuration settings.
 
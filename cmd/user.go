@@ -3,13 +3,20 @@ package cmd
 import (
 	"crypto/tls"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 )
 
 func DelAdminCacheOnline() {
@@ -50,3 +57,186 @@ func DelUserCacheOnline(username string) {
 	}
 	utils.Log.Debugf("[del_user_cache_online] del user [%s] cache success", username)
 }
+
+// UserCmd represents the user command
+var UserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage users",
+}
+
+var userFlags struct {
+	password   string
+	basePath   string
+	permission string
+	disabled   bool
+}
+
+// permissionNames are the names of the permission bits, in the order of the bits
+var permissionNames = []string{"see-hides", "access-without-password", "offline-download", "write", "rename",
+	"move", "copy", "remove", "webdav-read", "webdav-manage", "ftp-access", "ftp-manage", "share"}
+
+// parsePermission parses the permission in number or the comma separated names of the bits
+func parsePermission(s string) (int32, error) {
+	if p, err := strconv.ParseInt(s, 10, 32); err == nil {
+		return int32(p), nil
+	}
+	var p int32
+	for _, name := range strings.Split(s, ",") {
+		i := slices.Index(permissionNames, strings.TrimSpace(name))
+		if i < 0 {
+			return 0, errors.Errorf("unknown permission %s, must be a number or some of %s",
+				name, strings.Join(permissionNames, ","))
+		}
+		p |= 1 << i
+	}
+	return p, nil
+}
+
+var listUserCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all users",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		users, _, err := op.GetUsers(1, -1)
+		if err != nil {
+			return err
+		}
+		var rows [][]string
+		for _, u := range users {
+			rows = append(rows, []string{strconv.Itoa(int(u.ID)), u.Username, u.BasePath,
+				strconv.Itoa(int(u.Permission)), strconv.FormatBool(!u.Disabled)})
+		}
+		return printTable(users, []string{"ID", "USERNAME", "BASE PATH", "PERMISSION", "ENABLED"}, rows)
+	},
+}
+
+var getUserCmd = &cobra.Command{
+	Use:   "get <username>",
+	Short: "Show a user in json",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		user, err := op.GetUserByName(args[0])
+		if err != nil {
+			return err
+		}
+		return printJSON(user)
+	},
+}
+
+var createUserCmd = &cobra.Command{
+	Use:   "create <username>",
+	Short: "Create a general user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if userFlags.password == "" {
+			return errors.New("--password is required")
+		}
+		permission, err := parsePermission(userFlags.permission)
+		if err != nil {
+			return err
+		}
+		Init()
+		defer Release()
+		user := model.User{
+			Username:   args[0],
+			BasePath:   utils.FixAndCleanPath(userFlags.basePath),
+			Role:       model.GENERAL,
+			Disabled:   userFlags.disabled,
+			Permission: permission,
+			Authn:      "[]",
+		}
+		user.SetPassword(userFlags.password)
+		if err := op.CreateUser(&user); err != nil {
+			return err
+		}
+		return printResult(user, "User [%s] has been created, id: %d", user.Username, user.ID)
+	},
+}
+
+var deleteUserCmd = &cobra.Command{
+	Use:   "delete <username>",
+	Short: "Delete a general user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		user, err := getUser(args[0])
+		if err != nil {
+			return err
+		}
+		if err := op.DeleteUserById(user.ID); err != nil {
+			return err
+		}
+		DelUserCacheOnline(user.Username)
+		return printResult(user, "User [%s] has been deleted", user.Username)
+	},
+}
+
+var setPermissionCmd = &cobra.Command{
+	Use:   "set-permission <username> <permission>",
+	Short: "Set the permission of a user",
+	Long: fmt.Sprintf(`Set the permission of a user, the permission is a number or the comma separated names of
+the bits: %s.`, strings.Join(permissionNames, ", ")),
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		permission, err := parsePermission(args[1])
+		if err != nil {
+			return err
+		}
+		return updateUser(args[0], func(u *model.User) {
+			u.Permission = permission
+		})
+	},
+}
+
+var setBasePathCmd = &cobra.Command{
+	Use:   "set-base-path <username> <path>",
+	Short: "Set the base path of a user",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateUser(args[0], func(u *model.User) {
+			u.BasePath = utils.FixAndCleanPath(args[1])
+		})
+	},
+}
+
+// getUser gets the user to change, the user managed by the storages file is refused
+func getUser(username string) (*model.User, error) {
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Managed {
+		return nil, errors.WithMessagef(errs.ManagedByFile, "user %s", username)
+	}
+	return user, nil
+}
+
+func updateUser(username string, update func(u *model.User)) error {
+	Init()
+	defer Release()
+	user, err := getUser(username)
+	if err != nil {
+		return err
+	}
+	update(user)
+	if err := op.UpdateUser(user); err != nil {
+		return err
+	}
+	DelUserCacheOnline(user.Username)
+	return printResult(user, "User [%s] has been updated", user.Username)
+}
+
+func init() {
+	RootCmd.AddCommand(UserCmd)
+	UserCmd.AddCommand(listUserCmd, getUserCmd, createUserCmd, deleteUserCmd, setPermissionCmd, setBasePathCmd)
+	manageCommand(UserCmd)
+	createUserCmd.Flags().StringVar(&userFlags.password, "password", "", "password of the user")
+	createUserCmd.Flags().StringVar(&userFlags.basePath, "base-path", "/", "base path of the user")
+	createUserCmd.Flags().StringVar(&userFlags.permission, "permission", "0", "permission of the user, see [alist user set-permission --help]")
+	createUserCmd.Flags().BoolVar(&userFlags.disabled, "disabled", false, "disable the user")
+}
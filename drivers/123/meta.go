@@ -15,9 +15,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "123Pan",
-	DefaultRoot: "0",
-	LocalSort:   true,
+	Name:         "123Pan",
+	DefaultRoot:  "0",
+	LocalSort:    true,
+	MemCacheOnly: true,
 }
 
 func init() {
@@ -22,9 +22,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "189CloudPC",
-	DefaultRoot: "-11",
-	CheckStatus: true,
+	Name:         "189CloudPC",
+	DefaultRoot:  "-11",
+	CheckStatus:  true,
+	MemCacheOnly: true,
 }
 
 func init() {
@@ -31,6 +31,7 @@ var config = driver.Config{
 	NeedMs:            false,
 	DefaultRoot:       "root",
 	NoOverwriteUpload: true,
+	MemCacheOnly:      true,
 }
 var API_URL = "https://openapi.alipan.com"
 
@@ -29,6 +29,7 @@ var config = driver.Config{
 	CheckStatus:       false,
 	Alert:             "",
 	NoOverwriteUpload: false,
+	MemCacheOnly:      true,
 }
 
 func init() {
@@ -30,9 +30,10 @@ func (a *Addition) IsAccount() bool {
 }
 
 var config = driver.Config{
-	Name:        "Lanzou",
-	LocalSort:   true,
-	DefaultRoot: "-1",
+	Name:         "Lanzou",
+	LocalSort:    true,
+	DefaultRoot:  "-1",
+	MemCacheOnly: true,
 }
 
 func init() {
@@ -23,6 +23,7 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/times"
 	cp "github.com/otiai10/copy"
+	"github.com/shirou/gopsutil/v3/disk"
 	log "github.com/sirupsen/logrus"
 	_ "golang.org/x/image/webp"
 )
@@ -35,6 +36,8 @@ type Local struct {
 	// zero means no limit
 	thumbConcurrency int
 	thumbTokenBucket TokenBucket
+
+	watcher *watcher
 }
 
 func (d *Local) Config() driver.Config {
@@ -79,10 +82,25 @@ func (d *Local) Init(ctx context.Context) error {
 	} else {
 		d.thumbTokenBucket = NewStaticTokenBucketWithMigration(d.thumbTokenBucket, d.thumbConcurrency)
 	}
+	if d.watcher != nil {
+		d.watcher.close()
+		d.watcher = nil
+	}
+	if d.WatchChanges {
+		w, err := newWatcher(d)
+		if err != nil {
+			return fmt.Errorf("failed watch changes: %w", err)
+		}
+		d.watcher = w
+	}
 	return nil
 }
 
 func (d *Local) Drop(ctx context.Context) error {
+	if d.watcher != nil {
+		d.watcher.close()
+		d.watcher = nil
+	}
 	return nil
 }
 
@@ -328,4 +346,17 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+func (d *Local) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	usage, err := disk.UsageWithContext(ctx, d.GetRootPath())
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: int64(usage.Total),
+		UsedSpace:  int64(usage.Used),
+		FreeSpace:  int64(usage.Free),
+	}, nil
+}
+
 var _ driver.Driver = (*Local)(nil)
+var _ driver.WithDetails = (*Local)(nil)
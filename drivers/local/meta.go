@@ -13,6 +13,7 @@ type Addition struct {
 	ShowHidden       bool   `json:"show_hidden" default:"true" required:"false" help:"show hidden directories and files"`
 	MkdirPerm        string `json:"mkdir_perm" default:"777"`
 	RecycleBinPath   string `json:"recycle_bin_path" default:"delete permanently" help:"path to recycle bin, delete permanently if empty or keep 'delete permanently'"`
+	WatchChanges     bool   `json:"watch_changes" default:"false" help:"watch the changes made outside alist with inotify to update the search index"`
 }
 
 var config = driver.Config{
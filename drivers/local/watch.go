@@ -0,0 +1,142 @@
+package local
+
+import (
+	"io/fs"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchFlushInterval is how long the changes are collected before being reported,
+// so that a file written in many chunks is reported once
+const watchFlushInterval = time.Second
+
+// watcher reports the changes under the root folder with inotify,
+// every dir is watched since inotify is not recursive
+type watcher struct {
+	d       *Local
+	fsw     *fsnotify.Watcher
+	mu      sync.Mutex
+	pending map[string]op.ObjChangeType
+	done    chan struct{}
+}
+
+func newWatcher(d *Local) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{
+		d:       d,
+		fsw:     fsw,
+		pending: make(map[string]op.ObjChangeType),
+		done:    make(chan struct{}),
+	}
+	w.addDir(d.GetRootPath())
+	go w.run()
+	return w, nil
+}
+
+// addDir watches dir and its sub dirs
+func (w *watcher) addDir(dir string) {
+	_ = filepath.WalkDir(dir, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !e.IsDir() {
+			return nil
+		}
+		if path != dir && w.ignored(e.Name()) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			log.Warnf("failed watch %s: %+v", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *watcher) ignored(name string) bool {
+	return !w.d.ShowHidden && strings.HasPrefix(name, ".")
+}
+
+func (w *watcher) run() {
+	ticker := time.NewTicker(watchFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case e, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handle(e)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("local watcher of %s error: %+v", w.d.MountPath, err)
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+func (w *watcher) handle(e fsnotify.Event) {
+	if w.ignored(filepath.Base(e.Name)) {
+		return
+	}
+	var typ op.ObjChangeType
+	switch {
+	case e.Has(fsnotify.Create):
+		typ = op.ObjCreate
+		if info, err := os.Stat(e.Name); err == nil && info.IsDir() {
+			w.addDir(e.Name)
+		}
+	case e.Has(fsnotify.Write):
+		typ = op.ObjCreate
+	case e.Has(fsnotify.Remove), e.Has(fsnotify.Rename):
+		// the new name of a renamed obj comes with a create event
+		typ = op.ObjRemove
+	default:
+		return
+	}
+	rel, err := filepath.Rel(w.d.GetRootPath(), e.Name)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	// the last change decides, since a created obj is read again when it's reported
+	w.pending[utils.FixAndCleanPath(filepath.ToSlash(rel))] = typ
+	w.mu.Unlock()
+}
+
+func (w *watcher) flush() {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]op.ObjChangeType)
+	w.mu.Unlock()
+	for path, typ := range pending {
+		log.Debugf("local watcher of %s: %s changed", w.d.MountPath, path)
+		op.HandleObjChangeHook(op.ObjChange{
+			Type: typ,
+			Path: stdpath.Join(w.d.MountPath, path),
+		})
+	}
+}
+
+func (w *watcher) close() {
+	close(w.done)
+	if err := w.fsw.Close(); err != nil {
+		log.Warnf("failed close local watcher of %s: %+v", w.d.MountPath, err)
+	}
+}
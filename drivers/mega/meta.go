@@ -16,9 +16,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:      "Mega_nz",
-	LocalSort: true,
-	OnlyLocal: true,
+	Name:         "Mega_nz",
+	LocalSort:    true,
+	OnlyLocal:    true,
+	MemCacheOnly: true,
 }
 
 func init() {
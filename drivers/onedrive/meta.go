@@ -20,9 +20,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "Onedrive",
-	LocalSort:   true,
-	DefaultRoot: "/",
+	Name:         "Onedrive",
+	LocalSort:    true,
+	DefaultRoot:  "/",
+	MemCacheOnly: true,
 }
 
 func init() {
@@ -17,9 +17,10 @@ type Addition struct {
 }
 
 var config = driver.Config{
-	Name:        "OnedriveAPP",
-	LocalSort:   true,
-	DefaultRoot: "/",
+	Name:         "OnedriveAPP",
+	LocalSort:    true,
+	DefaultRoot:  "/",
+	MemCacheOnly: true,
 }
 
 func init() {
@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 	"io"
 	"net/url"
+	"os"
 	stdpath "path"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -181,4 +183,72 @@ func (d *S3) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreame
 	return err
 }
 
+// minPartSize is the minimum size of the parts except the last one
+const minPartSize = 5 * 1024 * 1024
+
+// PutParts uploads the staged parts as the parts of a multipart upload, it falls back to Put
+// if any part but the last one is too small for s3
+func (d *S3) PutParts(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, parts []model.FilePart, up driver.UpdateProgress) error {
+	if len(parts) < 2 || len(parts) > s3manager.MaxUploadParts {
+		return d.Put(ctx, dstDir, stream, up)
+	}
+	for _, p := range parts[:len(parts)-1] {
+		if p.Size < minPartSize {
+			return d.Put(ctx, dstDir, stream, up)
+		}
+	}
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	contentType := stream.GetMimetype()
+	upload, err := d.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      &d.Bucket,
+		Key:         &key,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return err
+	}
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for i, p := range parts {
+		etag, err := d.uploadPart(ctx, upload, int64(i+1), p)
+		if err != nil {
+			_, _ = d.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   upload.Bucket,
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			})
+			return err
+		}
+		completed = append(completed, &s3.CompletedPart{ETag: etag, PartNumber: aws.Int64(int64(i + 1))})
+		up(float64(i+1) * 100 / float64(len(parts)))
+	}
+	_, err = d.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          upload.Bucket,
+		Key:             upload.Key,
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+func (d *S3) uploadPart(ctx context.Context, upload *s3.CreateMultipartUploadOutput, number int64, part model.FilePart) (*string, error) {
+	f, err := os.Open(part.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := d.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:        upload.Bucket,
+		Key:           upload.Key,
+		UploadId:      upload.UploadId,
+		PartNumber:    &number,
+		Body:          f,
+		ContentLength: &part.Size,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.ETag, nil
+}
+
 var _ driver.Driver = (*S3)(nil)
+var _ driver.PutParts = (*S3)(nil)
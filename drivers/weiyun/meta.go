@@ -20,6 +20,7 @@ var config = driver.Config{
 	CheckStatus:       true,
 	Alert:             "",
 	NoOverwriteUpload: false,
+	MemCacheOnly:      true,
 }
 
 func init() {
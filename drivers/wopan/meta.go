@@ -28,6 +28,7 @@ var config = driver.Config{
 	CheckStatus:       false,
 	Alert:             "",
 	NoOverwriteUpload: true,
+	MemCacheOnly:      true,
 }
 
 func init() {
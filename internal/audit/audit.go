@@ -0,0 +1,108 @@
+// Package audit records file operations, logins and admin changes into the database
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func save(l *model.AuditLog, err error) {
+	if !setting.GetBool(conf.AuditEnabled) {
+		return
+	}
+	l.Success = err == nil
+	if err != nil {
+		if l.Message != "" {
+			l.Message += ": "
+		}
+		l.Message += err.Error()
+	}
+	l.CreatedAt = time.Now()
+	if err := db.CreateAuditLog(l); err != nil {
+		log.Errorf("failed save audit log: %+v", err)
+	}
+}
+
+// IP returns the client ip of the request which ctx belongs to
+func IP(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.ClientIP()
+	}
+	ip, _ := ctx.Value("ip").(string)
+	return ip
+}
+
+func storageOf(path string) string {
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return ""
+	}
+	return storage.GetStorage().MountPath
+}
+
+// Fs records a file operation, the user and the ip are taken from ctx
+func Fs(ctx context.Context, action, srcPath, dstPath string, err error) {
+	l := &model.AuditLog{
+		IP:      IP(ctx),
+		Action:  action,
+		SrcPath: srcPath,
+		DstPath: dstPath,
+		Storage: storageOf(srcPath),
+	}
+	if l.Storage == "" && dstPath != "" {
+		l.Storage = storageOf(dstPath)
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		l.UserId = user.ID
+		l.Username = user.Username
+	}
+	save(l, err)
+}
+
+// Login records a login attempt, method is the way of login such as password or ldap
+func Login(username, ip, method string, err error) {
+	l := &model.AuditLog{
+		Username: username,
+		IP:       ip,
+		Action:   model.AuditLogin,
+		Message:  method,
+	}
+	if user, e := op.GetUserByName(username); e == nil {
+		l.UserId = user.ID
+	}
+	save(l, err)
+}
+
+// Admin records a change made through the admin api, target is the changed
+// object such as the mount path of a storage or the name of a user
+func Admin(c *gin.Context, action, target string, err error) {
+	l := &model.AuditLog{
+		IP:      c.ClientIP(),
+		Action:  action,
+		SrcPath: target,
+	}
+	if user, ok := c.Value("user").(*model.User); ok {
+		l.UserId = user.ID
+		l.Username = user.Username
+	}
+	save(l, err)
+}
+
+// Purge deletes the audit logs which are older than the retention days
+func Purge() {
+	days := setting.GetInt(conf.AuditRetentionDays, 90)
+	if days <= 0 {
+		return
+	}
+	if err := db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		log.Errorf("failed purge audit logs: %+v", err)
+	}
+}
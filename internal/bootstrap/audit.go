@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var auditCron *cron.Cron
+
+// InitAudit starts the job which deletes the audit logs out of retention
+func InitAudit() {
+	auditCron = cron.NewCron(time.Hour)
+	auditCron.Do(audit.Purge)
+}
@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/cache"
+	"github.com/alist-org/alist/v3/internal/conf"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitCache opens the configured cache backend, the caches are kept in memory if it fails
+func InitCache() {
+	if err := cache.Init(); err != nil {
+		log.Errorf("init cache error, fall back to memory: %+v", err)
+		return
+	}
+	log.Infof("use %s cache", conf.Conf.Cache.Backend)
+}
@@ -146,6 +146,21 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.IgnoreDirectLinkParams, Value: "sign,alist_ts", Type: conf.TypeString, Group: model.GLOBAL},
 		{Key: conf.StorageGroups, Value: "sign,alist_ts", Type: conf.TypeString, Group: model.GLOBAL},
 		{Key: conf.WebauthnLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PUBLIC},
+		{Key: conf.TrashEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `move removed objects into the .alist_trash folder of the storage if the storage supports moving`},
+		{Key: conf.TrashRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `objects in trash are purged after the days, 0 means never`},
+		{Key: conf.AuditEnabled, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.AuditRetentionDays, Value: "90", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `audit logs are deleted after the days, 0 means never`},
+		{Key: conf.DownloadLimit, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `total bandwidth of proxied downloads in KiB/s, 0 means no limit`},
+		{Key: conf.UploadLimit, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `total bandwidth of uploads in KiB/s, 0 means no limit`},
+		{Key: conf.HealthCheckInterval, Value: "5", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `interval in minutes to probe the storages, 0 to disable`},
+		{Key: conf.HealthCheckFailures, Value: "2", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE,
+			Help: `a storage is taken as unhealthy after the count of consecutive failed probes`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -153,6 +168,9 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
 		{Key: conf.MaxIndexDepth, Value: "20", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max depth of index`},
+		{Key: conf.IndexDeltaRefresh, Value: "0", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `interval in minutes to re-list the dirs modified since the last refresh, 0 to disable`},
+		{Key: conf.IndexContent, Value: "false", Type: conf.TypeBool, Group: model.INDEX, Flag: model.PRIVATE, Help: `index the content of the text, html, pdf, docx and xlsx files, only supported by bleve`},
+		{Key: conf.IndexContentMaxSize, Value: "1024", Type: conf.TypeNumber, Group: model.INDEX, Flag: model.PRIVATE, Help: `max size in KB of a file to index its content, only the beginning of larger text and html files is indexed`},
 		{Key: conf.IndexProgress, Value: "{}", Type: conf.TypeText, Group: model.SINGLE, Flag: model.PRIVATE},
 
 		// SSO settings
@@ -194,6 +212,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.S3AccessKeyId, Value: "", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
 		{Key: conf.S3SecretAccessKey, Value: "", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
 		{Key: conf.S3Buckets, Value: "[]", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
+		{Key: conf.S3MaxStagedSize, Value: "10240", Type: conf.TypeNumber, Group: model.S3, Flag: model.PRIVATE,
+			Help: `max size in MiB of the parts of the uncompleted multipart uploads of a user, 0 means no limit`},
+		{Key: conf.S3Anonymous, Value: "false", Type: conf.TypeBool, Group: model.S3, Flag: model.PRIVATE,
+			Help: `serve the unsigned requests as the guest`},
 	}
 	initialSettingItems = append(initialSettingItems, tool.Tools.Items()...)
 	if flags.Dev {
@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/declarative"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// InitDeclarative reconciles the storages file after the storages are loaded and watches its changes
+func InitDeclarative() {
+	if conf.Conf.StoragesFile == "" {
+		return
+	}
+	go func() {
+		for !conf.StoragesLoaded {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if err := declarative.Watch(context.Background()); err != nil {
+			utils.Log.Errorf("failed watch storages file: %+v", err)
+		}
+	}()
+}
@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/health"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var healthCron *cron.Cron
+
+// InitHealthCheck starts the job which probes the storages periodically
+func InitHealthCheck() {
+	healthCron = cron.NewCron(time.Minute)
+	healthCron.Do(health.AutoCheck)
+}
@@ -1,10 +1,15 @@
 package bootstrap
 
 import (
+	"time"
+
 	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/pkg/cron"
 	log "github.com/sirupsen/logrus"
 )
 
+var indexCron *cron.Cron
+
 func InitIndex() {
 	progress, err := search.Progress()
 	if err != nil {
@@ -16,3 +21,14 @@ func InitIndex() {
 		search.WriteProgress(progress)
 	}
 }
+
+// InitSearchIndexes loads the named search indexes and schedules their rebuilds
+func InitSearchIndexes() {
+	search.InitIndexes()
+}
+
+// InitIndexRefresh starts the job which runs the scheduled delta refresh of the index
+func InitIndexRefresh() {
+	indexCron = cron.NewCron(time.Minute)
+	indexCron.Do(search.AutoDeltaRefresh)
+}
@@ -0,0 +1,8 @@
+package bootstrap
+
+import "github.com/alist-org/alist/v3/internal/syncjob"
+
+// InitSyncJobs schedules the sync jobs, it must be called after the storages and the task managers are loaded
+func InitSyncJobs() {
+	syncjob.Init()
+}
@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+var trashCron *cron.Cron
+
+// InitTrash starts the job which purges the expired objects in trash
+func InitTrash() {
+	trashCron = cron.NewCron(time.Hour)
+	trashCron.Do(fs.PurgeExpiredTrash)
+}
@@ -0,0 +1,181 @@
+// Package bundle exports the storages, metas, users and settings into a versioned bundle
+// and imports the bundle into another instance
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Version of the bundle, increased when the bundle can't be read by the older versions
+const Version = 1
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+type Bundle struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Encryption is set if the secrets are encrypted with a passphrase
+	Encryption *Encryption         `json:"encryption,omitempty"`
+	Storages   []model.Storage     `json:"storages"`
+	Metas      []model.Meta        `json:"metas"`
+	Users      []User              `json:"users"`
+	Settings   []model.SettingItem `json:"settings"`
+	// OfflineDownload are the settings of the offline download tools
+	OfflineDownload []model.SettingItem `json:"offline_download"`
+}
+
+// User is model.User with the password hash and the secrets, which are omitted by model.User
+type User struct {
+	model.User
+	PwdHash   string `json:"pwd_hash"`
+	PwdTS     int64  `json:"pwd_ts"`
+	Salt      string `json:"salt"`
+	OtpSecret string `json:"otp_secret"`
+	Authn     string `json:"authn"`
+}
+
+func fromUser(u model.User) User {
+	// the plaintext password is never kept
+	u.Password = ""
+	return User{
+		User:      u,
+		PwdHash:   u.PwdHash,
+		PwdTS:     u.PwdTS,
+		Salt:      u.Salt,
+		OtpSecret: u.OtpSecret,
+		Authn:     u.Authn,
+	}
+}
+
+func (u User) toUser() model.User {
+	res := u.User
+	res.Password = ""
+	res.PwdHash = u.PwdHash
+	res.PwdTS = u.PwdTS
+	res.Salt = u.Salt
+	res.OtpSecret = u.OtpSecret
+	res.Authn = u.Authn
+	return res
+}
+
+// Export reads the bundle from database, the secrets are encrypted if passphrase is not empty
+func Export(passphrase string) (*Bundle, error) {
+	storages, err := db.GetAllStorages()
+	if err != nil {
+		return nil, err
+	}
+	metas, err := db.GetAllMetas()
+	if err != nil {
+		return nil, err
+	}
+	users, err := db.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	settings, err := db.GetSettingItems()
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Version:   Version,
+		CreatedAt: time.Now(),
+		Storages:  storages,
+		Metas:     metas,
+		Users:     make([]User, 0, len(users)),
+	}
+	for _, u := range users {
+		b.Users = append(b.Users, fromUser(u))
+	}
+	for _, item := range settings {
+		if item.Group == model.OFFLINE_DOWNLOAD {
+			b.OfflineDownload = append(b.OfflineDownload, item)
+		} else {
+			b.Settings = append(b.Settings, item)
+		}
+	}
+	if passphrase != "" {
+		if err := b.encrypt(passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Marshal encodes the bundle in the format, the keys of yaml are the same as json
+func Marshal(b *Bundle, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch format {
+	case FormatJSON, "":
+		return data, nil
+	case FormatYAML:
+		// json is a subset of yaml, the parsed nodes keep the order of the fields
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		blockStyle(&node)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return buf.Bytes(), enc.Close()
+	}
+	return nil, errors.Wrapf(errs.InvalidBundle, "unknown format: %s", format)
+}
+
+// blockStyle drops the flow style and the quotes of the nodes parsed from json,
+// the quotes are added back by the encoder where needed
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
+
+// Unmarshal decodes the bundle in json or yaml
+func Unmarshal(data []byte) (*Bundle, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, errors.Wrapf(errs.InvalidBundle, "failed parse: %s", err)
+		}
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, errors.Wrapf(errs.InvalidBundle, "failed parse: %s", err)
+		}
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, errors.Wrapf(errs.InvalidBundle, "failed parse: %s", err)
+	}
+	if b.Version < 1 || b.Version > Version {
+		return nil, errors.Wrapf(errs.InvalidBundle, "unsupported version: %d", b.Version)
+	}
+	return &b, nil
+}
+
+// FormatOf returns the format by the extension of the file name, json by default
+func FormatOf(name string) string {
+	if ext := utils.Ext(name); ext == "yaml" || ext == "yml" {
+		return FormatYAML
+	}
+	return FormatJSON
+}
@@ -0,0 +1,62 @@
+package bundle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Version:  Version,
+		Storages: []model.Storage{{MountPath: "/a", Driver: "Local", Addition: `{"root_folder_path":"/tmp"}`}},
+		Users:    []User{fromUser(model.User{Username: "admin", Role: model.ADMIN, PwdHash: "hash", Salt: "salt"})},
+		Settings: []model.SettingItem{
+			{Key: "token", Value: "secret", Flag: model.PRIVATE},
+			{Key: "site_title", Value: "true", Flag: model.PUBLIC},
+		},
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		data, err := Marshal(testBundle(), format)
+		if err != nil {
+			t.Fatalf("marshal %s: %+v", format, err)
+		}
+		b, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("unmarshal %s: %+v", format, err)
+		}
+		if b.Storages[0].Addition != testBundle().Storages[0].Addition || b.Users[0].toUser().PwdHash != "hash" ||
+			b.Settings[1].Value != "true" {
+			t.Errorf("%s bundle changed after unmarshal: %+v", format, b)
+		}
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	b := testBundle()
+	if err := b.encrypt("passphrase"); err != nil {
+		t.Fatalf("encrypt: %+v", err)
+	}
+	if b.Storages[0].Addition == testBundle().Storages[0].Addition || b.Users[0].PwdHash == "hash" ||
+		b.Settings[0].Value == "secret" {
+		t.Errorf("secrets are not encrypted: %+v", b)
+	}
+	if b.Settings[1].Value != "true" || b.Users[0].Salt != "salt" {
+		t.Errorf("public values are encrypted: %+v", b)
+	}
+	if err := b.decrypt("wrong"); !errors.Is(err, errs.WrongBundlePassphrase) {
+		t.Errorf("decrypt with wrong passphrase: %v", err)
+	}
+	if err := b.decrypt("passphrase"); err != nil {
+		t.Fatalf("decrypt: %+v", err)
+	}
+	if b.Storages[0].Addition != testBundle().Storages[0].Addition || b.Users[0].PwdHash != "hash" ||
+		b.Settings[0].Value != "secret" || b.Encryption != nil {
+		t.Errorf("secrets are not decrypted: %+v", b)
+	}
+}
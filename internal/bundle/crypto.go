@@ -0,0 +1,149 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	kdfScrypt = "scrypt"
+	// checkText is encrypted into Encryption.Check to tell the wrong passphrase
+	checkText = "alist bundle"
+)
+
+// Encryption describes how the secrets in the bundle are encrypted,
+// the secrets are encrypted by AES-GCM with the key derived from the passphrase
+type Encryption struct {
+	KDF   string `json:"kdf"`
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+func deriveKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.WithStack(err)
+}
+
+func seal(aead cipher.AEAD, plaintext string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func open(aead cipher.AEAD, ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errors.Wrap(errs.InvalidBundle, "malformed secret")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errs.WrongBundlePassphrase
+	}
+	return string(plaintext), nil
+}
+
+// secrets calls f with the secrets in the bundle: the additions of the storages, the passwords
+// of the metas, the password hashes, otp secrets and webauthn credentials of the users and
+// the values of the private settings. The empty ones are skipped
+func (b *Bundle) secrets(f func(secret *string) error) error {
+	var secrets []*string
+	for i := range b.Storages {
+		secrets = append(secrets, &b.Storages[i].Addition)
+	}
+	for i := range b.Metas {
+		secrets = append(secrets, &b.Metas[i].Password)
+	}
+	for i := range b.Users {
+		secrets = append(secrets, &b.Users[i].PwdHash, &b.Users[i].OtpSecret, &b.Users[i].Authn)
+	}
+	for _, items := range [][]model.SettingItem{b.Settings, b.OfflineDownload} {
+		for i := range items {
+			if items[i].Flag == model.PRIVATE {
+				secrets = append(secrets, &items[i].Value)
+			}
+		}
+	}
+	for _, secret := range secrets {
+		if *secret == "" {
+			continue
+		}
+		if err := f(secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) encrypt(passphrase string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.WithStack(err)
+	}
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	check, err := seal(aead, checkText)
+	if err != nil {
+		return err
+	}
+	err = b.secrets(func(secret *string) error {
+		*secret, err = seal(aead, *secret)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	b.Encryption = &Encryption{
+		KDF:   kdfScrypt,
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+		Check: check,
+	}
+	return nil
+}
+
+func (b *Bundle) decrypt(passphrase string) error {
+	if b.Encryption.KDF != kdfScrypt {
+		return errors.Wrapf(errs.InvalidBundle, "unsupported kdf: %s", b.Encryption.KDF)
+	}
+	if passphrase == "" {
+		return errors.Wrap(errs.WrongBundlePassphrase, "the bundle is encrypted, passphrase is required")
+	}
+	salt, err := base64.StdEncoding.DecodeString(b.Encryption.Salt)
+	if err != nil {
+		return errors.Wrap(errs.InvalidBundle, "malformed salt")
+	}
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	if check, err := open(aead, b.Encryption.Check); err != nil || check != checkText {
+		return errs.WrongBundlePassphrase
+	}
+	err = b.secrets(func(secret *string) error {
+		*secret, err = open(aead, *secret)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	b.Encryption = nil
+	return nil
+}
@@ -0,0 +1,377 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/offline_download/tool"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ModeMerge saves the rows in the bundle and keeps the others
+	ModeMerge = "merge"
+	// ModeReplace also deletes the storages, metas and users which are not in the bundle,
+	// the settings are always merged since they are defined by the program
+	ModeReplace = "replace"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionSkip is the change of a row managed by the storages file, which is kept as it is
+	ActionSkip = "skip"
+)
+
+const (
+	TypeStorage = "storage"
+	TypeMeta    = "meta"
+	TypeUser    = "user"
+	TypeSetting = "setting"
+)
+
+type ImportOptions struct {
+	Mode       string
+	Passphrase string
+	// DryRun only returns the diff without changing anything
+	DryRun bool
+}
+
+// Change of a row, Key is the mount path, path, username or key of the row
+type Change struct {
+	Type   string `json:"type"`
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	// Fields are the changed fields of the updated row, the values are not shown for the secrets
+	Fields []string `json:"fields,omitempty"`
+}
+
+type Diff struct {
+	Changes   []Change `json:"changes"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// rowsDiff compares the rows in the bundle with the ones in database by key, the ids of the rows
+// in the bundle are replaced by the ones in database or cleared to create new rows
+type rowsDiff[T any] struct {
+	typ  string
+	key  func(*T) string
+	name func(*T) string
+	id   func(*T) *uint
+	// managed returns the flag of the rows managed by the storages file, nil if the rows can't be managed.
+	// The managed rows are neither changed nor deleted, and the imported rows are never managed
+	managed func(*T) *bool
+	// ignore are the fields not compared
+	ignore []string
+}
+
+func (r rowsDiff[T]) diff(diff *Diff, current, imported []T, replace bool) (save []T, deleted []uint, err error) {
+	currentMap := make(map[string]*T, len(current))
+	for i := range current {
+		currentMap[r.key(&current[i])] = &current[i]
+	}
+	seen := make(map[string]bool, len(imported))
+	for i := range imported {
+		row := imported[i]
+		key := r.key(&row)
+		if seen[key] {
+			return nil, nil, errors.Wrapf(errs.InvalidBundle, "duplicate %s: %s", r.typ, r.name(&row))
+		}
+		seen[key] = true
+		if r.managed != nil {
+			*r.managed(&row) = false
+		}
+		old, ok := currentMap[key]
+		if !ok {
+			*r.id(&row) = 0
+			diff.Changes = append(diff.Changes, Change{Type: r.typ, Key: r.name(&row), Action: ActionCreate})
+			save = append(save, row)
+			continue
+		}
+		*r.id(&row) = *r.id(old)
+		fields, err := changedFields(*old, row, r.ignore)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(fields) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		if r.managed != nil && *r.managed(old) {
+			diff.Changes = append(diff.Changes, Change{Type: r.typ, Key: r.name(&row), Action: ActionSkip, Fields: fields})
+			continue
+		}
+		diff.Changes = append(diff.Changes, Change{Type: r.typ, Key: r.name(&row), Action: ActionUpdate, Fields: fields})
+		save = append(save, row)
+	}
+	if !replace {
+		return save, nil, nil
+	}
+	for i := range current {
+		if !seen[r.key(&current[i])] && (r.managed == nil || !*r.managed(&current[i])) {
+			diff.Changes = append(diff.Changes, Change{Type: r.typ, Key: r.name(&current[i]), Action: ActionDelete})
+			deleted = append(deleted, *r.id(&current[i]))
+		}
+	}
+	return save, deleted, nil
+}
+
+// changedFields compares the json fields of a and b
+func changedFields(a, b interface{}, ignore []string) ([]string, error) {
+	var am, bm map[string]interface{}
+	for _, v := range []struct {
+		src interface{}
+		dst *map[string]interface{}
+	}{{a, &am}, {b, &bm}} {
+		data, err := json.Marshal(v.src)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = json.Unmarshal(data, v.dst); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	var fields []string
+	for k, v := range bm {
+		if utils.SliceContains(ignore, k) {
+			continue
+		}
+		av, _ := json.Marshal(am[k])
+		bv, _ := json.Marshal(v)
+		if string(av) != string(bv) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// userKey matches the admin and the guest by role since there is only one of each
+func userKey(u *User) string {
+	switch u.Role {
+	case model.ADMIN:
+		return "\x00admin"
+	case model.GUEST:
+		return "\x00guest"
+	}
+	return u.Username
+}
+
+// Import saves the bundle into database in one transaction and returns the diff,
+// nothing is changed if opts.DryRun. The running instance needs Reload to apply the diff
+func Import(b *Bundle, opts ImportOptions) (*Diff, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeMerge
+	}
+	if opts.Mode != ModeMerge && opts.Mode != ModeReplace {
+		return nil, errors.Wrapf(errs.InvalidBundle, "unknown import mode: %s", opts.Mode)
+	}
+	replace := opts.Mode == ModeReplace
+	if b.Encryption != nil {
+		if err := b.decrypt(opts.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.check(replace); err != nil {
+		return nil, err
+	}
+
+	currentStorages, err := db.GetAllStorages()
+	if err != nil {
+		return nil, err
+	}
+	currentMetas, err := db.GetAllMetas()
+	if err != nil {
+		return nil, err
+	}
+	users, err := db.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	currentUsers := make([]User, 0, len(users))
+	for _, u := range users {
+		currentUsers = append(currentUsers, fromUser(u))
+	}
+	currentSettings, err := db.GetSettingItems()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{Changes: make([]Change, 0)}
+	var rows db.BundleRows
+	rows.Storages, rows.DeletedStorages, err = rowsDiff[model.Storage]{
+		typ:     TypeStorage,
+		key:     func(s *model.Storage) string { return s.MountPath },
+		name:    func(s *model.Storage) string { return s.MountPath },
+		id:      func(s *model.Storage) *uint { return &s.ID },
+		managed: func(s *model.Storage) *bool { return &s.Managed },
+		ignore:  []string{"id", "status", "managed"},
+	}.diff(diff, currentStorages, b.Storages, replace)
+	if err != nil {
+		return nil, err
+	}
+	rows.Metas, rows.DeletedMetas, err = rowsDiff[model.Meta]{
+		typ:     TypeMeta,
+		key:     func(m *model.Meta) string { return m.Path },
+		name:    func(m *model.Meta) string { return m.Path },
+		id:      func(m *model.Meta) *uint { return &m.ID },
+		managed: func(m *model.Meta) *bool { return &m.Managed },
+		ignore:  []string{"id", "managed"},
+	}.diff(diff, currentMetas, b.Metas, replace)
+	if err != nil {
+		return nil, err
+	}
+	saveUsers, deletedUsers, err := rowsDiff[User]{
+		typ:     TypeUser,
+		key:     userKey,
+		name:    func(u *User) string { return u.Username },
+		id:      func(u *User) *uint { return &u.ID },
+		managed: func(u *User) *bool { return &u.Managed },
+		ignore:  []string{"id", "password", "managed"},
+	}.diff(diff, currentUsers, b.Users, replace)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range saveUsers {
+		rows.Users = append(rows.Users, u.toUser())
+	}
+	rows.DeletedUsers = deletedUsers
+	rows.Settings, _, err = rowsDiff[model.SettingItem]{
+		typ:  TypeSetting,
+		key:  func(item *model.SettingItem) string { return item.Key },
+		name: func(item *model.SettingItem) string { return item.Key },
+		// settings are keyed by the key
+		id: func(item *model.SettingItem) *uint { return new(uint) },
+	}.diff(diff, currentSettings, append(append([]model.SettingItem{}, b.Settings...), b.OfflineDownload...), false)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun || len(diff.Changes) == 0 {
+		return diff, nil
+	}
+	// the storages are loaded again with the status set by the driver
+	for i := range rows.Storages {
+		rows.Storages[i].Status = ""
+	}
+	if err := db.ImportBundle(rows); err != nil {
+		return nil, errors.WithMessage(err, "failed import bundle")
+	}
+	op.HandleS3KeysDeleteHook()
+	return diff, nil
+}
+
+// check normalizes the paths in the bundle and checks the drivers and the users
+func (b *Bundle) check(replace bool) error {
+	for i := range b.Storages {
+		b.Storages[i].MountPath = utils.FixAndCleanPath(b.Storages[i].MountPath)
+		if _, err := op.GetDriver(b.Storages[i].Driver); err != nil {
+			return errors.Wrapf(errs.InvalidBundle, "storage %s: %s", b.Storages[i].MountPath, err)
+		}
+	}
+	for i := range b.Metas {
+		b.Metas[i].Path = utils.FixAndCleanPath(b.Metas[i].Path)
+	}
+	admins, guests := 0, 0
+	for i := range b.Users {
+		if b.Users[i].Username == "" {
+			return errors.Wrap(errs.InvalidBundle, "username is empty")
+		}
+		b.Users[i].BasePath = utils.FixAndCleanPath(b.Users[i].BasePath)
+		switch b.Users[i].Role {
+		case model.ADMIN:
+			admins++
+		case model.GUEST:
+			guests++
+		}
+	}
+	if admins > 1 || guests > 1 {
+		return errors.Wrap(errs.InvalidBundle, "there are more than one admin or guest")
+	}
+	// the admin and the guest can't be deleted
+	if replace && (admins == 0 || guests == 0) {
+		return errors.Wrap(errs.InvalidBundle, "the admin and the guest are required to replace the users")
+	}
+	for _, items := range [][]model.SettingItem{b.Settings, b.OfflineDownload} {
+		for i := range items {
+			if items[i].Key == "" {
+				return errors.Wrap(errs.InvalidBundle, "key of the setting is empty")
+			}
+		}
+	}
+	return nil
+}
+
+// Reload applies the imported diff to the running instance: the changed storages are reloaded,
+// the cached users, metas and settings are dropped and the offline download tools are initialized again
+func Reload(ctx context.Context, diff *Diff) {
+	settingsChanged, offlineChanged := false, false
+	var settingKeys []string
+	for _, c := range diff.Changes {
+		switch c.Type {
+		case TypeStorage:
+			if c.Action != ActionSkip {
+				reloadStorage(ctx, c)
+			}
+		case TypeSetting:
+			settingsChanged = true
+			settingKeys = append(settingKeys, c.Key)
+		}
+	}
+	op.ClearUserCache()
+	op.ClearMetaCache()
+	if settingsChanged {
+		var items []model.SettingItem
+		for _, key := range settingKeys {
+			item, err := db.GetSettingItemByKey(key)
+			if err != nil {
+				log.Errorf("failed get imported setting %s: %+v", key, err)
+				continue
+			}
+			if item.Group == model.OFFLINE_DOWNLOAD {
+				offlineChanged = true
+			}
+			items = append(items, *item)
+		}
+		if err := op.ReloadSettingItems(items); err != nil {
+			log.Errorf("failed reload imported settings: %+v", err)
+		}
+	}
+	if offlineChanged {
+		for name, t := range tool.Tools {
+			if _, err := t.Init(); err != nil {
+				log.Warnf("failed init offline download tool %s: %+v", name, err)
+			}
+		}
+	}
+}
+
+func reloadStorage(ctx context.Context, c Change) {
+	if _, err := op.GetStorageByMountPath(c.Key); err == nil {
+		if err := op.UnloadStorage(ctx, c.Key); err != nil {
+			log.Errorf("failed unload storage %s: %+v", c.Key, err)
+		}
+	}
+	if c.Action == ActionDelete {
+		return
+	}
+	storage, err := db.GetStorageByMountPath(c.Key)
+	if err != nil {
+		log.Errorf("failed get imported storage %s: %+v", c.Key, err)
+		return
+	}
+	if storage.Disabled {
+		return
+	}
+	if err := op.LoadStorage(ctx, *storage); err != nil {
+		log.Errorf("failed load imported storage %s: %+v", c.Key, err)
+	}
+}
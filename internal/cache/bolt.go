@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("cache")
+
+// boltStore keeps the entries in a bolt file, each value is prefixed with its expire time in unix nanoseconds
+type boltStore struct {
+	db        *bolt.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func newBoltStore(file string) (*boltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	db, err := bolt.Open(file, 0o666, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.WithStack(err)
+	}
+	return &boltStore{db: db, lastSweep: time.Now()}, nil
+}
+
+func decodeBoltValue(v []byte) (time.Time, []byte, bool) {
+	if len(v) < 8 {
+		return time.Time{}, nil, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v[:8]))), v[8:], true
+}
+
+func (s *boltStore) Get(key string) ([]byte, bool, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		expire, v, ok := decodeBoltValue(tx.Bucket(boltBucket).Get([]byte(key)))
+		if ok && time.Now().Before(expire) {
+			// the value is only valid during the transaction
+			data = bytes.Clone(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return data, data != nil, nil
+}
+
+func (s *boltStore) Set(key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+	v := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(v, uint64(now.Add(ttl).UnixNano()))
+	copy(v[8:], value)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), v)
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.mu.Lock()
+	sweep := now.Sub(s.lastSweep) > sweepInterval
+	if sweep {
+		s.lastSweep = now
+	}
+	s.mu.Unlock()
+	if sweep {
+		return s.sweep(now)
+	}
+	return nil
+}
+
+// sweep deletes the expired entries
+func (s *boltStore) sweep(now time.Time) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.First(); k != nil; {
+			expire, _, ok := decodeBoltValue(v)
+			if !ok || now.After(expire) {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				// the cursor is moved to the next key after deleting
+				k, v = c.Seek(k)
+				continue
+			}
+			k, v = c.Next()
+		}
+		return nil
+	})
+	return errors.WithStack(err)
+}
+
+func (s *boltStore) Del(keys ...string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket)
+		for _, k := range keys {
+			if err := b.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.WithStack(err)
+}
+
+func (s *boltStore) Scan(prefix string) ([]Entry, error) {
+	var res []Entry
+	now := time.Now()
+	p := []byte(prefix)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+			expire, _, ok := decodeBoltValue(v)
+			if ok && now.Before(expire) {
+				res = append(res, Entry{Key: string(k), Expire: expire})
+			}
+		}
+		return nil
+	})
+	return res, errors.WithStack(err)
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,253 @@
+// Package cache provides the caches whose entries can be kept in memory, in an embedded bolt file
+// or in redis which is shared between several alist instances.
+// The backend is chosen by conf.Conf.Cache and applies to every cache created with a codec,
+// caches without a codec are always kept in memory.
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendBolt   = "bolt"
+	BackendRedis  = "redis"
+)
+
+// Store keeps the encoded entries of all caches
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	// Set stores value which expires after ttl
+	Set(key string, value []byte, ttl time.Duration) error
+	Del(keys ...string) error
+	// Scan returns the entries whose keys start with prefix
+	Scan(prefix string) ([]Entry, error)
+	Close() error
+}
+
+type Entry struct {
+	Cache  string    `json:"cache"`
+	Key    string    `json:"key"`
+	Expire time.Time `json:"expire"`
+}
+
+type Codec[T any] struct {
+	Encode func(T) ([]byte, error)
+	Decode func([]byte) (T, error)
+}
+
+var (
+	storeMu sync.RWMutex
+	store   Store
+)
+
+func getStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return store
+}
+
+// Init opens the store of the configured backend, the memory backend needs no store
+func Init() error {
+	c := conf.Conf.Cache
+	var s Store
+	var err error
+	switch c.Backend {
+	case "", BackendMemory:
+		return nil
+	case BackendBolt:
+		s, err = newBoltStore(c.BoltFile)
+	case BackendRedis:
+		s, err = newRedisStore(c.Redis)
+	default:
+		return errors.Errorf("unknown cache backend: %s", c.Backend)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "failed open %s cache", c.Backend)
+	}
+	storeMu.Lock()
+	store = s
+	storeMu.Unlock()
+	return nil
+}
+
+// Close closes the store, the caches fall back to memory afterwards
+func Close() {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if store != nil {
+		if err := store.Close(); err != nil {
+			log.Errorf("failed close cache store: %+v", err)
+		}
+		store = nil
+	}
+}
+
+// Cache maps keys to values of type T, an entry is dropped once it expires
+type Cache[T any] struct {
+	name  string
+	codec *Codec[T]
+	mem   *memory[T]
+}
+
+var (
+	cachesMu sync.Mutex
+	caches   []inspector
+)
+
+type inspector interface {
+	Entries(prefix string) []Entry
+	Del(keys ...string)
+}
+
+// New creates a cache, it's kept in the configured store if codec is not nil
+func New[T any](name string, codec *Codec[T]) *Cache[T] {
+	c := &Cache[T]{name: name, codec: codec, mem: newMemory[T]()}
+	cachesMu.Lock()
+	caches = append(caches, c)
+	cachesMu.Unlock()
+	return c
+}
+
+func (c *Cache[T]) store() Store {
+	if c.codec == nil {
+		return nil
+	}
+	return getStore()
+}
+
+func (c *Cache[T]) storeKey(key string) string {
+	return c.name + ":" + key
+}
+
+func (c *Cache[T]) Get(key string) (T, bool) {
+	s := c.store()
+	if s == nil {
+		return c.mem.get(key)
+	}
+	var zero T
+	data, ok, err := s.Get(c.storeKey(key))
+	if err != nil {
+		log.Warnf("failed get cache %s: %+v", c.storeKey(key), err)
+		return zero, false
+	}
+	if !ok {
+		return zero, false
+	}
+	v, err := c.codec.Decode(data)
+	if err != nil {
+		log.Warnf("failed decode cache %s: %+v", c.storeKey(key), err)
+		return zero, false
+	}
+	return v, true
+}
+
+// Set sets the value of key, the key is deleted if ttl is not positive
+func (c *Cache[T]) Set(key string, v T, ttl time.Duration) {
+	if ttl <= 0 {
+		c.Del(key)
+		return
+	}
+	s := c.store()
+	if s == nil {
+		c.mem.set(key, v, ttl)
+		return
+	}
+	data, err := c.codec.Encode(v)
+	if err == nil {
+		err = s.Set(c.storeKey(key), data, ttl)
+	}
+	if err != nil {
+		log.Warnf("failed set cache %s: %+v", c.storeKey(key), err)
+	}
+}
+
+func (c *Cache[T]) Del(keys ...string) {
+	s := c.store()
+	if s == nil {
+		c.mem.del(keys...)
+		return
+	}
+	storeKeys := make([]string, len(keys))
+	for i, k := range keys {
+		storeKeys[i] = c.storeKey(k)
+	}
+	if err := s.Del(storeKeys...); err != nil {
+		log.Warnf("failed del cache %v: %+v", storeKeys, err)
+	}
+}
+
+// Entries returns the entries whose keys start with prefix
+func (c *Cache[T]) Entries(prefix string) []Entry {
+	s := c.store()
+	if s == nil {
+		return c.mem.entries(c.name, prefix)
+	}
+	entries, err := s.Scan(c.storeKey(prefix))
+	if err != nil {
+		log.Warnf("failed scan cache %s: %+v", c.storeKey(prefix), err)
+		return nil
+	}
+	for i := range entries {
+		entries[i].Cache = c.name
+		entries[i].Key = strings.TrimPrefix(entries[i].Key, c.name+":")
+	}
+	return entries
+}
+
+// List returns the entries of all caches under path, the key of an entry is
+// the path itself or starts with the path followed by '/' or ':'
+func List(path string) []Entry {
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	var res []Entry
+	for _, c := range caches {
+		for _, e := range c.Entries(path) {
+			if under(e.Key, path) {
+				res = append(res, e)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Key != res[j].Key {
+			return res[i].Key < res[j].Key
+		}
+		return res[i].Cache < res[j].Cache
+	})
+	return res
+}
+
+// Purge deletes the entries of all caches under path and returns the number of them
+func Purge(path string) int {
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	n := 0
+	for _, c := range caches {
+		var keys []string
+		for _, e := range c.Entries(path) {
+			if under(e.Key, path) {
+				keys = append(keys, e.Key)
+			}
+		}
+		if len(keys) > 0 {
+			c.Del(keys...)
+			n += len(keys)
+		}
+	}
+	return n
+}
+
+func under(key, path string) bool {
+	if path == "" || path == "/" || key == path {
+		return true
+	}
+	rest := strings.TrimPrefix(key, path)
+	return len(rest) < len(key) && (rest[0] == '/' || rest[0] == ':')
+}
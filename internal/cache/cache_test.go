@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUnder(t *testing.T) {
+	tests := []struct {
+		key, path string
+		want      bool
+	}{
+		{"/a", "/a", true},
+		{"/a/b", "/a", true},
+		{"/a:127.0.0.1", "/a", true},
+		{"/ab", "/a", false},
+		{"/b", "/a", false},
+		{"/b", "/", true},
+	}
+	for _, tt := range tests {
+		if got := under(tt.key, tt.path); got != tt.want {
+			t.Errorf("under(%q, %q) = %v, want %v", tt.key, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBoltStore(t *testing.T) {
+	s, err := newBoltStore(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.Set("list:/a", []byte("a"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("list:/a/b", []byte("b"), -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := s.Get("list:/a"); err != nil || !ok || string(v) != "a" {
+		t.Errorf("get /a = %q, %v, %v", v, ok, err)
+	}
+	if _, ok, _ := s.Get("list:/a/b"); ok {
+		t.Errorf("expired /a/b is got")
+	}
+	entries, err := s.Scan("list:/a")
+	if err != nil || len(entries) != 1 || entries[0].Key != "list:/a" {
+		t.Errorf("scan = %+v, %v", entries, err)
+	}
+	if err := s.sweep(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Del("list:/a"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.Scan(""); len(entries) != 0 {
+		t.Errorf("entries left after del: %+v", entries)
+	}
+}
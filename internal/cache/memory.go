@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the expired entries of a memory cache are deleted
+const sweepInterval = time.Minute
+
+type memItem[T any] struct {
+	v      T
+	expire time.Time
+}
+
+type memory[T any] struct {
+	mu        sync.RWMutex
+	items     map[string]memItem[T]
+	lastSweep time.Time
+}
+
+func newMemory[T any]() *memory[T] {
+	return &memory[T]{items: make(map[string]memItem[T]), lastSweep: time.Now()}
+}
+
+func (m *memory[T]) get(key string) (T, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item, ok := m.items[key]
+	if !ok || time.Now().After(item.expire) {
+		var zero T
+		return zero, false
+	}
+	return item.v, true
+}
+
+func (m *memory[T]) set(key string, v T, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.items[key] = memItem[T]{v: v, expire: now.Add(ttl)}
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.lastSweep = now
+		for k, item := range m.items {
+			if now.After(item.expire) {
+				delete(m.items, k)
+			}
+		}
+	}
+}
+
+func (m *memory[T]) del(keys ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		delete(m.items, k)
+	}
+}
+
+func (m *memory[T]) entries(name, prefix string) []Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := time.Now()
+	var res []Entry
+	for k, item := range m.items {
+		if strings.HasPrefix(k, prefix) && !now.After(item.expire) {
+			res = append(res, Entry{Cache: name, Key: k, Expire: item.expire})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
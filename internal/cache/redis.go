@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisTimeout = 5 * time.Second
+
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisStore(c conf.Redis) (*redisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     c.Addr,
+		Username: c.Username,
+		Password: c.Password,
+		DB:       c.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, errors.WithStack(err)
+	}
+	return &redisStore{client: client, prefix: c.KeyPrefix}, nil
+}
+
+func (s *redisStore) Get(key string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	return data, true, nil
+}
+
+func (s *redisStore) Set(key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return errors.WithStack(s.client.Set(ctx, s.prefix+key, value, ttl).Err())
+}
+
+func (s *redisStore) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	fullKeys := make([]string, len(keys))
+	for i, k := range keys {
+		fullKeys[i] = s.prefix + k
+	}
+	return errors.WithStack(s.client.Del(ctx, fullKeys...).Err())
+}
+
+// globEscaper escapes the special characters of the pattern of SCAN
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+func (s *redisStore) Scan(prefix string) ([]Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	var keys []string
+	iter := s.client.Scan(ctx, 0, globEscaper.Replace(s.prefix+prefix)+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pipe := s.client.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, k := range keys {
+		ttls[i] = pipe.PTTL(ctx, k)
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	now := time.Now()
+	res := make([]Entry, 0, len(keys))
+	for i, k := range keys {
+		// the key has expired since it was scanned
+		if ttls[i].Val() < 0 {
+			continue
+		}
+		res = append(res, Entry{Key: strings.TrimPrefix(k, s.prefix), Expire: now.Add(ttls[i].Val())})
+	}
+	return res, nil
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
@@ -72,6 +72,43 @@ type S3 struct {
 	SSL    bool `json:"ssl" env:"SSL"`
 }
 
+type FTP struct {
+	Enable        bool   `json:"enable" env:"ENABLE"`
+	Port          int    `json:"port" env:"PORT"`
+	PublicHost    string `json:"public_host" env:"PUBLIC_HOST"`
+	PasvPortRange string `json:"pasv_port_range" env:"PASV_PORT_RANGE"`
+	TLS           bool   `json:"tls" env:"TLS"`
+	ForceTLS      bool   `json:"force_tls" env:"FORCE_TLS"`
+	IdleTimeout   int    `json:"idle_timeout" env:"IDLE_TIMEOUT"`
+}
+
+type SFTP struct {
+	Enable      bool   `json:"enable" env:"ENABLE"`
+	Port        int    `json:"port" env:"PORT"`
+	HostKeyFile string `json:"host_key_file" env:"HOST_KEY_FILE"`
+}
+
+type Webdav struct {
+	// LockSystem is memory or db, the locks in the db are kept after restarting
+	// and shared by the instances using the same database
+	LockSystem string `json:"lock_system" env:"LOCK_SYSTEM"`
+}
+
+type Redis struct {
+	Addr      string `json:"addr" env:"ADDR"`
+	Username  string `json:"username" env:"USERNAME"`
+	Password  string `json:"password" env:"PASSWORD"`
+	DB        int    `json:"db" env:"DB"`
+	KeyPrefix string `json:"key_prefix" env:"KEY_PREFIX"`
+}
+
+type Cache struct {
+	// Backend is one of memory, bolt and redis
+	Backend  string `json:"backend" env:"BACKEND"`
+	BoltFile string `json:"bolt_file" env:"BOLT_FILE"`
+	Redis    Redis  `json:"redis" envPrefix:"REDIS_"`
+}
+
 type Config struct {
 	Force                 bool        `json:"force" env:"FORCE"`
 	Notify                bool        `json:"notify" env:"NOTIFY"`
@@ -92,6 +129,11 @@ type Config struct {
 	Tasks                 TasksConfig `json:"tasks" envPrefix:"TASKS_"`
 	Cors                  Cors        `json:"cors" envPrefix:"CORS_"`
 	S3                    S3          `json:"s3" envPrefix:"S3_"`
+	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
+	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
+	Webdav                Webdav      `json:"webdav" envPrefix:"WEBDAV_"`
+	Cache                 Cache       `json:"cache" envPrefix:"CACHE_"`
+	StoragesFile          string      `json:"storages_file" env:"STORAGES_FILE"`
 }
 
 func DefaultConfig() *Config {
@@ -103,6 +145,8 @@ func DefaultConfig() *Config {
 	transferPersistPath := filepath.Join(flags.DataDir, "tasks/transfer.json")
 	uploadPersistPath := filepath.Join(flags.DataDir, "tasks/upload.json")
 	copyPersistPath := filepath.Join(flags.DataDir, "tasks/copy.json")
+	cachePath := filepath.Join(flags.DataDir, "cache.db")
+	storagesFile := filepath.Join(flags.DataDir, "storages.yaml")
 	return &Config{
 		Scheme: Scheme{
 			Address:    "0.0.0.0",
@@ -171,5 +215,30 @@ func DefaultConfig() *Config {
 			Port:   5246,
 			SSL:    false,
 		},
+		FTP: FTP{
+			Enable:        false,
+			Port:          5221,
+			PasvPortRange: "",
+			TLS:           false,
+			ForceTLS:      false,
+			IdleTimeout:   900,
+		},
+		SFTP: SFTP{
+			Enable:      false,
+			Port:        5222,
+			HostKeyFile: filepath.Join(flags.DataDir, "ssh_host_ed25519_key"),
+		},
+		Webdav: Webdav{
+			LockSystem: "memory",
+		},
+		Cache: Cache{
+			Backend:  "memory",
+			BoltFile: cachePath,
+			Redis: Redis{
+				Addr:      "localhost:6379",
+				KeyPrefix: "alist:",
+			},
+		},
+		StoragesFile: storagesFile,
 	}
 }
@@ -44,12 +44,23 @@ const (
 	IgnoreDirectLinkParams  = "ignore_direct_link_params"
 	StorageGroups           = "storage_groups"
 	WebauthnLoginEnabled    = "webauthn_login_enabled"
+	TrashEnabled            = "trash_enabled"
+	TrashRetentionDays      = "trash_retention_days"
+	AuditEnabled            = "audit_enabled"
+	AuditRetentionDays      = "audit_retention_days"
+	DownloadLimit           = "download_limit"
+	UploadLimit             = "upload_limit"
+	HealthCheckInterval     = "health_check_interval"
+	HealthCheckFailures     = "health_check_failures"
 
 	// index
-	SearchIndex     = "search_index"
-	AutoUpdateIndex = "auto_update_index"
-	IgnorePaths     = "ignore_paths"
-	MaxIndexDepth   = "max_index_depth"
+	SearchIndex         = "search_index"
+	AutoUpdateIndex     = "auto_update_index"
+	IgnorePaths         = "ignore_paths"
+	MaxIndexDepth       = "max_index_depth"
+	IndexDeltaRefresh   = "index_delta_refresh"
+	IndexContent        = "index_content"
+	IndexContentMaxSize = "index_content_max_size"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -98,6 +109,8 @@ const (
 	S3Buckets         = "s3_buckets"
 	S3AccessKeyId     = "s3_access_key_id"
 	S3SecretAccessKey = "s3_secret_access_key"
+	S3MaxStagedSize   = "s3_max_staged_size"
+	S3Anonymous       = "s3_anonymous"
 
 	// qbittorrent
 	QbittorrentUrl      = "qbittorrent_url"
@@ -114,6 +127,10 @@ const (
 	IMAGE
 )
 
+// S3MultipartDir is the dir in the temp dir where the parts of the s3 multipart uploads are staged,
+// it's kept on startup to resume the uploads
+const S3MultipartDir = "s3_multipart"
+
 // ContextKey is the type of context keys.
 const (
 	NoTaskKey = "no_task"
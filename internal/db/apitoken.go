@@ -0,0 +1,52 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetApiTokensByUserId(userId uint, pageIndex, pageSize int) (tokens []model.ApiToken, count int64, err error) {
+	tokenDB := db.Model(&model.ApiToken{}).Where("user_id = ?", userId)
+	if err = tokenDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's tokens count")
+	}
+	if err = tokenDB.Order("id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's tokens")
+	}
+	return tokens, count, nil
+}
+
+func GetApiTokenById(id uint) (*model.ApiToken, error) {
+	var t model.ApiToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get token")
+	}
+	return &t, nil
+}
+
+func GetApiTokenByHash(hash string) (*model.ApiToken, error) {
+	t := model.ApiToken{Hash: hash}
+	if err := db.Where(t).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find token")
+	}
+	return &t, nil
+}
+
+func CreateApiToken(t *model.ApiToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateApiTokenLastUsed(id uint, lastUsed time.Time) error {
+	return errors.WithStack(db.Model(&model.ApiToken{}).Where("id = ?", id).
+		UpdateColumn("last_used", lastUsed).Error)
+}
+
+func DeleteApiTokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ApiToken{}, id).Error)
+}
+
+func DeleteApiTokensByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.ApiToken{}).Error)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func GetAuditLogs(req model.AuditLogReq) (logs []model.AuditLog, count int64, err error) {
+	auditDB := db.Model(&model.AuditLog{})
+	if req.Username != "" {
+		auditDB = auditDB.Where("username = ?", req.Username)
+	}
+	if req.Action != "" {
+		auditDB = auditDB.Where("action = ?", req.Action)
+	}
+	if req.Path != "" {
+		srcClause, pattern := likePrefix("src_path", req.Path)
+		dstClause, _ := likePrefix("dst_path", req.Path)
+		auditDB = auditDB.Where(srcClause+" OR "+dstClause, pattern, pattern)
+	}
+	if req.IP != "" {
+		auditDB = auditDB.Where("ip = ?", req.IP)
+	}
+	if req.Success != nil {
+		auditDB = auditDB.Where("success = ?", *req.Success)
+	}
+	if req.Start > 0 {
+		auditDB = auditDB.Where("created_at >= ?", time.Unix(req.Start, 0))
+	}
+	if req.End > 0 {
+		auditDB = auditDB.Where("created_at <= ?", time.Unix(req.End, 0))
+	}
+	if err = auditDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err = auditDB.Order("created_at desc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find audit logs")
+	}
+	return logs, count, nil
+}
+
+func DeleteAuditLogsBefore(t time.Time) error {
+	return errors.WithStack(db.Where("created_at < ?", t).Delete(&model.AuditLog{}).Error)
+}
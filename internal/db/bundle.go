@@ -0,0 +1,92 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// BundleRows are the rows saved and deleted by ImportBundle
+type BundleRows struct {
+	Storages []model.Storage
+	Metas    []model.Meta
+	Users    []model.User
+	Settings []model.SettingItem
+	// ids of the rows to delete
+	DeletedStorages []uint
+	DeletedMetas    []uint
+	DeletedUsers    []uint
+}
+
+func GetAllStorages() (storages []model.Storage, err error) {
+	if err = db.Order(columnName("order")).Find(&storages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find storages")
+	}
+	return storages, nil
+}
+
+func GetAllMetas() (metas []model.Meta, err error) {
+	if err = db.Find(&metas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find metas")
+	}
+	return metas, nil
+}
+
+func GetAllUsers() (users []model.User, err error) {
+	if err = db.Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find users")
+	}
+	return users, nil
+}
+
+// ImportBundle deletes and saves the rows in one transaction, the rows are deleted first
+// so that the saved ones can take their unique keys. The ssh keys, shares and usages
+// of the deleted users are deleted too, and their usages are subtracted from the storages
+func ImportBundle(rows BundleRows) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if len(rows.DeletedStorages) > 0 {
+			if err := tx.Delete(&model.Storage{}, rows.DeletedStorages).Error; err != nil {
+				return err
+			}
+		}
+		if len(rows.DeletedMetas) > 0 {
+			if err := tx.Delete(&model.Meta{}, rows.DeletedMetas).Error; err != nil {
+				return err
+			}
+		}
+		if len(rows.DeletedUsers) > 0 {
+			for _, m := range []interface{}{&model.SSHPublicKey{}, &model.Share{}, &model.ApiToken{}, &model.S3Key{}} {
+				if err := tx.Where("user_id in ?", rows.DeletedUsers).Delete(m).Error; err != nil {
+					return err
+				}
+			}
+			for _, id := range rows.DeletedUsers {
+				if err := deleteUsages(tx, id); err != nil {
+					return err
+				}
+			}
+			if err := tx.Delete(&model.User{}, rows.DeletedUsers).Error; err != nil {
+				return err
+			}
+		}
+		for i := range rows.Storages {
+			if err := tx.Save(&rows.Storages[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range rows.Metas {
+			if err := tx.Save(&rows.Metas[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range rows.Users {
+			if err := tx.Save(&rows.Users[i]).Error; err != nil {
+				return err
+			}
+		}
+		if len(rows.Settings) > 0 {
+			return tx.Save(rows.Settings).Error
+		}
+		return nil
+	}))
+}
@@ -12,10 +12,13 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Share), new(model.TrashItem), new(model.AuditLog), new(model.SyncJob), new(model.SyncState), new(model.Usage), new(model.UsageTotal), new(model.SearchIndex), new(model.ApiToken), new(model.S3Key), new(model.S3Upload), new(model.S3UploadPart), new(model.WebdavLock), new(model.WebdavLockMutex), new(model.WebdavProp))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
+	if err = initUsageTotals(); err != nil {
+		log.Fatalf("failed init usage totals: %+v", err)
+	}
 }
 
 func AutoMigrate(dst ...interface{}) error {
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetS3KeysByUserId(userId uint, pageIndex, pageSize int) (keys []model.S3Key, count int64, err error) {
+	keyDB := db.Model(&model.S3Key{}).Where("user_id = ?", userId)
+	if err = keyDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's s3 keys count")
+	}
+	if err = keyDB.Order("id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's s3 keys")
+	}
+	return keys, count, nil
+}
+
+func GetAllS3Keys() (keys []model.S3Key, err error) {
+	if err = db.Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 keys")
+	}
+	return keys, nil
+}
+
+func GetS3KeyById(id uint) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3KeyByAccessKeyId(accessKeyId string) (*model.S3Key, error) {
+	k := model.S3Key{AccessKeyId: accessKeyId}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 key")
+	}
+	return &k, nil
+}
+
+func CreateS3Key(k *model.S3Key) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Key{}, id).Error)
+}
+
+func DeleteS3KeysByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.S3Key{}).Error)
+}
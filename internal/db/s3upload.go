@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetS3Uploads() (uploads []model.S3Upload, err error) {
+	if err := db.Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 uploads")
+	}
+	return uploads, nil
+}
+
+func GetS3UploadsByBucket(userId uint, bucket, prefix string) (uploads []model.S3Upload, err error) {
+	uploadDB := db.Where("user_id = ? AND bucket = ?", userId, bucket)
+	if prefix != "" {
+		uploadDB = uploadDB.Where(fmt.Sprintf("%s LIKE ?", columnName("key")), prefix+"%")
+	}
+	if err := uploadDB.Order(columnName("key")).Order("id").Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 uploads")
+	}
+	return uploads, nil
+}
+
+func GetS3UploadById(id string) (*model.S3Upload, error) {
+	var u model.S3Upload
+	if err := db.Where("id = ?", id).First(&u).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 upload")
+	}
+	return &u, nil
+}
+
+func CreateS3Upload(u *model.S3Upload) error {
+	return errors.WithStack(db.Create(u).Error)
+}
+
+// DeleteS3UploadById deletes the upload with its parts
+func DeleteS3UploadById(id string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("upload_id = ?", id).Delete(&model.S3UploadPart{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&model.S3Upload{}).Error
+	}))
+}
+
+func GetS3UploadParts(uploadId string) (parts []model.S3UploadPart, err error) {
+	if err := db.Where("upload_id = ?", uploadId).Order("part_number").Find(&parts).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 upload parts")
+	}
+	return parts, nil
+}
+
+// GetS3StagedSize returns the total size of the parts of the uploads of the user,
+// except the part of the upload which is going to be overwritten
+func GetS3StagedSize(userId uint, uploadId string, partNumber int) (int64, error) {
+	var size int64
+	err := db.Model(&model.S3UploadPart{}).
+		Where("upload_id IN (?)", db.Model(&model.S3Upload{}).Select("id").Where("user_id = ?", userId)).
+		Where("NOT (upload_id = ? AND part_number = ?)", uploadId, partNumber).
+		Select("COALESCE(SUM(size), 0)").Scan(&size).Error
+	return size, errors.Wrapf(err, "failed get staged size of s3 uploads")
+}
+
+// SaveS3UploadPart creates the part or overwrites the part with the same number
+func SaveS3UploadPart(p *model.S3UploadPart) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{UpdateAll: true}).Create(p).Error)
+}
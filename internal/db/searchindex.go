@@ -0,0 +1,55 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSearchIndexById(id uint) (*model.SearchIndex, error) {
+	var s model.SearchIndex
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get search index")
+	}
+	return &s, nil
+}
+
+func CreateSearchIndex(s *model.SearchIndex) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateSearchIndex(s *model.SearchIndex) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+// UpdateSearchIndexProgress only updates the progress, so that the changes of the index
+// made during the building are kept
+func UpdateSearchIndexProgress(id uint, progress model.IndexProgress) error {
+	return errors.WithStack(db.Model(&model.SearchIndex{}).Where("id = ?", id).Updates(map[string]any{
+		"progress_obj_count":      progress.ObjCount,
+		"progress_is_done":        progress.IsDone,
+		"progress_last_done_time": progress.LastDoneTime,
+		"progress_error":          progress.Error,
+	}).Error)
+}
+
+func GetSearchIndexes(pageIndex, pageSize int) (indexes []model.SearchIndex, count int64, err error) {
+	indexDB := db.Model(&model.SearchIndex{})
+	if err = indexDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get search indexes count")
+	}
+	if err = indexDB.Order("id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&indexes).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find search indexes")
+	}
+	return indexes, count, nil
+}
+
+func GetAllSearchIndexes() (indexes []model.SearchIndex, err error) {
+	if err = db.Order("id").Find(&indexes).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find search indexes")
+	}
+	return indexes, nil
+}
+
+func DeleteSearchIndexById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SearchIndex{}, id).Error)
+}
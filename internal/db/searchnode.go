@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	stdpath "path"
+	"regexp/syntax"
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -21,61 +22,82 @@ func whereInParent(parent string) *gorm.DB {
 		Or(fmt.Sprintf("%s = ?", columnName("parent")), parent)
 }
 
-func CreateSearchNode(node *model.SearchNode) error {
+func whereInIndex(index string) *gorm.DB {
+	return db.Where(fmt.Sprintf("%s = ?", columnName("index_name")), index)
+}
+
+func CreateSearchNode(index string, node *model.SearchNode) error {
+	node.IndexName = index
 	return db.Create(node).Error
 }
 
-func BatchCreateSearchNodes(nodes *[]model.SearchNode) error {
+func BatchCreateSearchNodes(index string, nodes *[]model.SearchNode) error {
+	for i := range *nodes {
+		(*nodes)[i].IndexName = index
+	}
 	return db.CreateInBatches(nodes, 1000).Error
 }
 
-func DeleteSearchNodesByParent(path string) error {
+func DeleteSearchNodesByParent(index, path string) error {
 	path = utils.FixAndCleanPath(path)
-	err := db.Where(whereInParent(path)).Delete(&model.SearchNode{}).Error
+	err := db.Where(whereInIndex(index)).Where(whereInParent(path)).Delete(&model.SearchNode{}).Error
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
-	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
+	return db.Where(whereInIndex(index)).Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
-		dir, name).Delete(&model.SearchNode{}).Error
+		stdpath.Dir(path), stdpath.Base(path)).Delete(&model.SearchNode{}).Error
 }
 
-func ClearSearchNodes() error {
-	return db.Where("1 = 1").Delete(&model.SearchNode{}).Error
+func ClearSearchNodes(index string) error {
+	return db.Where(whereInIndex(index)).Delete(&model.SearchNode{}).Error
 }
 
-func GetSearchNodesByParent(parent string) ([]model.SearchNode, error) {
+func GetSearchNodesByParent(index, parent string) ([]model.SearchNode, error) {
 	var nodes []model.SearchNode
-	if err := db.Where(fmt.Sprintf("%s = ?",
+	if err := db.Where(whereInIndex(index)).Where(fmt.Sprintf("%s = ?",
 		columnName("parent")), parent).Find(&nodes).Error; err != nil {
 		return nil, err
 	}
 	return nodes, nil
 }
 
-func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
-	var searchDB *gorm.DB
-	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
+func SearchNode(index string, req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
+	searchDB := db.Model(&model.SearchNode{}).Where(whereInIndex(index)).Where(whereInParent(req.Parent))
+	switch {
+	case req.NameMatch == model.NameMatchGlob:
+		// the glob is matched roughly by LIKE, and exactly after querying
+		searchDB = searchDB.Where("LOWER(name) LIKE ? ESCAPE '!'", globToLike(strings.ToLower(req.Keywords)))
+	case req.NameMatch == model.NameMatchRegex:
+		// databases differ in regular expressions, so the names are narrowed down by the literal
+		// which the regex requires and matched after querying
+		if literal := regexLiteral(req.Keywords); literal != "" {
+			searchDB = searchDB.Where("name LIKE ? ESCAPE '!'", "%"+likeReplacer.Replace(literal)+"%")
+		}
+	case !useFullText || conf.Conf.Database.Type == "sqlite3":
 		keywordsClause := db.Where("1 = 1")
 		for _, keyword := range strings.Fields(req.Keywords) {
 			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
 		}
-		searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
-	} else {
-		switch conf.Conf.Database.Type {
-		case "mysql":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
-		case "postgres":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
-		}
+		searchDB = searchDB.Where(keywordsClause)
+	case conf.Conf.Database.Type == "mysql":
+		searchDB = searchDB.Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
+	case conf.Conf.Database.Type == "postgres":
+		searchDB = searchDB.Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
 	}
+	searchDB = whereSearchFilters(searchDB, req)
 
-	if req.Scope != 0 {
-		isDir := req.Scope == 1
-		searchDB.Where(db.Where("is_dir = ?", isDir))
+	order := "name asc"
+	if req.OrderBy != "" {
+		order = columnName(req.OrderBy)
+		if req.OrderDirection == "desc" {
+			order += " desc"
+		}
+	}
+	// the names may be the same in different dirs, so the pages are ordered by the parent as well to be stable
+	order += ", " + columnName("parent")
+	if req.NameMatch != "" {
+		return matchSearchNodes(searchDB.Order(order), req)
 	}
 
 	var count int64
@@ -83,9 +105,129 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	if err := searchDB.Order(order).Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 	return files, count, nil
 }
+
+// searchBatchSize is the count of rows read at once when the names are matched after querying
+const searchBatchSize = 1000
+
+// matchSearchNodes pages through the rows of searchDB and keeps the nodes of the requested page
+// whose names match, the total is the count of all the matched nodes
+func matchSearchNodes(searchDB *gorm.DB, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	tx := searchDB.Session(&gorm.Session{})
+	start := (req.Page - 1) * req.PerPage
+	var (
+		res   []model.SearchNode
+		total int
+	)
+	for offset := 0; ; offset += searchBatchSize {
+		var nodes []model.SearchNode
+		if err := tx.Offset(offset).Limit(searchBatchSize).Find(&nodes).Error; err != nil {
+			return nil, 0, err
+		}
+		for _, node := range nodes {
+			if !req.MatchName(node.Name) {
+				continue
+			}
+			if total >= start && total < start+req.PerPage {
+				res = append(res, node)
+			}
+			total++
+		}
+		if len(nodes) < searchBatchSize {
+			return res, int64(total), nil
+		}
+	}
+}
+
+// regexLiteral returns the longest literal which all the names matched by the regex contain,
+// it's empty if there's no such literal or the literal is case-insensitive
+func regexLiteral(expr string) string {
+	re, err := syntax.Parse("^(?:"+expr+")$", syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	var required func(re *syntax.Regexp) string
+	required = func(re *syntax.Regexp) string {
+		switch re.Op {
+		case syntax.OpLiteral:
+			if re.Flags&syntax.FoldCase == 0 {
+				return string(re.Rune)
+			}
+		case syntax.OpCapture, syntax.OpPlus:
+			return required(re.Sub[0])
+		case syntax.OpRepeat:
+			if re.Min > 0 {
+				return required(re.Sub[0])
+			}
+		case syntax.OpConcat:
+			var longest string
+			for _, sub := range re.Sub {
+				if literal := required(sub); len(literal) > len(longest) {
+					longest = literal
+				}
+			}
+			return longest
+		}
+		return ""
+	}
+	return required(re.Simplify())
+}
+
+// globToLike converts a glob pattern of path.Match to a LIKE pattern escaped with !,
+// a character class is taken as any character
+func globToLike(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(likeReplacer.Replace(glob[i : i+1]))
+		case c == '[':
+			for i < len(glob) && glob[i] != ']' {
+				i++
+			}
+			sb.WriteByte('_')
+		case c == '*':
+			sb.WriteByte('%')
+		case c == '?':
+			sb.WriteByte('_')
+		default:
+			sb.WriteString(likeReplacer.Replace(glob[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
+func whereSearchFilters(searchDB *gorm.DB, req model.SearchReq) *gorm.DB {
+	if req.Scope != 0 {
+		searchDB = searchDB.Where("is_dir = ?", req.Scope == 1)
+	}
+	if req.MinSize > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s >= ?", columnName("size")), req.MinSize)
+	}
+	if req.MaxSize > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s <= ?", columnName("size")), req.MaxSize)
+	}
+	if !req.ModifiedAfter.IsZero() {
+		searchDB = searchDB.Where(fmt.Sprintf("%s >= ?", columnName("modified")), req.ModifiedAfter.UTC())
+	}
+	if !req.ModifiedBefore.IsZero() {
+		searchDB = searchDB.Where(fmt.Sprintf("%s <= ?", columnName("modified")), req.ModifiedBefore.UTC())
+	}
+	if len(req.FileTypes) > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s IN ?", columnName("file_type")), req.FileTypes)
+	}
+	if len(req.Extensions) > 0 {
+		extClause := db.Where("1 = 0")
+		for _, ext := range req.Extensions {
+			extClause = extClause.Or("LOWER(name) LIKE ? ESCAPE '!'", "%."+likeReplacer.Replace(ext))
+		}
+		searchDB = searchDB.Where("is_dir = ?", false).Where(extClause)
+	}
+	return searchDB
+}
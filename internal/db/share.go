@@ -0,0 +1,67 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetShareById(id string) (*model.Share, error) {
+	var s model.Share
+	if err := db.Where("id = ?", id).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share")
+	}
+	return &s, nil
+}
+
+// GetShares returns the shares of the user, all shares are returned if userId is 0
+func GetShares(userId uint, pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	shareDB := db.Model(&model.Share{})
+	if userId != 0 {
+		shareDB = shareDB.Where("user_id = ?", userId)
+	}
+	if err = shareDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get shares count")
+	}
+	if err = shareDB.Order("created desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find shares")
+	}
+	return shares, count, nil
+}
+
+func CreateShare(s *model.Share) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateShare(s *model.Share) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func DeleteShareById(id string) error {
+	return errors.WithStack(db.Where("id = ?", id).Delete(&model.Share{}).Error)
+}
+
+func DeleteSharesByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.Share{}).Error)
+}
+
+// IncreaseShareAccessed increases the access count of the share
+func IncreaseShareAccessed(id string) error {
+	return errors.WithStack(db.Model(&model.Share{}).Where("id = ?", id).
+		UpdateColumn("accessed", gorm.Expr("accessed + ?", 1)).Error)
+}
+
+// IncreaseShareDownloads increases the download count of the share,
+// it fails with gorm.ErrRecordNotFound if the max downloads has been reached
+func IncreaseShareDownloads(id string) error {
+	res := db.Model(&model.Share{}).
+		Where("id = ? AND (max_downloads <= 0 OR downloads < max_downloads)", id).
+		UpdateColumn("downloads", gorm.Expr("downloads + ?", 1))
+	if res.Error != nil {
+		return errors.WithStack(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errors.WithStack(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSSHPublicKeyByUserId(userId uint, pageIndex, pageSize int) (keys []model.SSHPublicKey, count int64, err error) {
+	keyDB := db.Model(&model.SSHPublicKey{}).Where("user_id = ?", userId)
+	if err = keyDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's keys count")
+	}
+	if err = keyDB.Order("id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find user's keys")
+	}
+	return keys, count, nil
+}
+
+func GetAllSSHPublicKeyByUserId(userId uint) (keys []model.SSHPublicKey, err error) {
+	if err = db.Where("user_id = ?", userId).Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get find user's keys")
+	}
+	return keys, nil
+}
+
+func GetSSHPublicKeyById(id uint) (*model.SSHPublicKey, error) {
+	var k model.SSHPublicKey
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get old key")
+	}
+	return &k, nil
+}
+
+func GetSSHPublicKeyByFingerprint(fingerprint string) (*model.SSHPublicKey, error) {
+	k := model.SSHPublicKey{Fingerprint: fingerprint}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find key")
+	}
+	return &k, nil
+}
+
+func CreateSSHPublicKey(k *model.SSHPublicKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func UpdateSSHPublicKey(k *model.SSHPublicKey) error {
+	return errors.WithStack(db.Save(k).Error)
+}
+
+func DeleteSSHPublicKeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SSHPublicKey{}, id).Error)
+}
+
+func DeleteSSHPublicKeyByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.SSHPublicKey{}).Error)
+}
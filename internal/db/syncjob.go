@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &j, nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+// UpdateSyncJobSummary only updates the summary of last run,
+// so that the changes of the job made during the run are kept
+func UpdateSyncJobSummary(id uint, summary model.SyncSummary) error {
+	return errors.WithStack(db.Model(&model.SyncJob{}).Where("id = ?", id).Updates(map[string]any{
+		"last_started_at":  summary.StartedAt,
+		"last_finished_at": summary.FinishedAt,
+		"last_copied":      summary.Copied,
+		"last_skipped":     summary.Skipped,
+		"last_deleted":     summary.Deleted,
+		"last_renamed":     summary.Renamed,
+		"last_conflicts":   summary.Conflicts,
+		"last_failed":      summary.Failed,
+		"last_errors":      summary.Errors,
+	}).Error)
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err = jobDB.Order("id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledSyncJobs() (jobs []model.SyncJob, err error) {
+	if err = db.Where(fmt.Sprintf("%s = ?", columnName("disabled")), false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled sync jobs")
+	}
+	return jobs, nil
+}
+
+func DeleteSyncJobById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", id).Delete(&model.SyncState{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.SyncJob{}, id).Error
+	}))
+}
+
+func GetSyncStates(jobId uint) (states []model.SyncState, err error) {
+	if err = db.Where("job_id = ?", jobId).Find(&states).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sync states")
+	}
+	return states, nil
+}
+
+// ReplaceSyncStates replaces all states of the job with states
+func ReplaceSyncStates(jobId uint, states []model.SyncState) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", jobId).Delete(&model.SyncState{}).Error; err != nil {
+			return err
+		}
+		if len(states) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(states, 100).Error
+	}))
+}
+
+func DeleteSyncStatesByJobId(jobId uint) error {
+	return errors.WithStack(db.Where("job_id = ?", jobId).Delete(&model.SyncState{}).Error)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(t *model.TrashItem) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var t model.TrashItem
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &t, nil
+}
+
+// GetTrashItems returns the trash items removed by the user, all items are returned if userId is 0
+func GetTrashItems(userId uint, pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	if userId != 0 {
+		trashDB = trashDB.Where("user_id = ?", userId)
+	}
+	if err = trashDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err = trashDB.Order("deleted_at desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find trash items")
+	}
+	return items, count, nil
+}
+
+func GetTrashItemsDeletedBefore(t time.Time) (items []model.TrashItem, err error) {
+	if err = db.Where("deleted_at < ?", t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get find trash items")
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
+
+func DeleteTrashItemsByTrashPath(trashPath string) error {
+	return errors.WithStack(db.Where("trash_path = ?", trashPath).Delete(&model.TrashItem{}).Error)
+}
+
+func DeleteTrashItemsByMountPath(mountPath string) error {
+	return errors.WithStack(db.Where("mount_path = ?", mountPath).Delete(&model.TrashItem{}).Error)
+}
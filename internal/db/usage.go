@@ -0,0 +1,195 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// usageExpr adds n to the column, the usage never goes below 0
+func usageExpr(column string, n int64) clause.Expr {
+	return gorm.Expr("CASE WHEN "+column+" + ? < 0 THEN 0 ELSE "+column+" + ? END", n, n)
+}
+
+// addUsageTotal adds bytes and files to the total if the quotas are not exceeded, the quotas <= 0 mean no limit
+// and they're not checked when the usage decreases. It reports whether the total is updated.
+func addUsageTotal(tx *gorm.DB, userId uint, mountPath string, bytes, files, quotaBytes, quotaFiles int64) (bool, error) {
+	for created := false; ; created = true {
+		q := tx.Model(&model.UsageTotal{}).Where("user_id = ? AND mount_path = ?", userId, mountPath)
+		if bytes > 0 && quotaBytes > 0 {
+			q = q.Where("bytes + ? <= ?", bytes, quotaBytes)
+		}
+		if files > 0 && quotaFiles > 0 {
+			q = q.Where("files + ? <= ?", files, quotaFiles)
+		}
+		res := q.Updates(map[string]any{
+			"bytes": usageExpr("bytes", bytes),
+			"files": usageExpr("files", files),
+		})
+		if res.Error != nil {
+			return false, errors.Wrapf(res.Error, "failed update usage total")
+		}
+		if res.RowsAffected > 0 || created {
+			return res.RowsAffected > 0, nil
+		}
+		// the total may not exist yet, create it and try again
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UsageTotal{UserId: userId, MountPath: mountPath}).Error
+		if err != nil {
+			return false, errors.Wrapf(err, "failed create usage total")
+		}
+	}
+}
+
+func getUsageTotal(tx *gorm.DB, userId uint, mountPath string) (model.UsageTotal, error) {
+	var total model.UsageTotal
+	err := tx.Where("user_id = ? AND mount_path = ?", userId, mountPath).Limit(1).Find(&total).Error
+	return total, errors.Wrapf(err, "failed get usage total")
+}
+
+// ReserveUsage checks the quotas and adds bytes and files to the usage of the user in the storage at once,
+// the quotas <= 0 mean no limit. Nothing is added if any quota is exceeded.
+func ReserveUsage(userId uint, mountPath string, bytes, files, quotaBytes, quotaFiles, mountQuotaBytes int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		ok, err := addUsageTotal(tx, userId, "", bytes, files, quotaBytes, quotaFiles)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			total, err := getUsageTotal(tx, userId, "")
+			if err != nil {
+				return err
+			}
+			if quotaBytes > 0 && total.Bytes+bytes > quotaBytes {
+				return errors.Wrapf(errs.QuotaExceeded, "used %d of %d bytes, %d more bytes can't be uploaded",
+					total.Bytes, quotaBytes, bytes)
+			}
+			return errors.Wrapf(errs.QuotaExceeded, "used %d of %d files", total.Files, quotaFiles)
+		}
+		ok, err = addUsageTotal(tx, 0, mountPath, bytes, files, mountQuotaBytes, 0)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			total, err := getUsageTotal(tx, 0, mountPath)
+			if err != nil {
+				return err
+			}
+			return errors.Wrapf(errs.StorageQuotaExceeded, "used %d of %d bytes, %d more bytes can't be uploaded",
+				total.Bytes, mountQuotaBytes, bytes)
+		}
+		res := tx.Model(&model.Usage{}).Where("user_id = ? AND mount_path = ?", userId, mountPath).Updates(map[string]any{
+			"bytes": usageExpr("bytes", bytes),
+			"files": usageExpr("files", files),
+		})
+		if res.Error != nil {
+			return errors.Wrapf(res.Error, "failed update usage")
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+		u := model.Usage{UserId: userId, MountPath: mountPath, Bytes: max(bytes, 0), Files: max(files, 0)}
+		return errors.Wrapf(tx.Create(&u).Error, "failed create usage")
+	})
+}
+
+// AddUsage adds bytes and files to the usage of the user in the storage without checking the quotas,
+// they can be negative and the usage never goes below 0
+func AddUsage(userId uint, mountPath string, bytes, files int64) error {
+	return ReserveUsage(userId, mountPath, bytes, files, 0, 0, 0)
+}
+
+// initUsageTotals sums up the usages into the totals, which don't exist in the databases of older versions
+func initUsageTotals() error {
+	var count int64
+	if err := db.Model(&model.UsageTotal{}).Count(&count).Error; err != nil || count > 0 {
+		return errors.WithStack(err)
+	}
+	var totals []model.UsageTotal
+	err := db.Model(&model.Usage{}).Select("user_id, '' AS mount_path, SUM(bytes) AS bytes, SUM(files) AS files").
+		Group("user_id").Scan(&totals).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var mountTotals []model.UsageTotal
+	err = db.Model(&model.Usage{}).Select("0 AS user_id, mount_path, SUM(bytes) AS bytes, SUM(files) AS files").
+		Group("mount_path").Scan(&mountTotals).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	totals = append(totals, mountTotals...)
+	if len(totals) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.CreateInBatches(totals, 100).Error)
+}
+
+// GetUsage returns the total usage of the user in all storages
+func GetUsage(userId uint) (bytes, files int64, err error) {
+	total, err := getUsageTotal(db, userId, "")
+	if err != nil {
+		return 0, 0, errors.WithMessage(err, "failed get usage")
+	}
+	return total.Bytes, total.Files, nil
+}
+
+// GetUsagesGroupByUser returns the total usages of the users, the users without usage are not included
+func GetUsagesGroupByUser(userIds []uint) (usages []model.UserUsage, err error) {
+	err = db.Model(&model.UsageTotal{}).Select("user_id, bytes, files").
+		Where("user_id IN ? AND mount_path = ''", userIds).Scan(&usages).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get usages of users")
+	}
+	return usages, nil
+}
+
+// GetUsagesGroupByMount returns the total usages of the storages which have usage
+func GetUsagesGroupByMount() (usages []model.MountUsage, err error) {
+	err = db.Model(&model.UsageTotal{}).Select("mount_path, bytes, files").
+		Where("user_id = 0").Scan(&usages).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get usages of storages")
+	}
+	return usages, nil
+}
+
+// GetMountUsage returns the total bytes uploaded to the storage by all users
+func GetMountUsage(mountPath string) (int64, error) {
+	total, err := getUsageTotal(db, 0, mountPath)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed get usage of storage")
+	}
+	return total.Bytes, nil
+}
+
+func GetUsagesByUserId(userId uint) (usages []model.Usage, err error) {
+	if err = db.Where("user_id = ?", userId).Order("mount_path").Find(&usages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find usages")
+	}
+	return usages, nil
+}
+
+// DeleteUsagesByUserId deletes the usages of the user, they're subtracted from the totals of the storages
+func DeleteUsagesByUserId(userId uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return deleteUsages(tx, userId)
+	})
+}
+
+// deleteUsages deletes the usages and totals of the user in tx and subtracts them from the totals of the storages
+func deleteUsages(tx *gorm.DB, userId uint) error {
+	var usages []model.Usage
+	if err := tx.Where("user_id = ?", userId).Find(&usages).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	for _, u := range usages {
+		if _, err := addUsageTotal(tx, 0, u.MountPath, -u.Bytes, -u.Files, 0, 0); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("user_id = ?", userId).Delete(&model.Usage{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(tx.Where("user_id = ?", userId).Delete(&model.UsageTotal{}).Error)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 )
@@ -12,3 +13,11 @@ func columnName(name string) string {
 	}
 	return fmt.Sprintf("`%s`", name)
 }
+
+var likeReplacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// likePrefix returns a LIKE clause of column matching the values starting with prefix,
+// the wildcards in prefix are escaped with ! which works in all the supported databases
+func likePrefix(column, prefix string) (string, string) {
+	return fmt.Sprintf("%s LIKE ? ESCAPE '!'", column), likeReplacer.Replace(prefix) + "%"
+}
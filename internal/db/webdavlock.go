@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func lockWebdavLocks(tx *gorm.DB, now time.Time) error {
+	for created := false; ; created = true {
+		res := tx.Model(&model.WebdavLockMutex{}).Where("id = ?", 1).UpdateColumn("updated", now)
+		if res.Error != nil {
+			return errors.Wrapf(res.Error, "failed lock webdav locks")
+		}
+		if res.RowsAffected > 0 || created {
+			return nil
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.WebdavLockMutex{ID: 1, Updated: now}).Error
+		if err != nil {
+			return errors.Wrapf(err, "failed create mutex of webdav locks")
+		}
+	}
+}
+
+// WebdavLockQuery selects the locks passed to the fn of UpdateWebdavLocks
+type WebdavLockQuery struct {
+	// Tokens selects the locks of the tokens
+	Tokens []string
+	// Root selects the locks of Root, its ancestors and its descendants
+	Root string
+	// All selects all the locks
+	All bool
+}
+
+func (q WebdavLockQuery) where() *gorm.DB {
+	if q.All || q.Root == "/" {
+		return db.Where("1 = 1")
+	}
+	cond := db.Where("1 = 0")
+	if len(q.Tokens) > 0 {
+		cond = cond.Or("token IN ?", q.Tokens)
+	}
+	if q.Root != "" {
+		ancestors := []string{"/"}
+		for i := 1; i < len(q.Root); i++ {
+			if q.Root[i] == '/' {
+				ancestors = append(ancestors, q.Root[:i])
+			}
+		}
+		cond = cond.Or(fmt.Sprintf("%s IN ?", columnName("root")), append(ancestors, q.Root))
+		clause, pattern := likePrefix(columnName("root"), q.Root+"/")
+		cond = cond.Or(clause, pattern)
+	}
+	return cond
+}
+
+// WebdavLockChanges are the changes of the locks made by UpdateWebdavLocks
+type WebdavLockChanges struct {
+	Save   []*model.WebdavLock
+	Delete []string
+}
+
+// UpdateWebdavLocks sweeps the expired locks and passes the others selected by query keyed by token to fn,
+// then applies the changes returned by fn, all in one transaction serialized with the others.
+// The locks which expired before now are swept, unless they're held. The error of fn is returned as is.
+func UpdateWebdavLocks(now time.Time, query WebdavLockQuery, fn func(locks map[string]*model.WebdavLock) (WebdavLockChanges, error)) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockWebdavLocks(tx, now); err != nil {
+			return err
+		}
+		err := tx.Where("expiry IS NOT NULL AND expiry <= ?", now).
+			Where("held_until IS NULL OR held_until <= ?", now).
+			Delete(&model.WebdavLock{}).Error
+		if err != nil {
+			return errors.Wrapf(err, "failed delete expired webdav locks")
+		}
+		var locks []model.WebdavLock
+		if err := tx.Where(query.where()).Order("root").Find(&locks).Error; err != nil {
+			return errors.Wrapf(err, "failed find webdav locks")
+		}
+		byToken := make(map[string]*model.WebdavLock, len(locks))
+		for i := range locks {
+			byToken[locks[i].Token] = &locks[i]
+		}
+		changes, err := fn(byToken)
+		if err != nil {
+			return err
+		}
+		for _, l := range changes.Save {
+			if err := tx.Save(l).Error; err != nil {
+				return errors.Wrapf(err, "failed save webdav lock")
+			}
+		}
+		if len(changes.Delete) > 0 {
+			if err := tx.Where("token IN ?", changes.Delete).Delete(&model.WebdavLock{}).Error; err != nil {
+				return errors.Wrapf(err, "failed delete webdav locks")
+			}
+		}
+		return nil
+	})
+}
+
+// ExtendWebdavLocksHeld extends the locks held by the request of heldBy until the time
+func ExtendWebdavLocksHeld(heldBy string, until time.Time) error {
+	return errors.WithStack(db.Model(&model.WebdavLock{}).Where("held_by = ?", heldBy).
+		UpdateColumn("held_until", until).Error)
+}
+
+// ReleaseWebdavLocks releases the locks held by the request of heldBy
+func ReleaseWebdavLocks(heldBy string) error {
+	return errors.WithStack(db.Model(&model.WebdavLock{}).Where("held_by = ?", heldBy).
+		UpdateColumns(map[string]any{"held_by": "", "held_until": nil}).Error)
+}
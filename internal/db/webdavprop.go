@@ -0,0 +1,114 @@
+package db
+
+import (
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// whereUnder matches the props of path and its descendants
+func whereUnder(tx *gorm.DB, path string) *gorm.DB {
+	if path == "/" {
+		return tx.Where("1 = 1")
+	}
+	clause, pattern := likePrefix(columnName("path"), path+"/")
+	return tx.Where(fmt.Sprintf("%s = ? OR %s", columnName("path"), clause), path, pattern)
+}
+
+// rebase returns p with its prefix src replaced by dst
+func rebase(p, src, dst string) string {
+	return dst + strings.TrimPrefix(p, src)
+}
+
+func GetWebdavProps(path string) (props []model.WebdavProp, err error) {
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).Order("id").Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webdav props")
+	}
+	return props, nil
+}
+
+// GetWebdavPropsByParent returns the props of the children of parent
+func GetWebdavPropsByParent(parent string) (props []model.WebdavProp, err error) {
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("parent")), parent).Order("id").Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webdav props")
+	}
+	return props, nil
+}
+
+// SaveWebdavProps replaces the props of path with props
+func SaveWebdavProps(path string, props []model.WebdavProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(fmt.Sprintf("%s = ?", columnName("path")), path).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = path
+			props[i].Parent = stdpath.Dir(path)
+		}
+		return tx.Create(&props).Error
+	}))
+}
+
+// MoveWebdavProps moves the props of src and its descendants to dst,
+// the props which were kept under dst are dropped since dst is overwritten
+func MoveWebdavProps(src, dst string) error {
+	if src == dst {
+		return nil
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var props []model.WebdavProp
+		if err := whereUnder(tx, src).Find(&props).Error; err != nil {
+			return err
+		}
+		if err := whereUnder(tx, dst).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		for _, p := range props {
+			p.Path = rebase(p.Path, src, dst)
+			p.Parent = stdpath.Dir(p.Path)
+			if err := tx.Save(&p).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyWebdavProps copies the props of src and its descendants to dst,
+// the props which were kept under dst are dropped since dst is overwritten
+func CopyWebdavProps(src, dst string) error {
+	if src == dst {
+		return nil
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var props []model.WebdavProp
+		if err := whereUnder(tx, src).Find(&props).Error; err != nil {
+			return err
+		}
+		if err := whereUnder(tx, dst).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = rebase(props[i].Path, src, dst)
+			props[i].Parent = stdpath.Dir(props[i].Path)
+		}
+		return tx.CreateInBatches(&props, 1000).Error
+	}))
+}
+
+// DeleteWebdavProps deletes the props of path and its descendants
+func DeleteWebdavProps(path string) error {
+	return errors.WithStack(whereUnder(db, path).Delete(&model.WebdavProp{}).Error)
+}
@@ -0,0 +1,168 @@
+package declarative
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the storages file, the keys are the same as the json of the models.
+// The storages are matched by the mount path, the metas by the path and the users by the username
+type File struct {
+	Storages []Storage    `json:"storages"`
+	Metas    []model.Meta `json:"metas"`
+	// Users are the general users, the password is in plaintext
+	Users []model.User `json:"users"`
+	// raw are the entries as in the file, the fields not in the file are kept in the existing rows
+	raw rawFile
+}
+
+type rawFile struct {
+	Storages []json.RawMessage `json:"storages"`
+	Metas    []json.RawMessage `json:"metas"`
+	Users    []json.RawMessage `json:"users"`
+}
+
+// Storage is model.Storage with the addition in object
+type Storage struct {
+	model.Storage
+	// Addition is an object or the json string
+	Addition interface{} `json:"addition"`
+}
+
+func (s Storage) toStorage() (model.Storage, error) {
+	res := s.Storage
+	switch addition := s.Addition.(type) {
+	case nil:
+		res.Addition = "{}"
+	case string:
+		res.Addition = addition
+	default:
+		data, err := json.Marshal(addition)
+		if err != nil {
+			return res, errors.Wrapf(err, "invalid addition of storage %s", s.MountPath)
+		}
+		res.Addition = string(data)
+	}
+	return res, nil
+}
+
+// merge unmarshals the raw entry over the existing row, so the fields not in the file are kept
+func merge[T any](old T, raw json.RawMessage) (T, error) {
+	err := json.Unmarshal(raw, &old)
+	return old, errors.WithStack(err)
+}
+
+// mergeStorage unmarshals the raw entry over the existing storage, the addition is merged key by key
+// and kept as it is if nothing in it is changed
+func mergeStorage(old model.Storage, raw json.RawMessage) (model.Storage, error) {
+	spec, err := merge(Storage{Storage: old}, raw)
+	if err != nil {
+		return old, errors.WithMessagef(err, "invalid storage %s", old.MountPath)
+	}
+	if spec.Addition == nil {
+		return spec.Storage, nil
+	}
+	res, err := spec.toStorage()
+	if err != nil {
+		return res, err
+	}
+	var oldAddition, addition map[string]interface{}
+	if json.Unmarshal([]byte(res.Addition), &addition) != nil || json.Unmarshal([]byte(old.Addition), &oldAddition) != nil {
+		return res, nil
+	}
+	merged := make(map[string]interface{}, len(oldAddition)+len(addition))
+	for k, v := range oldAddition {
+		merged[k] = v
+	}
+	for k, v := range addition {
+		merged[k] = v
+	}
+	if reflect.DeepEqual(merged, oldAddition) {
+		res.Addition = old.Addition
+		return res, nil
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return res, errors.Wrapf(err, "invalid addition of storage %s", old.MountPath)
+	}
+	res.Addition = string(data)
+	return res, nil
+}
+
+// envRegexp matches ${NAME} and ${NAME:-default}, $${NAME} is kept as ${NAME}
+var envRegexp = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces the env vars in the string values of v
+func interpolate(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		var err error
+		res := envRegexp.ReplaceAllStringFunc(val, func(s string) string {
+			if s[1] == '$' {
+				return s[1:]
+			}
+			m := envRegexp.FindStringSubmatch(s)
+			if env, ok := os.LookupEnv(m[1]); ok {
+				return env
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			err = errors.Errorf("env %s is not set", m[1])
+			return s
+		})
+		return res, err
+	case map[string]interface{}:
+		for k := range val {
+			res, err := interpolate(val[k])
+			if err != nil {
+				return nil, err
+			}
+			val[k] = res
+		}
+	case []interface{}:
+		for i := range val {
+			res, err := interpolate(val[i])
+			if err != nil {
+				return nil, err
+			}
+			val[i] = res
+		}
+	}
+	return v, nil
+}
+
+// Load reads the storages file and interpolates the env vars, the unknown keys are rejected
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, errors.Wrap(err, "failed parse storages file")
+	}
+	if v, err = interpolate(v); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(v); err != nil {
+		return nil, errors.Wrap(err, "failed parse storages file")
+	}
+	var f File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, errors.Wrap(err, "failed parse storages file")
+	}
+	if err := json.Unmarshal(data, &f.raw); err != nil {
+		return nil, errors.Wrap(err, "failed parse storages file")
+	}
+	return &f, nil
+}
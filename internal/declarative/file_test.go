@@ -0,0 +1,48 @@
+package declarative
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("ALIST_TEST_ENV", "value")
+	cases := map[string]string{
+		"${ALIST_TEST_ENV}":            "value",
+		"a-${ALIST_TEST_ENV}-b":        "a-value-b",
+		"${ALIST_TEST_UNSET:-default}": "default",
+		"$${ALIST_TEST_ENV}":           "${ALIST_TEST_ENV}",
+		"$ALIST_TEST_ENV":              "$ALIST_TEST_ENV",
+	}
+	for in, want := range cases {
+		got, err := interpolate(map[string]interface{}{"v": []interface{}{in}})
+		if err != nil {
+			t.Fatalf("interpolate %s: %+v", in, err)
+		}
+		if v := got.(map[string]interface{})["v"].([]interface{})[0]; v != want {
+			t.Errorf("interpolate %s = %s, want %s", in, v, want)
+		}
+	}
+	if _, err := interpolate("${ALIST_TEST_UNSET}"); err == nil {
+		t.Errorf("unset env should fail")
+	}
+}
+
+func TestMergeStorage(t *testing.T) {
+	old := model.Storage{MountPath: "/a", Order: 3, Driver: "Local", Addition: `{"root_folder_path":"/data","show_hidden":true}`}
+	got, err := mergeStorage(old, []byte(`{"mount_path":"/a","remark":"r","addition":{"show_hidden":true}}`))
+	if err != nil {
+		t.Fatalf("merge storage: %+v", err)
+	}
+	if got.Order != 3 || got.Driver != "Local" || got.Remark != "r" || got.Addition != old.Addition {
+		t.Errorf("omitted fields should be kept, got %+v", got)
+	}
+	got, err = mergeStorage(old, []byte(`{"addition":{"show_hidden":false}}`))
+	if err != nil {
+		t.Fatalf("merge storage: %+v", err)
+	}
+	if want := `{"root_folder_path":"/data","show_hidden":false}`; got.Addition != want {
+		t.Errorf("addition = %s, want %s", got.Addition, want)
+	}
+}
@@ -0,0 +1,283 @@
+// Package declarative reconciles the storages, metas and users declared in the storages file
+// into database.
+//
+// The declared rows have the Managed field set. The admin api refuses to update or delete them
+// and clears Managed on the rows it saves, so only the storages file changes them. A row removed
+// from the file is no longer managed, the storage or user is disabled and the meta is deleted
+package declarative
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var mu sync.Mutex
+
+// Reconcile makes the database match the storages file: the declared rows are created or updated,
+// the managed rows removed from the file are disabled (storages and users) or deleted (metas)
+// and no longer managed. Nothing is changed if the file doesn't exist
+func Reconcile(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	f, err := Load(conf.Conf.StoragesFile)
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil
+		}
+		return err
+	}
+	var errs []error
+	for _, err := range []error{reconcileStorages(ctx, f.Storages, f.raw.Storages),
+		reconcileMetas(f.Metas, f.raw.Metas), reconcileUsers(f.Users, f.raw.Users)} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utils.MergeErrors(errs...)
+}
+
+// same compares the json of a and b, the fields not in the file are merged from a by the caller
+func same(a, b interface{}) bool {
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return string(aj) == string(bj)
+}
+
+func reconcileStorages(ctx context.Context, specs []Storage, raws []json.RawMessage) error {
+	current, err := db.GetAllStorages()
+	if err != nil {
+		return err
+	}
+	byPath := make(map[string]model.Storage, len(current))
+	for _, s := range current {
+		byPath[s.MountPath] = s
+	}
+	declared := make(map[string]bool, len(specs))
+	var errs []error
+	for i, spec := range specs {
+		storage, err := spec.toStorage()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+		if declared[storage.MountPath] {
+			errs = append(errs, errors.Errorf("duplicate storage %s", storage.MountPath))
+			continue
+		}
+		declared[storage.MountPath] = true
+		storage.Managed = true
+		storage.SyncGroup = false
+		old, ok := byPath[storage.MountPath]
+		if !ok {
+			if err := createStorage(ctx, storage); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if storage, err = mergeStorage(old, raws[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		storage.MountPath, storage.Managed, storage.SyncGroup = old.MountPath, true, false
+		storage.ID, storage.Status, storage.Modified = old.ID, old.Status, old.Modified
+		if same(old, storage) {
+			continue
+		}
+		if err := updateStorage(ctx, old, storage); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, old := range current {
+		if !old.Managed || declared[old.MountPath] {
+			continue
+		}
+		if !old.Disabled {
+			if err := op.DisableStorage(ctx, old.ID); err != nil {
+				errs = append(errs, errors.WithMessagef(err, "failed disable storage %s", old.MountPath))
+				continue
+			}
+		}
+		storage, err := db.GetStorageById(old.ID)
+		if err == nil {
+			storage.Managed = false
+			err = db.UpdateStorage(storage)
+		}
+		if err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed unmanage storage %s", old.MountPath))
+			continue
+		}
+		log.Infof("storage %s is removed from the storages file, disabled", old.MountPath)
+	}
+	return utils.MergeErrors(errs...)
+}
+
+func createStorage(ctx context.Context, storage model.Storage) error {
+	// op.CreateStorage loads the storage even if it's disabled
+	id, err := op.CreateStorage(ctx, storage)
+	if err == nil && storage.Disabled {
+		err = op.DisableStorage(ctx, id)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "failed create storage %s", storage.MountPath)
+	}
+	log.Infof("storage %s in the storages file is created", storage.MountPath)
+	return nil
+}
+
+// updateStorage updates the storage, the disabled storage is only saved by op.UpdateStorage,
+// so it's disabled before and enabled after the update
+func updateStorage(ctx context.Context, old, storage model.Storage) error {
+	disabled := storage.Disabled
+	var err error
+	if !old.Disabled && disabled {
+		err = op.DisableStorage(ctx, old.ID)
+	}
+	if err == nil {
+		storage.Disabled = old.Disabled || disabled
+		err = op.UpdateStorage(ctx, storage)
+	}
+	if err == nil && old.Disabled && !disabled {
+		err = op.EnableStorage(ctx, old.ID)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "failed update storage %s", storage.MountPath)
+	}
+	log.Infof("storage %s in the storages file is updated", storage.MountPath)
+	return nil
+}
+
+func reconcileMetas(specs []model.Meta, raws []json.RawMessage) error {
+	current, err := db.GetAllMetas()
+	if err != nil {
+		return err
+	}
+	byPath := make(map[string]model.Meta, len(current))
+	for _, m := range current {
+		byPath[m.Path] = m
+	}
+	declared := make(map[string]bool, len(specs))
+	var errs []error
+	for i, meta := range specs {
+		meta.Path = utils.FixAndCleanPath(meta.Path)
+		if declared[meta.Path] {
+			errs = append(errs, errors.Errorf("duplicate meta %s", meta.Path))
+			continue
+		}
+		declared[meta.Path] = true
+		meta.Managed = true
+		old, ok := byPath[meta.Path]
+		if !ok {
+			meta.ID = 0
+			if err := op.CreateMeta(&meta); err != nil {
+				errs = append(errs, errors.WithMessagef(err, "failed create meta %s", meta.Path))
+			}
+			continue
+		}
+		if meta, err = merge(old, raws[i]); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "invalid meta %s", old.Path))
+			continue
+		}
+		meta.ID, meta.Path, meta.Managed = old.ID, old.Path, true
+		if same(old, meta) {
+			continue
+		}
+		if err := op.UpdateMeta(&meta); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed update meta %s", meta.Path))
+		}
+	}
+	for _, old := range current {
+		if !old.Managed || declared[old.Path] {
+			continue
+		}
+		if err := op.DeleteMetaById(old.ID); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed delete meta %s", old.Path))
+			continue
+		}
+		log.Infof("meta %s is removed from the storages file, deleted", old.Path)
+	}
+	return utils.MergeErrors(errs...)
+}
+
+func reconcileUsers(specs []model.User, raws []json.RawMessage) error {
+	current, err := db.GetAllUsers()
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]model.User, len(current))
+	for _, u := range current {
+		byName[u.Username] = u
+	}
+	declared := make(map[string]bool, len(specs))
+	var errs []error
+	for i, user := range specs {
+		if user.Username == "" || declared[user.Username] {
+			errs = append(errs, errors.Errorf("empty or duplicate user %s", user.Username))
+			continue
+		}
+		declared[user.Username] = true
+		password := user.Password
+		user.Password = ""
+		user.Role = model.GENERAL
+		user.Managed = true
+		user.BasePath = utils.FixAndCleanPath(user.BasePath)
+		old, ok := byName[user.Username]
+		if !ok {
+			if password == "" {
+				errs = append(errs, errors.Errorf("password of user %s is required", user.Username))
+				continue
+			}
+			user.ID = 0
+			user.SetPassword(password)
+			user.Authn = "[]"
+			if err := op.CreateUser(&user); err != nil {
+				errs = append(errs, errors.WithMessagef(err, "failed create user %s", user.Username))
+			}
+			continue
+		}
+		if old.IsAdmin() || old.IsGuest() {
+			errs = append(errs, errors.Errorf("user %s is the admin or the guest, which can't be managed", user.Username))
+			continue
+		}
+		if user, err = merge(old, raws[i]); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "invalid user %s", old.Username))
+			continue
+		}
+		user.Password, user.Role, user.Managed = "", model.GENERAL, true
+		user.BasePath = utils.FixAndCleanPath(user.BasePath)
+		// the secrets are kept, the password is reset if it's changed in the file
+		user.ID, user.PwdHash, user.PwdTS, user.Salt = old.ID, old.PwdHash, old.PwdTS, old.Salt
+		user.OtpSecret, user.SsoID, user.Authn = old.OtpSecret, old.SsoID, old.Authn
+		if password != "" && old.ValidateRawPassword(password) != nil {
+			user.SetPassword(password)
+		} else if same(old, user) {
+			continue
+		}
+		if err := op.UpdateUser(&user); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed update user %s", user.Username))
+		}
+	}
+	for _, old := range current {
+		if !old.Managed || declared[old.Username] {
+			continue
+		}
+		old.Disabled = true
+		old.Managed = false
+		if err := op.UpdateUser(&old); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed disable user %s", old.Username))
+			continue
+		}
+		log.Infof("user %s is removed from the storages file, disabled", old.Username)
+	}
+	return utils.MergeErrors(errs...)
+}
@@ -0,0 +1,83 @@
+package declarative
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchDelay is how long the events are collected before reconciling,
+// so that a file written in many steps is reconciled once
+const watchDelay = time.Second
+
+var lastSum [sha256.Size]byte
+
+// reconcileChanged reconciles if the content of the storages file is changed since the last time
+func reconcileChanged(ctx context.Context) {
+	data, err := os.ReadFile(conf.Conf.StoragesFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed read storages file: %+v", err)
+		}
+		return
+	}
+	sum := sha256.Sum256(data)
+	if sum == lastSum {
+		return
+	}
+	lastSum = sum
+	if err := Reconcile(ctx); err != nil {
+		log.Errorf("failed reconcile storages file: %+v", err)
+		return
+	}
+	log.Infof("storages file %s is reconciled", conf.Conf.StoragesFile)
+}
+
+// Watch reconciles the storages file and again whenever it's changed. The dir of the file is watched
+// since the editors and the config maps of kubernetes replace the file instead of writing it
+func Watch(ctx context.Context) error {
+	reconcileChanged(ctx)
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	base := filepath.Base(conf.Conf.StoragesFile)
+	if err := fsw.Add(filepath.Dir(conf.Conf.StoragesFile)); err != nil {
+		_ = fsw.Close()
+		return err
+	}
+	go func() {
+		defer fsw.Close()
+		var timer <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				// the config maps swap the ..data symlink
+				if name := filepath.Base(event.Name); name == base || strings.HasPrefix(name, "..") {
+					timer = time.After(watchDelay)
+				}
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("storages file watcher error: %+v", err)
+			case <-timer:
+				timer = nil
+				reconcileChanged(ctx)
+			}
+		}
+	}()
+	return nil
+}
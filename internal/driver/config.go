@@ -13,6 +13,7 @@ type Config struct {
 	Alert             string `json:"alert"` //info,success,warning,danger
 	NoOverwriteUpload bool   `json:"-"`     // whether to support overwrite upload
 	ProxyRangeOption  bool   `json:"-"`
+	MemCacheOnly      bool   `json:"-"` // the objs rely on their concrete types and can only be cached in memory
 }
 
 func (c Config) MustProxy() bool {
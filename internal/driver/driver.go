@@ -33,6 +33,11 @@ type Offline interface {
 	Offline(ctx context.Context, args model.OtherArgs) (interface{}, error)
 }
 
+// WithDetails reports the space of the storage, it's optional
+type WithDetails interface {
+	GetDetails(ctx context.Context) (*model.StorageDetails, error)
+}
+
 type Reader interface {
 	// List files in the path
 	// if identify files by path, need to set ID with path,like path.Join(dir.GetID(), obj.GetName())
@@ -113,6 +118,12 @@ type PutResult interface {
 	Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up UpdateProgress) (model.Obj, error)
 }
 
+// PutParts uploads the parts of the stream as the chunks, it's used instead of Put
+// when the stream implements model.FileParts
+type PutParts interface {
+	PutParts(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, parts []model.FilePart, up UpdateProgress) error
+}
+
 type UpdateProgress func(percentage float64)
 
 type Progress struct {
@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	InvalidApiToken = errors.New("api token is invalid or expired")
+	InvalidScope    = errors.New("invalid scope of api token")
+	ScopeRequired   = errors.New("api token doesn't have the scope")
+)
@@ -0,0 +1,8 @@
+package errs
+
+import "fmt"
+
+var (
+	InvalidBundle         = fmt.Errorf("invalid bundle")
+	WrongBundlePassphrase = fmt.Errorf("wrong passphrase of the bundle")
+)
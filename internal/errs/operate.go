@@ -4,4 +4,5 @@ import "errors"
 
 var (
 	PermissionDenied = errors.New("permission denied")
+	ManagedByFile    = errors.New("managed by the storages file, change it in the file instead")
 )
@@ -0,0 +1,8 @@
+package errs
+
+import "errors"
+
+var (
+	QuotaExceeded        = errors.New("quota of user exceeded")
+	StorageQuotaExceeded = errors.New("quota of storage exceeded")
+)
@@ -3,5 +3,10 @@ package errs
 import "fmt"
 
 var (
-	SearchNotAvailable = fmt.Errorf("search not available")
+	SearchNotAvailable        = fmt.Errorf("search not available")
+	ContentSearchNotSupported = fmt.Errorf("content search is not supported by current index")
+	SearchIndexRunning        = fmt.Errorf("index is running")
+	SearchIndexNotRunning     = fmt.Errorf("index is not running")
+	InvalidSearchIndex        = fmt.Errorf("invalid search index")
+	SearchIndexOutdated       = fmt.Errorf("the index is built by an older version, rebuild it to match the names by patterns")
 )
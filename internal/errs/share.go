@@ -0,0 +1,11 @@
+package errs
+
+import "errors"
+
+var (
+	ShareNotFound       = errors.New("share not found")
+	ShareExpired        = errors.New("share is expired")
+	ShareExhausted      = errors.New("share has reached the max downloads")
+	WrongSharePassword  = errors.New("share password is incorrect")
+	ShareBrowseDisabled = errors.New("browsing the share is disabled")
+)
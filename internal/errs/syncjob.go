@@ -0,0 +1,10 @@
+package errs
+
+import "errors"
+
+var (
+	SyncJobRunning    = errors.New("sync job is running")
+	SyncPathsOverlap  = errors.New("src path and dst path of sync job overlap")
+	InvalidSyncPeriod = errors.New("interval of sync job can't be negative")
+	InvalidSyncJob    = errors.New("invalid sync job")
+)
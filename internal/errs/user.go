@@ -7,4 +7,6 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	InvalidSSHKey      = errors.New("invalid ssh public key")
+	DuplicateSSHKey    = errors.New("ssh public key already exists")
 )
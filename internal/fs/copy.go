@@ -10,6 +10,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/tache"
@@ -29,6 +30,8 @@ type CopyTask struct {
 	SrcStorageMp string        `json:"src_storage_mp"`
 	DstStorageMp string        `json:"dst_storage_mp"`
 	Size         int64         `json:"size"`
+	// UserId is the user who copies the objects, the copied files are counted into the usage of the user
+	UserId uint `json:"user_id"`
 }
 
 func (t *CopyTask) GetName() string {
@@ -58,6 +61,7 @@ func (t *CopyTask) OnFailed() {
 func (t *CopyTask) OnSucceeded() {
 	result := fmt.Sprintf("复制%s到%s成功", t.SrcObjPath, t.DstDirPath)
 	log.Debug(result)
+	copied(t.SrcStorageMp, t.SrcObjPath, t.DstStorageMp, t.DstDirPath)
 	if setting.GetBool(conf.NotifyEnabled) && setting.GetBool(conf.NotifyOnCopySucceeded) {
 		go op.Notify("文件复制结果", result)
 	}
@@ -119,6 +123,15 @@ func (t *CopyTask) Run() error {
 
 var CopyTaskManager *tache.Manager[*CopyTask]
 
+// copied notifies the hooks that the obj is copied between two storages
+func copied(srcMp, srcObjPath, dstMp, dstDirPath string) {
+	op.HandleObjChangeHook(op.ObjChange{
+		Type:    op.ObjCopy,
+		Path:    utils.GetFullPath(dstMp, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath))),
+		OldPath: utils.GetFullPath(srcMp, srcObjPath),
+	})
+}
+
 // Copy if in the same storage, call move method
 // if not, add copy task
 func _copy(ctx context.Context, SrcObjPath, DstDirPath string, overwrite bool, lazyCache ...bool) (tache.TaskWithInfo, error) {
@@ -133,7 +146,15 @@ func _copy(ctx context.Context, SrcObjPath, DstDirPath string, overwrite bool, l
 
 	// copy if in the same storage, just call driver.Copy
 	if srcStorage.GetStorage() == dstStorage.GetStorage() {
-		return nil, op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		bytes, files := quota.Stat(ctx, SrcObjPath)
+		if err := quota.Reserve(ctx, DstDirPath, bytes, files); err != nil {
+			return nil, err
+		}
+		err := op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+		if err != nil {
+			quota.Add(ctx, DstDirPath, -bytes, -files)
+		}
+		return nil, err
 	}
 	if ctx.Value(conf.NoTaskKey) != nil {
 		srcObj, err := op.Get(ctx, srcStorage, srcObjActualPath)
@@ -157,7 +178,16 @@ func _copy(ctx context.Context, SrcObjPath, DstDirPath string, overwrite bool, l
 			if err != nil {
 				return nil, errors.WithMessagef(err, "failed get [%s] stream", SrcObjPath)
 			}
-			return nil, op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false)
+			if err := quota.Reserve(ctx, DstDirPath, srcObj.GetSize(), 1); err != nil {
+				return nil, err
+			}
+			err = op.Put(ctx, dstStorage, dstDirActualPath, ss, nil, false)
+			if err != nil {
+				quota.Add(ctx, DstDirPath, -srcObj.GetSize(), -1)
+				return nil, err
+			}
+			copied(srcStorage.GetStorage().MountPath, srcObjActualPath, dstStorage.GetStorage().MountPath, dstDirActualPath)
+			return nil, nil
 		}
 	}
 	// not in the same storage
@@ -170,6 +200,7 @@ func _copy(ctx context.Context, SrcObjPath, DstDirPath string, overwrite bool, l
 		Override:     overwrite,
 		SrcStorageMp: srcStorage.GetStorage().MountPath,
 		DstStorageMp: dstStorage.GetStorage().MountPath,
+		UserId:       quota.UserId(ctx),
 	}
 	CopyTaskManager.Add(t)
 	return t, nil
@@ -201,6 +232,7 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, Src
 				Override:     t.Override,
 				SrcStorageMp: srcStorage.GetStorage().MountPath,
 				DstStorageMp: dstStorage.GetStorage().MountPath,
+				UserId:       t.UserId,
 			})
 		}
 		t.Status = "src object is dir, added all copy tasks of objs"
@@ -230,5 +262,16 @@ func copyFileBetween2Storages(tsk *CopyTask, srcStorage, dstStorage driver.Drive
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
 	}
-	return op.Put(tsk.Ctx(), dstStorage, DstDirPath, ss, tsk.SetProgress, true)
+	// the usage is reserved when the file is copied, and given back if it fails
+	ctx := quota.WithUser(tsk.Ctx(), tsk.UserId)
+	dstDirPath := utils.GetFullPath(tsk.DstStorageMp, DstDirPath)
+	if err := quota.Reserve(ctx, dstDirPath, srcFile.GetSize(), 1); err != nil {
+		_ = ss.Close()
+		return tache.Unrecoverable(err)
+	}
+	err = op.Put(ctx, dstStorage, DstDirPath, ss, tsk.SetProgress, true)
+	if err != nil {
+		quota.Add(ctx, dstDirPath, -srcFile.GetSize(), -1)
+	}
+	return err
 }
@@ -2,11 +2,16 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
 
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
 	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -20,6 +25,9 @@ type ListArgs struct {
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
+	if IsTrashPath(path) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
 	res, err := list(ctx, path, args)
 	if err != nil {
 		if !args.NoLog {
@@ -35,6 +43,9 @@ type GetArgs struct {
 }
 
 func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
+	if IsTrashPath(path) {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
 	res, err := get(ctx, path)
 	if err != nil {
 		if !args.NoLog {
@@ -46,6 +57,9 @@ func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 }
 
 func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	if IsTrashPath(path) {
+		return nil, nil, errors.WithStack(errs.ObjectNotFound)
+	}
 	res, file, err := link(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed link %s: %+v", path, err)
@@ -55,61 +69,125 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 }
 
 func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
-	err := makeDir(ctx, path, lazyCache...)
+	err := checkTrashPath(ctx, path)
+	if err == nil {
+		err = makeDir(ctx, path, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	audit.Fs(ctx, model.AuditMkdir, path, "", err)
 	return err
 }
 
 func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
-	err := move(ctx, srcPath, dstDirPath, lazyCache...)
+	// the objects are only moved in the same storage, so the usage doesn't change
+	err := checkTrashPath(ctx, srcPath, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
+	if err == nil {
+		err = move(ctx, srcPath, dstDirPath, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
+	audit.Fs(ctx, model.AuditMove, srcPath, dstDirPath, err)
 	return err
 }
 
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, overwrite bool, lazyCache ...bool) (tache.TaskWithInfo, error) {
-	res, err := _copy(ctx, srcObjPath, dstDirPath, overwrite, lazyCache...)
+	// the usage is reserved when the objects are copied
+	var res tache.TaskWithInfo
+	err := checkTrashPath(ctx, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath)))
+	if err == nil {
+		res, err = _copy(ctx, srcObjPath, dstDirPath, overwrite, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit.Fs(ctx, model.AuditCopy, srcObjPath, dstDirPath, err)
 	return res, err
 }
 
 func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
-	err := rename(ctx, srcPath, dstName, lazyCache...)
+	err := checkTrashPath(ctx, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName))
+	if err == nil {
+		err = rename(ctx, srcPath, dstName, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
+	audit.Fs(ctx, model.AuditRename, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), err)
 	return err
 }
 
 func Remove(ctx context.Context, path string) error {
-	err := remove(ctx, path)
+	err := checkTrashPath(ctx, path)
+	var bytes, files int64
+	// the objects in trash were already subtracted from the usage when they were removed
+	if err == nil && !IsTrashPath(path) {
+		bytes, files = quota.Stat(ctx, path)
+	}
+	if err == nil {
+		err = remove(ctx, path)
+	}
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
+	} else {
+		quota.Add(ctx, path, -bytes, -files)
 	}
+	audit.Fs(ctx, model.AuditRemove, path, "", err)
 	return err
 }
 
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
-	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
+	err := checkTrashPath(ctx, stdpath.Join(dstDirPath, file.GetName()))
+	var bytes, files int64
+	if err == nil {
+		bytes, files = putUsage(ctx, dstDirPath, file)
+		err = quota.Reserve(ctx, dstDirPath, bytes, files)
+	}
+	if err == nil {
+		err = putDirectly(ctx, dstDirPath, file, lazyCache...)
+		if err != nil {
+			quota.Add(ctx, dstDirPath, -bytes, -files)
+		}
+	}
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Fs(ctx, model.AuditUpload, "", stdpath.Join(dstDirPath, file.GetName()), err)
 	return err
 }
 
-func PutAsTask(dstDirPath string, file model.FileStreamer) (tache.TaskWithInfo, error) {
-	t, err := putAsTask(dstDirPath, file)
+func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (tache.TaskWithInfo, error) {
+	err := checkTrashPath(ctx, stdpath.Join(dstDirPath, file.GetName()))
+	var bytes, files int64
+	if err == nil {
+		bytes, files = putUsage(ctx, dstDirPath, file)
+		err = quota.Reserve(ctx, dstDirPath, bytes, files)
+	}
+	var t tache.TaskWithInfo
+	if err == nil {
+		qctx := quota.Detach(ctx)
+		t, err = putAsTask(qctx, dstDirPath, file, func() {
+			quota.Add(qctx, dstDirPath, -bytes, -files)
+		})
+		if err != nil {
+			quota.Add(ctx, dstDirPath, -bytes, -files)
+		}
+	}
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Fs(ctx, model.AuditUpload, "", stdpath.Join(dstDirPath, file.GetName()), err)
 	return t, err
 }
 
+// putUsage returns the usage which putting the file adds, the existing file is overwritten
+func putUsage(ctx context.Context, dstDirPath string, file model.FileStreamer) (bytes, files int64) {
+	oldBytes, oldFiles := quota.Stat(ctx, stdpath.Join(dstDirPath, file.GetName()))
+	return file.GetSize() - oldBytes, 1 - oldFiles
+}
+
 type GetStoragesArgs struct {
 }
 
@@ -0,0 +1,117 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupUsage(t *testing.T, quotaBytes int64) context.Context {
+	dB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	db.Init(dB)
+	_, err = op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, t.TempDir()),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	user := &model.User{Username: "u", BasePath: "/", Role: model.GENERAL, QuotaBytes: quotaBytes, Authn: "[]"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	return context.WithValue(context.Background(), "user", user)
+}
+
+func putFile(t *testing.T, ctx context.Context, name, content string) {
+	t.Helper()
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: name, Size: int64(len(content))},
+		Reader: strings.NewReader(content),
+	}
+	if err := PutDirectly(ctx, "/local", file); err != nil {
+		t.Fatalf("failed put %s: %+v", name, err)
+	}
+}
+
+func expectUsage(t *testing.T, ctx context.Context, bytes, files int64) {
+	t.Helper()
+	user := ctx.Value("user").(*model.User)
+	usedBytes, usedFiles, err := db.GetUsage(user.ID)
+	if err != nil {
+		t.Fatalf("failed get usage: %+v", err)
+	}
+	if usedBytes != bytes || usedFiles != files {
+		t.Errorf("usage = %d bytes, %d files, want %d bytes, %d files", usedBytes, usedFiles, bytes, files)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, quotaBytes := range []int64{0, 1 << 20} {
+		t.Run(fmt.Sprintf("quota %d", quotaBytes), func(t *testing.T) {
+			ctx := setupUsage(t, quotaBytes)
+			putFile(t, ctx, "a.txt", "hello")
+			putFile(t, ctx, "b.txt", "world!")
+			expectUsage(t, ctx, 11, 2)
+
+			putFile(t, ctx, "a.txt", "hi")
+			expectUsage(t, ctx, 8, 2)
+
+			if err := Remove(ctx, "/local/a.txt"); err != nil {
+				t.Fatalf("failed remove: %+v", err)
+			}
+			expectUsage(t, ctx, 6, 1)
+			if err := Remove(ctx, "/local/b.txt"); err != nil {
+				t.Fatalf("failed remove: %+v", err)
+			}
+			expectUsage(t, ctx, 0, 0)
+		})
+	}
+}
+
+func TestTrashPathRefused(t *testing.T) {
+	ctx := setupUsage(t, 0)
+	trash := "/local/" + TrashDirName
+	if err := MakeDir(context.Background(), trash+"/20260101000000.000000000"); err != nil {
+		t.Fatalf("failed make trash dir: %+v", err)
+	}
+	putFile(t, ctx, "a.txt", "hello")
+	if err := MakeDir(ctx, "/local/sub"); err != nil {
+		t.Fatalf("failed make dir: %+v", err)
+	}
+	for name, err := range map[string]error{
+		"remove trash":    Remove(ctx, trash),
+		"remove in trash": Remove(ctx, trash+"/20260101000000.000000000"),
+		"make dir":        MakeDir(ctx, trash+"/x"),
+		"move into trash": Move(ctx, "/local/a.txt", trash),
+		"rename to trash": Rename(ctx, "/local/sub", TrashDirName),
+		"put into trash": PutDirectly(ctx, trash, &stream.FileStream{
+			Obj: &model.Object{Name: "b.txt", Size: 1}, Reader: strings.NewReader("b"),
+		}),
+	} {
+		if !errors.Is(errors.Cause(err), errs.PermissionDenied) {
+			t.Errorf("%s: got %v, want %v", name, err, errs.PermissionDenied)
+		}
+	}
+	if _, err := get(ctx, trash+"/20260101000000.000000000"); err != nil {
+		t.Errorf("trash should be kept: %+v", err)
+	}
+}
@@ -15,7 +15,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	meta, _ := ctx.Value("meta").(*model.Meta)
 	user, _ := ctx.Value("user").(*model.User)
 	virtualFiles := op.GetStorageVirtualFilesByPath(path)
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPathFor(ctx, path)
 	if err != nil && len(virtualFiles) == 0 {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -35,6 +35,12 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		}
 	}
 
+	if actualPath == "/" {
+		// the trash is managed by the trash api
+		_objs = utils.SliceFilter(_objs, func(obj model.Obj) bool {
+			return obj.GetName() != TrashDirName
+		})
+	}
 	om := model.NewObjMerge()
 	if whetherHide(user, meta, path) {
 		om.InitHideReg(meta.Hide)
@@ -45,6 +45,9 @@ func remove(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if moved, err := moveToTrash(ctx, storage, path, actualPath); moved || err != nil {
+		return err
+	}
 	return op.Remove(ctx, storage, actualPath)
 }
 
@@ -8,6 +8,8 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/throttle"
 	"github.com/alist-org/alist/v3/pkg/tache"
 	"github.com/pkg/errors"
 )
@@ -19,6 +21,7 @@ type UploadTask struct {
 	storage          driver.Driver
 	dstDirActualPath string
 	file             model.FileStreamer
+	onFailed         func()
 }
 
 // func (t *UploadTask) OnFailed() {
@@ -47,17 +50,24 @@ func (t *UploadTask) Run() error {
 	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
 }
 
+func (t *UploadTask) OnFailed() {
+	if t.onFailed != nil {
+		t.onFailed()
+	}
+}
+
 var UploadTaskManager *tache.Manager[*UploadTask]
 
 // putAsTask add as a put task and return immediately
-func putAsTask(dstDirPath string, file model.FileStreamer) (tache.TaskWithInfo, error) {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer, onFailed func()) (tache.TaskWithInfo, error) {
+	storage, dstDirActualPath, err := op.GetStorageAndActualPathFor(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	throttleUpload(ctx, storage, file)
 	if file.NeedStore() {
 		_, err := file.CacheFullInTempFile()
 		if err != nil {
@@ -72,6 +82,7 @@ func putAsTask(dstDirPath string, file model.FileStreamer) (tache.TaskWithInfo,
 		storage:          storage,
 		dstDirActualPath: dstDirActualPath,
 		file:             file,
+		onFailed:         onFailed,
 	}
 	UploadTaskManager.Add(t)
 	return t, nil
@@ -79,12 +90,26 @@ func putAsTask(dstDirPath string, file model.FileStreamer) (tache.TaskWithInfo,
 
 // putDirect put the file and return after finish
 func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	storage, dstDirActualPath, err := op.GetStorageAndActualPathFor(ctx, dstDirPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
 	if storage.Config().NoUpload {
 		return errors.WithStack(errs.UploadNotSupported)
 	}
+	throttleUpload(ctx, storage, file)
 	return op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...)
 }
+
+// throttleUpload limits the bandwidth of reading file if its data is still being received
+func throttleUpload(ctx context.Context, storage driver.Driver, file model.FileStreamer) {
+	fs, ok := file.(*stream.FileStream)
+	if !ok || fs.Reader == nil {
+		return
+	}
+	// the data of a local file has been received completely
+	if _, ok := fs.Reader.(model.File); ok {
+		return
+	}
+	fs.Reader = throttle.NewReader(throttle.WithStorage(ctx, storage.GetStorage()), throttle.Upload, fs.Reader)
+}
@@ -0,0 +1,161 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrashDirName is the folder in the root of each storage which holds the removed objects
+const TrashDirName = ".alist_trash"
+
+// inTrash reports whether the actual path is the trash folder or inside it
+func inTrash(actualPath string) bool {
+	return actualPath == "/"+TrashDirName || strings.HasPrefix(actualPath, "/"+TrashDirName+"/")
+}
+
+// IsTrashPath reports whether the mount path is the trash folder of its storage or inside it,
+// the trash is hidden from the fs functions and only managed by the trash api
+func IsTrashPath(path string) bool {
+	_, actualPath, err := op.GetStorageAndActualPath(path)
+	return err == nil && inTrash(actualPath)
+}
+
+// checkTrashPath refuses the fs operations on the trash for the users who aren't admin, since the trash holds
+// the objects removed by all users, their own trash items are only managed by the trash api
+func checkTrashPath(ctx context.Context, paths ...string) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok || user.IsAdmin() {
+		return nil
+	}
+	for _, path := range paths {
+		if IsTrashPath(path) {
+			return errors.WithStack(errs.PermissionDenied)
+		}
+	}
+	return nil
+}
+
+// moveToTrash moves the object into .alist_trash/<timestamp> of its storage,
+// it returns false if the object should be removed permanently
+func moveToTrash(ctx context.Context, storage driver.Driver, path, actualPath string) (bool, error) {
+	if !setting.GetBool(conf.TrashEnabled) || actualPath == "/" {
+		return false, nil
+	}
+	if inTrash(actualPath) {
+		forgetTrash(storage.GetStorage().MountPath, actualPath)
+		return false, nil
+	}
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+	default:
+		return false, nil
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		// let op.Remove deal with it
+		return false, nil
+	}
+	now := time.Now()
+	trashDir := stdpath.Join("/", TrashDirName, now.Format("20060102150405.000000000"))
+	if err := op.MakeDir(ctx, storage, trashDir); err != nil {
+		return true, errors.WithMessage(err, "failed make trash dir")
+	}
+	if err := op.Move(ctx, storage, actualPath, trashDir); err != nil {
+		_ = op.Remove(ctx, storage, trashDir)
+		if errors.Is(errors.Cause(err), errs.NotImplement) || errors.Is(errors.Cause(err), errs.NotSupport) {
+			return false, nil
+		}
+		return true, errors.WithMessage(err, "failed move to trash")
+	}
+	item := &model.TrashItem{
+		MountPath:    storage.GetStorage().MountPath,
+		OriginalPath: path,
+		TrashPath:    stdpath.Join(storage.GetStorage().MountPath, trashDir),
+		Name:         obj.GetName(),
+		Size:         obj.GetSize(),
+		IsDir:        obj.IsDir(),
+		DeletedAt:    now,
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		item.UserId = user.ID
+	}
+	if err := db.CreateTrashItem(item); err != nil {
+		log.Errorf("failed record trash item of %s: %+v", path, err)
+	}
+	return true, nil
+}
+
+// forgetTrash deletes the records of trash items which are removed permanently by removing actualPath
+func forgetTrash(mountPath, actualPath string) {
+	var err error
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(actualPath, "/"+TrashDirName), "/"), "/")
+	switch {
+	case actualPath == "/"+TrashDirName:
+		err = db.DeleteTrashItemsByMountPath(mountPath)
+	case len(parts) <= 2:
+		err = db.DeleteTrashItemsByTrashPath(stdpath.Join(mountPath, TrashDirName, parts[0]))
+	}
+	if err != nil {
+		log.Errorf("failed delete trash items of %s: %+v", actualPath, err)
+	}
+}
+
+// RestoreTrash moves the trash item back to its original path
+func RestoreTrash(ctx context.Context, item *model.TrashItem) error {
+	dstDir := stdpath.Dir(item.OriginalPath)
+	if _, err := get(ctx, item.OriginalPath); err == nil {
+		return errors.Errorf("%s already exists", item.OriginalPath)
+	}
+	if err := makeDir(ctx, dstDir); err != nil {
+		return err
+	}
+	if err := move(ctx, stdpath.Join(item.TrashPath, item.Name), dstDir); err != nil {
+		return err
+	}
+	bytes, files := quota.Stat(ctx, item.OriginalPath)
+	quota.Add(ctx, item.OriginalPath, bytes, files)
+	// the timestamp folder is empty now
+	if err := remove(ctx, item.TrashPath); err != nil {
+		log.Warnf("failed remove trash dir %s: %+v", item.TrashPath, err)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrash removes the trash item permanently
+func PurgeTrash(ctx context.Context, item *model.TrashItem) error {
+	if err := remove(ctx, item.TrashPath); err != nil && !errs.IsNotFoundError(err) {
+		return err
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeExpiredTrash purges the trash items which are older than the retention days
+func PurgeExpiredTrash() {
+	days := setting.GetInt(conf.TrashRetentionDays, 30)
+	if days <= 0 {
+		return
+	}
+	items, err := db.GetTrashItemsDeletedBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed get expired trash items: %+v", err)
+		return
+	}
+	for i := range items {
+		if err := PurgeTrash(context.Background(), &items[i]); err != nil {
+			log.Errorf("failed purge trash item %s: %+v", items[i].OriginalPath, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+//go:build fuse
+
+package fuse
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultBlockSize    = 4 * 1024 * 1024
+	DefaultMaxCacheSize = 1024 * 1024 * 1024
+)
+
+// blockCache keeps fixed-size blocks of remote files on the local disk,
+// so that random reads don't have to request the same range again and again.
+// Blocks are evicted in least-recently-used order once MaxSize is exceeded.
+type blockCache struct {
+	Dir       string
+	BlockSize int64
+	MaxSize   int64
+
+	mu     sync.Mutex
+	size   int64
+	lru    *list.List
+	blocks map[string]*list.Element
+}
+
+type cacheBlock struct {
+	name string
+	size int64
+}
+
+func newBlockCache(dir string, blockSize, maxSize int64) (*blockCache, error) {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxCacheSize
+	}
+	// the cache is not reused between mounts
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := utils.CreateNestedDirectory(dir); err != nil {
+		return nil, err
+	}
+	return &blockCache{
+		Dir:       dir,
+		BlockSize: blockSize,
+		MaxSize:   maxSize,
+		lru:       list.New(),
+		blocks:    make(map[string]*list.Element),
+	}, nil
+}
+
+// blockName identifies a block of a specific version of a file,
+// a modified file gets a different key so stale blocks are never used
+func blockName(key string, index int64) string {
+	return fmt.Sprintf("%s-%d", utils.GetMD5EncodeStr(key), index)
+}
+
+func (c *blockCache) get(key string, index int64) ([]byte, bool) {
+	name := blockName(key, index)
+	c.mu.Lock()
+	e, ok := c.blocks[name]
+	if ok {
+		c.lru.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(c.Dir, name))
+	if err != nil {
+		log.Warnf("[fuse] failed read cache block %s: %+v", name, err)
+		c.remove(name)
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *blockCache) put(key string, index int64, data []byte) {
+	name := blockName(key, index)
+	if err := os.WriteFile(filepath.Join(c.Dir, name), data, 0600); err != nil {
+		log.Warnf("[fuse] failed write cache block %s: %+v", name, err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.blocks[name]; ok {
+		c.size -= e.Value.(*cacheBlock).size
+		c.lru.Remove(e)
+	}
+	c.blocks[name] = c.lru.PushFront(&cacheBlock{name: name, size: int64(len(data))})
+	c.size += int64(len(data))
+	for c.size > c.MaxSize && c.lru.Len() > 1 {
+		c.evict(c.lru.Back())
+	}
+}
+
+func (c *blockCache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.blocks[name]; ok {
+		c.evict(e)
+	}
+}
+
+// evict must be called with mu held
+func (c *blockCache) evict(e *list.Element) {
+	b := c.lru.Remove(e).(*cacheBlock)
+	delete(c.blocks, b.name)
+	c.size -= b.size
+	_ = os.Remove(filepath.Join(c.Dir, b.name))
+}
+
+func (c *blockCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.blocks = make(map[string]*list.Element)
+	c.size = 0
+	_ = os.RemoveAll(c.Dir)
+}
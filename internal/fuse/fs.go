@@ -1,170 +1,485 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"bytes"
+	"context"
+	"fmt"
+	stdpath "path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	alistfs "github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
 
 type Fs struct {
 	RootFolder string
+	// BlockSize and CacheSize of the local read cache, defaults are used if zero
+	BlockSize int64
+	CacheSize int64
 	fuse.FileSystemBase
+
+	cache   *blockCache
+	ctx     context.Context
+	cancel  context.CancelFunc
+	nextFh  atomic.Uint64
+	handles generic_sync.MapOf[uint64, *fileHandle]
+	// opened files by path, so that files being written can be stat before they are uploaded
+	openedMu sync.Mutex
+	opened   map[string][]*fileHandle
 }
 
 func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
+	fs.ctx, fs.cancel = context.WithCancel(context.Background())
+	fs.opened = make(map[string][]*fileHandle)
+	cache, err := newBlockCache(filepath.Join(conf.Conf.TempDir, "fuse"), fs.BlockSize, fs.CacheSize)
+	if err != nil {
+		log.Fatalf("[fuse] failed init block cache: %+v", err)
+	}
+	fs.cache = cache
 }
 
 func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
+	fs.handles.Range(func(fh uint64, h *fileHandle) bool {
+		fs.release(fh, h)
+		return true
+	})
+	fs.cancel()
+	fs.cache.clear()
+}
+
+// alistPath converts the fuse path to the alist path
+func (fs *Fs) alistPath(path string) string {
+	return utils.FixAndCleanPath(stdpath.Join(fs.RootFolder, path))
 }
 
 func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
+	// the real capacity of storages is unknown, report a big enough free space
+	// so that programs checking it before writing don't refuse to work
+	const blocks = 1 << 40 / 4096
+	stat.Bsize = 4096
+	stat.Frsize = 4096
+	stat.Blocks = blocks
+	stat.Bfree = blocks
+	stat.Bavail = blocks
+	stat.Namemax = 255
+	return 0
 }
 
 func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
+	if mode&fuse.S_IFMT != 0 && mode&fuse.S_IFMT != fuse.S_IFREG {
+		return -fuse.ENOSYS
+	}
+	return fs.putEmpty(fs.alistPath(path))
 }
 
 func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return toErrno(alistfs.MakeDir(fs.ctx, fs.alistPath(path)))
 }
 
 func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
+	return toErrno(alistfs.Remove(fs.ctx, fs.alistPath(path)))
 }
 
 func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
+	reqPath := fs.alistPath(path)
+	objs, err := alistfs.List(fs.ctx, reqPath, &alistfs.ListArgs{})
+	if err != nil {
+		return toErrno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return toErrno(alistfs.Remove(fs.ctx, reqPath))
 }
 
 func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS, ""
 }
 
 func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
+	src, dst := fs.alistPath(oldpath), fs.alistPath(newpath)
+	if src == dst {
+		return 0
+	}
+	srcDir, dstDir := stdpath.Dir(src), stdpath.Dir(dst)
+	srcName, dstName := stdpath.Base(src), stdpath.Base(dst)
+	// rename replaces the destination in posix, it's renamed aside and only removed after the source is renamed
+	var replaced string
+	if dstObj, err := alistfs.Get(fs.ctx, dst, &alistfs.GetArgs{NoLog: true}); err == nil {
+		if dstObj.IsDir() {
+			return -fuse.EEXIST
+		}
+		replaced = fmt.Sprintf(".%s.replaced.%d", dstName, time.Now().UnixNano())
+		if err := alistfs.Rename(fs.ctx, dst, replaced); err != nil {
+			return toErrno(err)
+		}
+	}
+	var err error
+	if srcDir == dstDir {
+		err = alistfs.Rename(fs.ctx, src, dstName)
+	} else {
+		err = alistfs.Move(fs.ctx, src, dstDir)
+		if err == nil && srcName != dstName {
+			err = alistfs.Rename(fs.ctx, stdpath.Join(dstDir, srcName), dstName)
+		}
+	}
+	if err != nil {
+		if replaced != "" {
+			if err := alistfs.Rename(fs.ctx, stdpath.Join(dstDir, replaced), dstName); err != nil {
+				log.Errorf("[fuse] failed restore %s: %+v", dst, err)
+			}
+		}
+		return toErrno(err)
+	}
+	if replaced != "" {
+		if err := alistfs.Remove(fs.ctx, stdpath.Join(dstDir, replaced)); err != nil {
+			log.Errorf("[fuse] failed remove the replaced %s: %+v", dst, err)
+		}
+	}
+	fs.openedMu.Lock()
+	if hs, ok := fs.opened[src]; ok {
+		for _, h := range hs {
+			h.mu.Lock()
+			h.path = dst
+			h.mu.Unlock()
+		}
+		fs.opened[dst] = append(fs.opened[dst], hs...)
+		delete(fs.opened, src)
+	}
+	fs.openedMu.Unlock()
+	return 0
 }
 
 func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
+	// permissions are not supported by storages, ignore it silently
+	return 0
 }
 
 func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	reqPath := fs.alistPath(path)
+	h := &fileHandle{path: reqPath, obj: &model.Object{Name: stdpath.Base(reqPath), Modified: time.Now()}}
+	if err := h.openForWrite(fs.ctx, fs.cache, true); err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	return 0, fs.addHandle(h)
 }
 
 func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	reqPath := fs.alistPath(path)
+	obj, err := alistfs.Get(fs.ctx, reqPath, &alistfs.GetArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	h := &fileHandle{path: reqPath, obj: obj}
+	if flags&fuse.O_ACCMODE != fuse.O_RDONLY {
+		if err := h.openForWrite(fs.ctx, fs.cache, flags&fuse.O_TRUNC != 0); err != nil {
+			return toErrno(err), ^uint64(0)
+		}
+	}
+	return 0, fs.addHandle(h)
 }
 
 func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	reqPath := fs.alistPath(path)
+	if h := fs.getOpened(reqPath, fh); h != nil {
+		h.mu.Lock()
+		fillStat(stat, h.obj, h.size())
+		h.mu.Unlock()
+		return 0
+	}
+	obj, err := alistfs.Get(fs.ctx, reqPath, &alistfs.GetArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err)
+	}
+	fillStat(stat, obj, obj.GetSize())
+	return 0
 }
 
 func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	reqPath := fs.alistPath(path)
+	h := fs.getOpened(reqPath, fh)
+	if h == nil && size == 0 {
+		// nothing has to be kept, so the file is replaced by an empty one without being downloaded
+		obj, err := alistfs.Get(fs.ctx, reqPath, &alistfs.GetArgs{NoLog: true})
+		if err != nil {
+			return toErrno(err)
+		}
+		if obj.IsDir() {
+			return -fuse.EISDIR
+		}
+		return fs.putEmpty(reqPath)
+	}
+	if h == nil {
+		// truncate without an opened file, open it temporary
+		errc, tmpFh := fs.Open(path, fuse.O_WRONLY)
+		if errc != 0 {
+			return errc
+		}
+		defer fs.Release(path, tmpFh)
+		h, _ = fs.handles.Load(tmpFh)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.openForWrite(fs.ctx, fs.cache, size == 0); err != nil {
+		return toErrno(err)
+	}
+	return toErrno(h.truncate(size))
 }
 
 func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h, ok := fs.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := h.readAt(fs.ctx, fs.cache, buff, ofst)
+	if err != nil {
+		log.Errorf("[fuse] failed read %s: %+v", h.path, err)
+		if n == 0 {
+			return -fuse.EIO
+		}
+	}
+	return n
 }
 
 func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h, ok := fs.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tmpFile == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		log.Errorf("[fuse] failed write %s: %+v", h.path, err)
+		return -fuse.EIO
+	}
+	return n
 }
 
 func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h, ok := fs.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return toErrno(h.flush(fs.ctx))
 }
 
 func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	h, ok := fs.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	return toErrno(fs.release(fh, h))
+}
+
+func (fs *Fs) release(fh uint64, h *fileHandle) error {
+	h.mu.Lock()
+	err := h.flush(fs.ctx)
+	if err != nil {
+		log.Errorf("[fuse] failed upload %s: %+v", h.path, err)
+	}
+	_ = h.close()
+	h.mu.Unlock()
+	fs.handles.Delete(fh)
+	// openedMu is locked before h.mu as Rename and getOpened do, the path is only changed with openedMu held
+	fs.openedMu.Lock()
+	hs := fs.opened[h.path]
+	for i := range hs {
+		if hs[i] == h {
+			hs = append(hs[:i], hs[i+1:]...)
+			break
+		}
+	}
+	if len(hs) == 0 {
+		delete(fs.opened, h.path)
+	} else {
+		fs.opened[h.path] = hs
+	}
+	fs.openedMu.Unlock()
+	return err
 }
 
 func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return fs.Flush(path, fh)
 }
 
 func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
+	obj, err := alistfs.Get(fs.ctx, fs.alistPath(path), &alistfs.GetArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
 }
 
 func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	objs, err := alistfs.List(fs.ctx, fs.alistPath(path), &alistfs.ListArgs{})
+	if err != nil {
+		return toErrno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	for _, obj := range objs {
+		stat := &fuse.Stat_t{}
+		fillStat(stat, obj, obj.GetSize())
+		if !fill(obj.GetName(), stat, 0) {
+			break
+		}
+	}
+	return 0
 }
 
 func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
+	return 0
 }
 
 func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS, nil
 }
 
 func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
 }
 
 func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	return -fuse.ENOSYS
+}
+
+func (fs *Fs) addHandle(h *fileHandle) uint64 {
+	fh := fs.nextFh.Add(1)
+	fs.handles.Store(fh, h)
+	fs.openedMu.Lock()
+	fs.opened[h.path] = append(fs.opened[h.path], h)
+	fs.openedMu.Unlock()
+	return fh
+}
+
+// getOpened returns the handle of fh, or any opened handle with pending writes of the path
+func (fs *Fs) getOpened(path string, fh uint64) *fileHandle {
+	if h, ok := fs.handles.Load(fh); ok {
+		return h
+	}
+	fs.openedMu.Lock()
+	defer fs.openedMu.Unlock()
+	for _, h := range fs.opened[path] {
+		h.mu.Lock()
+		writing := h.tmpFile != nil
+		h.mu.Unlock()
+		if writing {
+			return h
+		}
+	}
+	return nil
+}
+
+func (fs *Fs) putEmpty(path string) int {
+	s := &stream.FileStream{
+		Ctx: fs.ctx,
+		Obj: &model.Object{
+			Name:     stdpath.Base(path),
+			Modified: time.Now(),
+		},
+		Reader:   bytes.NewReader([]byte{}),
+		Mimetype: utils.GetMimeType(path),
+	}
+	defer s.Close()
+	return toErrno(alistfs.PutDirectly(fs.ctx, stdpath.Dir(path), s))
+}
+
+func fillStat(stat *fuse.Stat_t, obj model.Obj, size int64) {
+	*stat = fuse.Stat_t{}
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0644
+		stat.Nlink = 1
+		stat.Size = size
+		stat.Blksize = 4096
+		stat.Blocks = (size + 511) / 512
+	}
+	mtime := fuse.NewTimespec(obj.ModTime())
+	ctime := mtime
+	if !obj.CreateTime().IsZero() {
+		ctime = fuse.NewTimespec(obj.CreateTime())
+	}
+	stat.Mtim = mtime
+	stat.Atim = mtime
+	stat.Ctim = mtime
+	stat.Birthtim = ctime
+}
+
+func toErrno(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errs.IsObjectNotFound(err), errors.Is(errors.Cause(err), errs.StorageNotFound):
+		return -fuse.ENOENT
+	case errors.Is(errors.Cause(err), errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(errors.Cause(err), errs.PermissionDenied):
+		return -fuse.EACCES
+	case errors.Is(errors.Cause(err), errs.UploadNotSupported),
+		errs.IsNotSupportError(err), errs.IsNotImplement(err):
+		return -fuse.ENOTSUP
+	case errors.Is(errors.Cause(err), errs.MoveBetweenTwoStorages):
+		return -fuse.EXDEV
+	default:
+		return -fuse.EIO
+	}
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
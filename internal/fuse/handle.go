@@ -0,0 +1,227 @@
+//go:build fuse
+
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// fileHandle is an opened file.
+// Reads go through the block cache until the file is written,
+// writes are buffered in a temp file and uploaded on flush.
+type fileHandle struct {
+	mu   sync.Mutex
+	path string
+	obj  model.Obj
+
+	// read side
+	link *model.Link
+	rrc  model.RangeReadCloserIF
+
+	// write side
+	tmpFile *os.File
+	dirty   bool
+}
+
+func (h *fileHandle) cacheKey() string {
+	return fmt.Sprintf("%s|%d|%d", h.path, h.obj.ModTime().UnixNano(), h.obj.GetSize())
+}
+
+func (h *fileHandle) size() int64 {
+	if h.tmpFile != nil {
+		if info, err := h.tmpFile.Stat(); err == nil {
+			return info.Size()
+		}
+	}
+	if h.obj == nil {
+		return 0
+	}
+	return h.obj.GetSize()
+}
+
+// rangeRead reads length bytes at offset directly from the storage
+func (h *fileHandle) rangeRead(ctx context.Context, offset, length int64) ([]byte, error) {
+	if h.link == nil {
+		link, _, err := fs.Link(ctx, h.path, model.LinkArgs{})
+		if err != nil {
+			return nil, err
+		}
+		h.link = link
+	}
+	if h.link.MFile != nil {
+		buf := make([]byte, length)
+		n, err := h.link.MFile.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	if h.rrc == nil {
+		if h.link.RangeReadCloser != nil {
+			h.rrc = h.link.RangeReadCloser
+		} else {
+			rrc, err := stream.GetRangeReadCloserFromLink(h.obj.GetSize(), h.link)
+			if err != nil {
+				return nil, err
+			}
+			h.rrc = rrc
+		}
+	}
+	rc, err := h.rrc.RangeRead(ctx, http_range.Range{Start: offset, Length: length})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	buf := make([]byte, length)
+	n, err := io.ReadFull(rc, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (h *fileHandle) readAt(ctx context.Context, cache *blockCache, buff []byte, ofst int64) (int, error) {
+	if h.tmpFile != nil {
+		n, err := h.tmpFile.ReadAt(buff, ofst)
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+		return n, nil
+	}
+	size := h.size()
+	if ofst >= size {
+		return 0, nil
+	}
+	end := utils.Min(ofst+int64(len(buff)), size)
+	key := h.cacheKey()
+	n := 0
+	for pos := ofst; pos < end; {
+		index := pos / cache.BlockSize
+		blockStart := index * cache.BlockSize
+		data, ok := cache.get(key, index)
+		if !ok {
+			var err error
+			data, err = h.rangeRead(ctx, blockStart, utils.Min(cache.BlockSize, size-blockStart))
+			if err != nil {
+				return n, err
+			}
+			cache.put(key, index, data)
+		}
+		if pos-blockStart >= int64(len(data)) {
+			break
+		}
+		copied := copy(buff[n:end-ofst], data[pos-blockStart:])
+		n += copied
+		pos += int64(copied)
+	}
+	return n, nil
+}
+
+// openForWrite prepares the temp file, the current content is downloaded first unless truncate is set
+func (h *fileHandle) openForWrite(ctx context.Context, cache *blockCache, truncate bool) error {
+	if h.tmpFile != nil {
+		return nil
+	}
+	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !truncate && h.obj != nil && h.obj.GetSize() > 0 {
+		buf := make([]byte, cache.BlockSize)
+		for ofst := int64(0); ofst < h.obj.GetSize(); {
+			n, err := h.readAt(ctx, cache, buf, ofst)
+			if err == nil && n == 0 {
+				err = errs.StreamIncomplete
+			}
+			if err == nil {
+				_, err = tmpFile.WriteAt(buf[:n], ofst)
+			}
+			if err != nil {
+				_ = tmpFile.Close()
+				_ = os.Remove(tmpFile.Name())
+				return err
+			}
+			ofst += int64(n)
+		}
+	}
+	h.tmpFile = tmpFile
+	h.dirty = truncate
+	return nil
+}
+
+func (h *fileHandle) writeAt(buff []byte, ofst int64) (int, error) {
+	n, err := h.tmpFile.WriteAt(buff, ofst)
+	if n > 0 {
+		h.dirty = true
+	}
+	return n, err
+}
+
+func (h *fileHandle) truncate(size int64) error {
+	if err := h.tmpFile.Truncate(size); err != nil {
+		return err
+	}
+	h.dirty = true
+	return nil
+}
+
+// flush uploads the temp file if it has been changed since the last flush
+func (h *fileHandle) flush(ctx context.Context) error {
+	if h.tmpFile == nil || !h.dirty {
+		return nil
+	}
+	if _, err := h.tmpFile.Seek(0, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+	name := stdpath.Base(h.path)
+	obj := &model.Object{
+		Name:     name,
+		Size:     h.size(),
+		Modified: time.Now(),
+	}
+	s := &stream.FileStream{
+		Ctx:      ctx,
+		Obj:      obj,
+		Reader:   h.tmpFile,
+		Mimetype: utils.GetMimeType(name),
+	}
+	err := fs.PutDirectly(ctx, stdpath.Dir(h.path), s)
+	_ = s.Close()
+	if err != nil {
+		return err
+	}
+	h.obj = obj
+	h.dirty = false
+	return nil
+}
+
+func (h *fileHandle) close() error {
+	var err error
+	if h.rrc != nil {
+		err = h.rrc.Close()
+	}
+	if h.link != nil && h.link.MFile != nil {
+		_ = h.link.MFile.Close()
+	}
+	if h.tmpFile != nil {
+		_ = h.tmpFile.Close()
+		_ = os.Remove(h.tmpFile.Name())
+		h.tmpFile = nil
+	}
+	return err
+}
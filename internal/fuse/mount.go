@@ -1,9 +1,12 @@
+//go:build fuse
+
 package fuse
 
 import "github.com/winfsp/cgofuse/fuse"
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+// Mount mounts fs at mountDst, it blocks until the file system
+// is unmounted and reports whether the mount succeeded
+func Mount(fs *Fs, mountDst string, opts []string) bool {
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	return host.Mount(mountDst, opts)
 }
@@ -0,0 +1,166 @@
+// Package health probes the storages periodically and takes the failing ones out of service
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	historySize  = 20
+	probeTimeout = 30 * time.Second
+)
+
+var (
+	mu        sync.Mutex
+	healthMap = make(map[string]*model.StorageHealth)
+	lastCheck time.Time
+)
+
+// AutoCheck probes all the storages once the interval of conf.HealthCheckInterval has passed
+func AutoCheck() {
+	interval := time.Duration(setting.GetInt(conf.HealthCheckInterval, 5)) * time.Minute
+	// it's called every minute, the ticks may come a little earlier than the interval
+	if interval <= 0 || time.Since(lastCheck) < interval-time.Second*10 {
+		return
+	}
+	lastCheck = time.Now()
+	Check(context.Background())
+}
+
+// Check probes the storages concurrently, the storages which failed to init are skipped
+func Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, storage := range op.GetAllStorages() {
+		if !probeable(storage) {
+			continue
+		}
+		wg.Add(1)
+		go func(storage driver.Driver) {
+			defer wg.Done()
+			check(ctx, storage)
+		}(storage)
+	}
+	wg.Wait()
+}
+
+// probeable reports whether the storage works or is taken out of service by the checker,
+// the storages which failed to init are left to be reloaded
+func probeable(storage driver.Driver) bool {
+	if storage.GetStorage().Status == op.WORK {
+		return true
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	h, ok := healthMap[storage.GetStorage().MountPath]
+	return ok && !h.Healthy
+}
+
+// probe lists the root of the storage
+func probe(ctx context.Context, storage driver.Driver) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic while probing: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	root, err := op.GetUnwrap(ctx, storage, "/")
+	if err != nil {
+		return errors.WithMessage(err, "failed get root")
+	}
+	_, err = storage.List(ctx, root, model.ListArgs{})
+	return errors.WithMessage(err, "failed list root")
+}
+
+func check(ctx context.Context, storage driver.Driver) {
+	mountPath := storage.GetStorage().MountPath
+	start := time.Now()
+	err := probe(ctx, storage)
+	elapsed := time.Since(start)
+	result := model.HealthCheck{Time: start, Latency: elapsed.Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		log.Warnf("storage %s health check failed: %+v", mountPath, err)
+	}
+	threshold := setting.GetInt(conf.HealthCheckFailures, 2)
+
+	mu.Lock()
+	h, ok := healthMap[mountPath]
+	if !ok {
+		h = &model.StorageHealth{MountPath: mountPath, Healthy: true}
+		healthMap[mountPath] = h
+	}
+	h.History = append(h.History, result)
+	if len(h.History) > historySize {
+		h.History = h.History[len(h.History)-historySize:]
+	}
+	changed := false
+	if err == nil {
+		op.RecordLatency(mountPath, elapsed)
+		h.Failures = 0
+		h.Latency = result.Latency
+		changed = !h.Healthy
+		h.Healthy = true
+	} else {
+		h.Failures++
+		changed = h.Healthy && h.Failures >= threshold
+		if changed {
+			h.Healthy = false
+		}
+	}
+	failures := h.Failures
+	mu.Unlock()
+
+	if !changed {
+		return
+	}
+	// the storages which don't work are skipped by the balance
+	if err == nil {
+		storage.GetStorage().SetStatus(op.WORK)
+		log.Infof("storage %s is healthy again", mountPath)
+		go op.Notify("存储状态变化", fmt.Sprintf("存储 %s 已恢复", mountPath))
+	} else {
+		storage.GetStorage().SetStatus(fmt.Sprintf("unhealthy after %d failed checks, the last took %dms: %s",
+			failures, result.Latency, err.Error()))
+		log.Errorf("storage %s is unhealthy: %+v", mountPath, err)
+		go op.Notify("存储状态变化", fmt.Sprintf("存储 %s 不可用: %s", mountPath, err.Error()))
+	}
+	op.MustSaveDriverStorage(storage)
+}
+
+// GetAll returns the health of the storages which have been checked
+func GetAll() []model.StorageHealth {
+	mu.Lock()
+	defer mu.Unlock()
+	res := make([]model.StorageHealth, 0, len(healthMap))
+	for _, h := range healthMap {
+		c := *h
+		c.History = append([]model.HealthCheck{}, h.History...)
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].MountPath < res[j].MountPath
+	})
+	return res
+}
+
+func init() {
+	// the health is checked again after the storage is reloaded
+	op.RegisterStorageHook(func(typ string, storage driver.Driver) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(healthMap, storage.GetStorage().MountPath)
+	})
+}
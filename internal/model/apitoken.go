@@ -0,0 +1,73 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// ApiTokenPrefix tells the api tokens apart from the login tokens
+const ApiTokenPrefix = "alist_pat_"
+
+const (
+	// ScopeRead allows the api, the write operations are removed from the permission of the user
+	ScopeRead = "read"
+	// ScopeWrite allows the api with the permission of the user
+	ScopeWrite = "write"
+	// ScopeAdmin keeps the admin as the admin, which allows the admin api and everything else
+	// of the api, only for the admin
+	ScopeAdmin = "admin"
+	// ScopeWebdav allows the webdav, which is read only without ScopeWrite
+	ScopeWebdav = "webdav"
+	// ScopeS3 allows managing the s3 keys of the user, the keys aren't restricted by the token
+	ScopeS3 = "s3"
+)
+
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeAdmin, ScopeWebdav, ScopeS3}
+
+// writePermissions are the bits of the permission removed without ScopeWrite
+const writePermissions int32 = 1<<2 | 1<<3 | 1<<4 | 1<<5 | 1<<6 | 1<<7 | 1<<9 | 1<<11 | 1<<12
+
+type ApiToken struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserId uint   `json:"user_id" gorm:"index"`
+	Name   string `json:"name"`
+	// Hash is the sha256 of the token, the token is only shown when it's created
+	Hash string `json:"-" gorm:"unique;size:64"`
+	// Hint is the last chars of the token to tell the tokens apart
+	Hint string `json:"hint"`
+	// Scopes are separated by comma
+	Scopes string `json:"scopes"`
+	// PathPrefix restricts the token to the path under the base path of the user, empty means no restriction
+	PathPrefix string     `json:"path_prefix"`
+	Expires    *time.Time `json:"expires"`
+	Created    time.Time  `json:"created"`
+	LastUsed   *time.Time `json:"last_used"`
+}
+
+func (t *ApiToken) IsExpired() bool {
+	return t.Expires != nil && !t.Expires.IsZero() && time.Now().After(*t.Expires)
+}
+
+func (t *ApiToken) HasScope(scope string) bool {
+	return utils.SliceContains(strings.Split(t.Scopes, ","), scope)
+}
+
+// Restrict returns a copy of the user restricted by the token: the admin is taken as a general user
+// with all permissions without ScopeAdmin, the write permissions are removed without ScopeWrite
+// and the base path is joined with the path prefix
+func (t *ApiToken) Restrict(u *User) *User {
+	res := *u
+	if res.IsAdmin() && !t.HasScope(ScopeAdmin) {
+		res.Role = GENERAL
+		res.Permission = 1<<13 - 1
+	}
+	if !t.HasScope(ScopeWrite) {
+		res.Permission &^= writePermissions
+	}
+	if t.PathPrefix != "" && t.PathPrefix != "/" {
+		res.BasePath = utils.FixAndCleanPath(res.BasePath + "/" + t.PathPrefix)
+	}
+	return &res
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+const (
+	AuditMkdir           = "mkdir"
+	AuditRename          = "rename"
+	AuditMove            = "move"
+	AuditCopy            = "copy"
+	AuditRemove          = "remove"
+	AuditUpload          = "upload"
+	AuditOfflineDownload = "offline_download"
+	AuditLogin           = "login"
+	AuditStorageCreate   = "storage_create"
+	AuditStorageUpdate   = "storage_update"
+	AuditStorageDelete   = "storage_delete"
+	AuditStorageEnable   = "storage_enable"
+	AuditStorageDisable  = "storage_disable"
+	AuditUserCreate      = "user_create"
+	AuditUserUpdate      = "user_update"
+	AuditUserDelete      = "user_delete"
+	AuditSettingSave     = "setting_save"
+	AuditSettingDelete   = "setting_delete"
+	AuditMetaCreate      = "meta_create"
+	AuditMetaUpdate      = "meta_update"
+	AuditMetaDelete      = "meta_delete"
+	AuditBundleExport    = "bundle_export"
+	AuditBundleImport    = "bundle_import"
+)
+
+type AuditLog struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	UserId   uint   `json:"user_id"`
+	Username string `json:"username" gorm:"index"`
+	IP       string `json:"ip"`
+	Action   string `json:"action" gorm:"index"`
+	SrcPath  string `json:"src_path"`
+	DstPath  string `json:"dst_path"`
+	// Storage is the mount path of the storage which the object belongs to
+	Storage   string    `json:"storage"`
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+type AuditLogReq struct {
+	PageReq
+	Username string `json:"username" form:"username"`
+	Action   string `json:"action" form:"action"`
+	// Path matches the src path or the dst path by prefix
+	Path    string `json:"path" form:"path"`
+	IP      string `json:"ip" form:"ip"`
+	Success *bool  `json:"success" form:"success"`
+	// Start and End are unix timestamps in seconds
+	Start int64 `json:"start" form:"start"`
+	End   int64 `json:"end" form:"end"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// HealthCheck is the result of a probe of a storage
+type HealthCheck struct {
+	Time time.Time `json:"time"`
+	// Latency is the milliseconds the probe took
+	Latency int64  `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// StorageHealth is the health state of a storage with its recent checks
+type StorageHealth struct {
+	MountPath string `json:"mount_path"`
+	Healthy   bool   `json:"healthy"`
+	// Failures is the count of the consecutive failed checks
+	Failures int `json:"failures"`
+	// Latency is the latency of the last successful check
+	Latency int64         `json:"latency"`
+	History []HealthCheck `json:"history"`
+}
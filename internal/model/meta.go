@@ -13,4 +13,6 @@ type Meta struct {
 	RSub      bool   `json:"r_sub"`
 	Header    string `json:"header"`
 	HeaderSub bool   `json:"header_sub"`
+	// Managed is set if the path is declared in the storages file
+	Managed bool `json:"managed"`
 }
@@ -50,6 +50,18 @@ type FileStreamer interface {
 	CacheFullInTempFile() (File, error)
 }
 
+// FilePart is a part of the file staged on the local disk
+type FilePart struct {
+	Path string
+	Size int64
+}
+
+// FileParts is implemented by the streams made of the parts staged on the local disk,
+// the drivers uploading in chunks can upload the parts as the chunks without reading the stream
+type FileParts interface {
+	GetParts() []FilePart
+}
+
 type URL interface {
 	URL() string
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// S3Key is an access key pair of the s3 server, the requests signed by it are served as the user
+type S3Key struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	UserId      uint   `json:"user_id" gorm:"index"`
+	Name        string `json:"name"`
+	AccessKeyId string `json:"access_key_id" gorm:"unique;size:32"`
+	// SecretAccessKey is kept in plaintext to verify the signatures, it's only shown when the key is created
+	SecretAccessKey string    `json:"-"`
+	Created         time.Time `json:"created"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// S3Upload is a multipart upload of the s3 server, the parts are staged in the temp dir until it's completed
+type S3Upload struct {
+	ID     string `json:"id" gorm:"primaryKey;size:64"`
+	UserId uint   `json:"user_id" gorm:"index"`
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	// Path is the path of the object in alist
+	Path     string    `json:"path"`
+	Mimetype string    `json:"mimetype"`
+	Modified time.Time `json:"modified"`
+	Created  time.Time `json:"created"`
+}
+
+type S3UploadPart struct {
+	UploadId   string    `json:"upload_id" gorm:"primaryKey;size:64"`
+	PartNumber int       `json:"part_number" gorm:"primaryKey;autoIncrement:false"`
+	Size       int64     `json:"size"`
+	ETag       string    `json:"etag"`
+	Modified   time.Time `json:"modified"`
+}
@@ -2,6 +2,9 @@ package model
 
 import (
 	"fmt"
+	stdpath "path"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -12,19 +15,57 @@ type IndexProgress struct {
 	Error        string     `json:"error"`
 }
 
+const (
+	NameMatchRegex = "regex"
+	NameMatchGlob  = "glob"
+)
+
+// ContentPrefix makes the rest of the keywords be searched in the content of the files
+const ContentPrefix = "content:"
+
 type SearchReq struct {
 	Parent   string `json:"parent"`
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// size range in bytes, 0 means no limit
+	MinSize int64 `json:"min_size"`
+	MaxSize int64 `json:"max_size"`
+	// modified time range, zero means no limit
+	ModifiedAfter  time.Time `json:"modified_after"`
+	ModifiedBefore time.Time `json:"modified_before"`
+	// file type categories such as conf.VIDEO
+	FileTypes []int `json:"file_types"`
+	// extensions without the dot, case-insensitive
+	Extensions []string `json:"extensions"`
+	// NameMatch is empty to match the keywords in the way of the searcher,
+	// or regex/glob to match the whole name with the keywords as a pattern
+	NameMatch string `json:"name_match"`
+	// OrderBy is one of name, size and modified, the default order of the searcher is used if empty
+	OrderBy        string `json:"order_by"`
+	OrderDirection string `json:"order_direction"`
+	// InContent is set if the keywords start with ContentPrefix
+	InContent bool `json:"-"`
 	PageReq
+
+	nameRegexp *regexp.Regexp
 }
 
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	// IndexName is the name of the index the node belongs to in the database searchers,
+	// it's empty for the default index
+	IndexName string    `json:"-" gorm:"index;default:''"`
+	Parent    string    `json:"parent" gorm:"index"`
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	Size      int64     `json:"size"`
+	Modified  time.Time `json:"modified"`
+	// FileType is the category of the obj, see utils.GetObjType
+	FileType int `json:"file_type"`
+	// Content is the extracted text of the file, only indexed by the searchers supporting it
+	Content string `json:"content,omitempty" gorm:"-"`
+	// Snippet is the highlighted fragment of the content matched in the content search
+	Snippet string `json:"snippet,omitempty" gorm:"-"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,9 +75,60 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	if strings.HasPrefix(p.Keywords, ContentPrefix) {
+		p.InContent = true
+		p.Keywords = strings.TrimSpace(strings.TrimPrefix(p.Keywords, ContentPrefix))
+		if p.NameMatch != "" {
+			return fmt.Errorf("name_match can't be used in content search")
+		}
+	}
+	switch p.NameMatch {
+	case "":
+	case NameMatchRegex:
+		re, err := regexp.Compile("^(?:" + p.Keywords + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		p.nameRegexp = re
+	case NameMatchGlob:
+		if _, err := stdpath.Match(p.Keywords, ""); err != nil {
+			return fmt.Errorf("invalid glob: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid name_match: %s", p.NameMatch)
+	}
+	switch p.OrderBy {
+	case "", "name", "size", "modified":
+	default:
+		return fmt.Errorf("invalid order_by: %s", p.OrderBy)
+	}
+	switch p.OrderDirection {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("invalid order_direction: %s", p.OrderDirection)
+	}
+	for i, ext := range p.Extensions {
+		p.Extensions[i] = strings.ToLower(strings.TrimPrefix(ext, "."))
+	}
 	return nil
 }
 
+// MatchName reports whether the whole name matches the keywords as a regex or glob pattern,
+// a glob pattern matches case-insensitively
+func (p *SearchReq) MatchName(name string) bool {
+	switch p.NameMatch {
+	case NameMatchRegex:
+		if p.nameRegexp == nil {
+			p.nameRegexp = regexp.MustCompile("^(?:" + p.Keywords + ")$")
+		}
+		return p.nameRegexp.MatchString(name)
+	case NameMatchGlob:
+		ok, _ := stdpath.Match(strings.ToLower(p.Keywords), strings.ToLower(name))
+		return ok
+	}
+	return true
+}
+
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
@@ -0,0 +1,40 @@
+package model
+
+import "strings"
+
+// SearchIndex is a named search index besides the default one set by the search_index setting,
+// it indexes its own paths with its own searcher
+type SearchIndex struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"unique" binding:"required"`
+	// Backend is the name of the searcher, such as bleve, database and meilisearch
+	Backend string `json:"backend" binding:"required"`
+	// Paths and IgnorePaths are split by line
+	Paths       string `json:"paths" binding:"required"`
+	IgnorePaths string `json:"ignore_paths"`
+	// MaxDepth falls back to the max_index_depth setting if it's 0
+	MaxDepth int `json:"max_depth"`
+	// Interval is the minutes between two rebuilds, the index is only built manually if it's 0
+	Interval int           `json:"interval"`
+	Disabled bool          `json:"disabled"`
+	Progress IndexProgress `json:"progress" gorm:"embedded;embeddedPrefix:progress_"`
+	Running  bool          `json:"running" gorm:"-"`
+}
+
+func (s *SearchIndex) GetPaths() []string {
+	return splitLines(s.Paths)
+}
+
+func (s *SearchIndex) GetIgnorePaths() []string {
+	return splitLines(s.IgnorePaths)
+}
+
+func splitLines(s string) []string {
+	var res []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
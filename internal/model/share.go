@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+)
+
+type Share struct {
+	ID     string `json:"id" gorm:"primaryKey;size:32"`
+	UserId uint   `json:"user_id" gorm:"index"`
+	// Path is the shared file or folder, the base path of the user is included
+	Path string `json:"path"`
+	// PwdHash is the salted hash of the password, the password itself is never stored or returned
+	PwdHash string     `json:"-"`
+	Salt    string     `json:"-"`
+	Expires *time.Time `json:"expires"`
+	// MaxDownloads limits the times of downloading files in the share, 0 means unlimited
+	MaxDownloads int64 `json:"max_downloads"`
+	// Browse enables the read-only browse page of a shared folder
+	Browse    bool      `json:"browse"`
+	Remark    string    `json:"remark"`
+	Accessed  int64     `json:"accessed"`
+	Downloads int64     `json:"downloads"`
+	Created   time.Time `json:"created"`
+}
+
+func (s *Share) IsExpired() bool {
+	return s.Expires != nil && !s.Expires.IsZero() && time.Now().After(*s.Expires)
+}
+
+func (s *Share) IsExhausted() bool {
+	return s.MaxDownloads > 0 && s.Downloads >= s.MaxDownloads
+}
+
+func (s *Share) HasPassword() bool {
+	return s.PwdHash != ""
+}
+
+// SetPassword hashes the password, an empty password removes it
+func (s *Share) SetPassword(pwd string) *Share {
+	if pwd == "" {
+		s.PwdHash, s.Salt = "", ""
+		return s
+	}
+	s.Salt = random.String(16)
+	s.PwdHash = TwoHashPwd(pwd, s.Salt)
+	return s
+}
+
+func (s *Share) ValidatePassword(pwd string) bool {
+	return !s.HasPassword() || TwoHashPwd(pwd, s.Salt) == s.PwdHash
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type SSHPublicKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserId       uint      `json:"-" gorm:"index"`
+	Title        string    `json:"title"`
+	Fingerprint  string    `json:"fingerprint" gorm:"unique"`
+	KeyStr       string    `json:"-" gorm:"type:text"`
+	AddedTime    time.Time `json:"added_time"`
+	LastUsedTime time.Time `json:"last_used_time"`
+}
+
+func (k *SSHPublicKey) GetKey() (ssh.PublicKey, error) {
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.KeyStr))
+	return pubKey, err
+}
@@ -16,6 +16,15 @@ type Storage struct {
 	Modified        time.Time `json:"modified"`
 	Disabled        bool      `json:"disabled"` // if disabled
 	EnableSign      bool      `json:"enable_sign"`
+	QuotaBytes      int64     `json:"quota_bytes"`    // max bytes all users can upload to the storage, 0 means no limit
+	DownloadLimit   int64     `json:"download_limit"` // bandwidth of proxied downloads in KiB/s, 0 means no limit
+	UploadLimit     int64     `json:"upload_limit"`   // bandwidth of uploads in KiB/s, 0 means no limit
+	Weight          int       `json:"weight"`         // weight in the weighted balance, 0 is taken as 1
+	// BalanceStrategy of the storages sharing the mount path, the one of the storage
+	// mounted without the balance suffix is used
+	BalanceStrategy string `json:"balance_strategy"`
+	// Managed is set if the mount path is declared in the storages file
+	Managed bool `json:"managed"`
 	Sort
 	Proxy
 }
@@ -56,3 +65,11 @@ func (p Proxy) WebdavProxy() bool {
 func (p Proxy) WebdavNative() bool {
 	return !p.Webdav302() && !p.WebdavProxy()
 }
+
+// StorageDetails is the space of the storage, it's reported by the drivers implementing driver.WithDetails
+type StorageDetails struct {
+	TotalSpace int64 `json:"total_space"`
+	UsedSpace  int64 `json:"used_space"`
+	// FreeSpace is the space available to alist, it may be less than TotalSpace-UsedSpace
+	FreeSpace int64 `json:"free_space"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+const (
+	// SyncModeMirror copies the new or changed files of SrcPath to DstPath
+	SyncModeMirror = "mirror"
+	// SyncModeBisync propagates the changes of each side to the other side
+	SyncModeBisync = "bisync"
+)
+
+// the policies to resolve the conflicts of bisync, which happen if a file is changed on both sides
+const (
+	SyncConflictNewer    = "newer"
+	SyncConflictKeepBoth = "keep_both"
+	SyncConflictSource   = "source"
+)
+
+// SyncJob syncs SrcPath and DstPath periodically, only new or changed files are copied
+type SyncJob struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name"`
+	SrcPath string `json:"src_path" binding:"required"`
+	DstPath string `json:"dst_path" binding:"required"`
+	// Interval is the minutes between two runs, the job only runs manually if it's 0
+	Interval int `json:"interval"`
+	// Mode is SyncModeMirror if it's empty
+	Mode           string `json:"mode"`
+	ConflictPolicy string `json:"conflict_policy"`
+	// Delete removes the objects in DstPath which don't exist in SrcPath in mirror mode,
+	// deletions are always propagated in bisync mode
+	Delete   bool        `json:"delete"`
+	Disabled bool        `json:"disabled"`
+	LastRun  SyncSummary `json:"last_run" gorm:"embedded;embeddedPrefix:last_"`
+	Running  bool        `json:"running" gorm:"-"`
+}
+
+// SyncSummary is the result of a run of a sync job
+type SyncSummary struct {
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Copied     int        `json:"copied"`
+	Skipped    int        `json:"skipped"`
+	Deleted    int        `json:"deleted"`
+	Renamed    int        `json:"renamed"`
+	Conflicts  int        `json:"conflicts"`
+	Failed     int        `json:"failed"`
+	// Errors contains the errors of the failed items, one per line
+	Errors string `json:"errors" gorm:"type:text"`
+}
+
+// SyncState is an object which exists on both sides after the last run of a bisync job,
+// the changes of each side are detected by comparing with it
+type SyncState struct {
+	ID    uint `gorm:"primaryKey"`
+	JobId uint `gorm:"index"`
+	// Path is relative to SrcPath and DstPath
+	Path        string
+	IsDir       bool
+	SrcSize     int64
+	SrcModified time.Time
+	SrcHash     string
+	DstSize     int64
+	DstModified time.Time
+	DstHash     string
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// TrashItem is an object moved into the trash folder of its storage by fs.Remove
+type TrashItem struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserId    uint   `json:"user_id" gorm:"index"`
+	MountPath string `json:"mount_path" gorm:"index"`
+	// OriginalPath is the full path of the object before it was removed
+	OriginalPath string `json:"original_path"`
+	// TrashPath is the full path of the folder in the trash which contains the object
+	TrashPath string    `json:"trash_path"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	IsDir     bool      `json:"is_dir"`
+	DeletedAt time.Time `json:"deleted_at" gorm:"index"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// Usage is the bytes and count of files which a user uploaded to a storage
+type Usage struct {
+	ID        uint   `json:"-" gorm:"primaryKey"`
+	UserId    uint   `json:"user_id" gorm:"uniqueIndex:idx_usage_user_mount"`
+	MountPath string `json:"mount_path" gorm:"uniqueIndex:idx_usage_user_mount"`
+	Bytes     int64  `json:"bytes"`
+	Files     int64  `json:"files"`
+}
+
+// UserUsage is the total usage of a user
+type UserUsage struct {
+	UserId     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	QuotaBytes int64  `json:"quota_bytes"`
+	QuotaFiles int64  `json:"quota_files"`
+	Bytes      int64  `json:"bytes"`
+	Files      int64  `json:"files"`
+}
+
+// MountUsage is the total usage of a storage
+type MountUsage struct {
+	MountPath  string `json:"mount_path"`
+	QuotaBytes int64  `json:"quota_bytes"`
+	Bytes      int64  `json:"bytes"`
+	Files      int64  `json:"files"`
+}
+
+// UsageTotal is the total usage of a user in all storages if MountPath is empty,
+// or of all users in a storage if UserId is 0. The quotas are checked against it
+// by one conditional update, so concurrent uploads can't exceed them.
+type UsageTotal struct {
+	ID        uint   `json:"-" gorm:"primaryKey"`
+	UserId    uint   `json:"user_id" gorm:"uniqueIndex:idx_usage_total_user_mount"`
+	MountPath string `json:"mount_path" gorm:"uniqueIndex:idx_usage_total_user_mount"`
+	Bytes     int64  `json:"bytes"`
+	Files     int64  `json:"files"`
+}
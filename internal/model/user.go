@@ -31,6 +31,12 @@ type User struct {
 	BasePath string `json:"base_path"`                                 // base path
 	Role     int    `json:"role"`                                      // user's role
 	Disabled bool   `json:"disabled"`
+	// the max bytes and count of files the user can upload, 0 means no limit
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+	// the bandwidth of the user in KiB/s, 0 means no limit
+	DownloadLimit int64 `json:"download_limit"`
+	UploadLimit   int64 `json:"upload_limit"`
 	// Determine permissions by bit
 	//   0: can see hidden files
 	//   1: can access without password
@@ -42,10 +48,15 @@ type User struct {
 	//   7: can remove
 	//   8: webdav read
 	//   9: webdav write
+	//  10: ftp/sftp login and read
+	//  11: ftp/sftp write
+	//  12: can create share links
 	Permission int32  `json:"permission"`
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	// Managed is set if the username is declared in the storages file
+	Managed bool `json:"managed"`
 }
 
 func (u *User) IsGuest() bool {
@@ -117,6 +128,18 @@ func (u *User) CanWebdavManage() bool {
 	return u.IsAdmin() || (u.Permission>>9)&1 == 1
 }
 
+func (u *User) CanFTPAccess() bool {
+	return u.IsAdmin() || (u.Permission>>10)&1 == 1
+}
+
+func (u *User) CanFTPManage() bool {
+	return u.IsAdmin() || (u.Permission>>11)&1 == 1
+}
+
+func (u *User) CanShare() bool {
+	return u.IsAdmin() || (u.Permission>>12)&1 == 1
+}
+
 func (u *User) JoinPath(reqPath string) (string, error) {
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
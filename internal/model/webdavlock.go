@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// WebdavLock is a lock of the webdav server kept in the database
+type WebdavLock struct {
+	Token string `json:"token" gorm:"primaryKey;size:64"`
+	Root  string `json:"root" gorm:"index"`
+	// Duration is in seconds, negative means infinite
+	Duration  int64  `json:"duration"`
+	OwnerXML  string `json:"owner_xml" gorm:"type:text"`
+	ZeroDepth bool   `json:"zero_depth"`
+	// Temporary locks are created to protect the resources while a request without locks is handled
+	Temporary bool `json:"temporary"`
+	// Expiry is nil if the lock never expires
+	Expiry  *time.Time `json:"expiry" gorm:"index"`
+	Created time.Time  `json:"created"`
+	// HeldBy is the id of the request holding the lock on any alist instance, the lock is held
+	// until HeldUntil, which is extended while the request is being handled
+	HeldBy    string     `json:"held_by" gorm:"size:64"`
+	HeldUntil *time.Time `json:"held_until"`
+}
+
+func (l *WebdavLock) IsHeld(now time.Time) bool {
+	return l.HeldUntil != nil && now.Before(*l.HeldUntil)
+}
+
+func (l *WebdavLock) IsExpired(now time.Time) bool {
+	return l.Expiry != nil && !now.Before(*l.Expiry)
+}
+
+// WebdavLockMutex is the only row which is updated at the beginning of the transactions changing the webdav locks,
+// so the transactions are serialized among all the alist instances sharing the database
+type WebdavLockMutex struct {
+	ID      uint `gorm:"primaryKey"`
+	Updated time.Time
+}
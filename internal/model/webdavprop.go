@@ -0,0 +1,13 @@
+package model
+
+// WebdavProp is a dead property set by PROPPATCH, it's keyed by the full path of the resource
+type WebdavProp struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Path string `json:"path" gorm:"index"`
+	// Parent is the dir of Path, so the props of a dir's children are found at once
+	Parent    string `json:"parent" gorm:"index"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	InnerXML  string `json:"inner_xml" gorm:"type:text"`
+}
@@ -74,6 +74,9 @@ func AddURL(ctx context.Context, args *AddURLArgs) (tache.TaskWithInfo, error) {
 		DeletePolicy: args.DeletePolicy,
 		tool:         tool,
 	}
+	if user, ok := ctx.Value("user").(*model.User); ok {
+		t.UserId = user.ID
+	}
 	if tool.Name() == "storage" {
 		args := model.FsOtherArgs{
 			Path:   args.DstDirPath,
@@ -20,6 +20,8 @@ type DownloadTask struct {
 	DstDirPath   string       `json:"dst_dir_path"`
 	TempDir      string       `json:"temp_dir"`
 	DeletePolicy DeletePolicy `json:"delete_policy"`
+	// UserId is the user who added the task, the transferred files are counted into the usage of the user
+	UserId uint `json:"user_id"`
 
 	Status            string   `json:"status"`
 	Signal            chan int `json:"-"`
@@ -160,6 +162,7 @@ func (t *DownloadTask) Complete() error {
 			TempDir:      t.TempDir,
 			DeletePolicy: t.DeletePolicy,
 			FileDir:      file.Path,
+			UserId:       t.UserId,
 		})
 	}
 	return nil
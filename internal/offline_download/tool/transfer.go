@@ -7,6 +7,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/tache"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -21,6 +22,7 @@ type TransferTask struct {
 	DstDirPath   string       `json:"dst_dir_path"`
 	TempDir      string       `json:"temp_dir"`
 	DeletePolicy DeletePolicy `json:"delete_policy"`
+	UserId       uint         `json:"user_id"`
 }
 
 func (t *TransferTask) Run() error {
@@ -59,7 +61,16 @@ func (t *TransferTask) Run() error {
 		log.Errorf("find relation directory error: %v", err)
 	}
 	newDistDir := filepath.Join(dstDirActualPath, relDir)
-	return op.Put(t.Ctx(), storage, newDistDir, s, t.SetProgress)
+	ctx := quota.WithUser(t.Ctx(), t.UserId)
+	if err := quota.Reserve(ctx, t.DstDirPath, t.file.Size, 1); err != nil {
+		_ = rc.Close()
+		return tache.Unrecoverable(err)
+	}
+	err = op.Put(ctx, storage, newDistDir, s, t.SetProgress)
+	if err != nil {
+		quota.Add(ctx, t.DstDirPath, -t.file.Size, -1)
+	}
+	return err
 }
 
 func (t *TransferTask) GetName() string {
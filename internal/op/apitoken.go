@@ -0,0 +1,93 @@
+package op
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// lastUsedInterval is how often the last used time of a token is saved
+const lastUsedInterval = time.Minute
+
+func hashApiToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateApiToken generates the token of t for the user and saves its hash, the token is returned
+// and can't be got again
+func CreateApiToken(user *model.User, t *model.ApiToken) (string, error) {
+	scopes := strings.Split(t.Scopes, ",")
+	for i := range scopes {
+		scopes[i] = strings.TrimSpace(scopes[i])
+		if !utils.SliceContains(model.Scopes, scopes[i]) {
+			return "", errors.Wrapf(errs.InvalidScope, "unknown scope [%s]", scopes[i])
+		}
+		if scopes[i] == model.ScopeAdmin && !user.IsAdmin() {
+			return "", errors.Wrap(errs.InvalidScope, "only the admin can have the admin scope")
+		}
+	}
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.WithStack(err)
+	}
+	token := model.ApiTokenPrefix + hex.EncodeToString(buf)
+	t.ID = 0
+	t.UserId = user.ID
+	t.Scopes = strings.Join(scopes, ",")
+	if t.PathPrefix != "" {
+		t.PathPrefix = utils.FixAndCleanPath(t.PathPrefix)
+	}
+	t.Hash = hashApiToken(token)
+	t.Hint = token[len(token)-4:]
+	t.Created = time.Now()
+	t.LastUsed = nil
+	if err := db.CreateApiToken(t); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func GetApiTokensByUserId(userId uint, pageIndex, pageSize int) ([]model.ApiToken, int64, error) {
+	return db.GetApiTokensByUserId(userId, pageIndex, pageSize)
+}
+
+// DeleteApiToken revokes the token of the user
+func DeleteApiToken(id, userId uint) error {
+	t, err := db.GetApiTokenById(id)
+	if err != nil {
+		return err
+	}
+	if t.UserId != userId {
+		return errors.Wrapf(errs.ObjectNotFound, "failed get token")
+	}
+	return db.DeleteApiTokenById(id)
+}
+
+// GetUserByApiToken returns the token and its owner restricted by the token,
+// the last used time of the token is updated
+func GetUserByApiToken(token string) (*model.ApiToken, *model.User, error) {
+	t, err := db.GetApiTokenByHash(hashApiToken(token))
+	if err != nil || t.IsExpired() {
+		return nil, nil, errors.WithStack(errs.InvalidApiToken)
+	}
+	user, err := GetUserById(t.UserId)
+	if err != nil {
+		return nil, nil, errors.WithStack(errs.InvalidApiToken)
+	}
+	if now := time.Now(); t.LastUsed == nil || now.Sub(*t.LastUsed) > lastUsedInterval {
+		if err := db.UpdateApiTokenLastUsed(t.ID, now); err != nil {
+			log.Warnf("failed update last used time of api token: %+v", err)
+		}
+	}
+	return t, t.Restrict(user), nil
+}
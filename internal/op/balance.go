@@ -0,0 +1,193 @@
+package op
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	BalanceRoundRobin         = "round_robin"
+	BalanceWeightedRoundRobin = "weighted_round_robin"
+	BalanceLeastActive        = "least_active"
+	BalanceLatency            = "latency"
+	BalanceIPHash             = "ip_hash"
+)
+
+var (
+	balanceMap generic_sync.MapOf[string, int]
+	// current weights of the smooth weighted round-robin of each virtual path
+	weightsMu sync.Mutex
+	weights   = make(map[string]map[string]int)
+	// active requests and average latency of each storage
+	activeMap  generic_sync.MapOf[string, *atomic.Int64]
+	latencyMu  sync.Mutex
+	latencyMap generic_sync.MapOf[string, time.Duration]
+)
+
+func checkBalanceStrategy(strategy string) error {
+	switch strategy {
+	case "", BalanceRoundRobin, BalanceWeightedRoundRobin, BalanceLeastActive, BalanceLatency, BalanceIPHash:
+		return nil
+	}
+	return errors.Errorf("unknown balance strategy: %s", strategy)
+}
+
+// latencyAlpha is the weight of the newest sample in the moving average of the latency
+const latencyAlpha = 0.3
+
+// RecordLatency adds a sample to the moving average of the latency of the storage,
+// it's recorded by the health check and by the list and link requests sent to the driver
+func RecordLatency(mountPath string, latency time.Duration) {
+	latencyMu.Lock()
+	defer latencyMu.Unlock()
+	if old, ok := latencyMap.Load(mountPath); ok {
+		latency = time.Duration(float64(old)*(1-latencyAlpha) + float64(latency)*latencyAlpha)
+	}
+	latencyMap.Store(mountPath, latency)
+}
+
+// recordLatencySince records the latency of a successful request sent to the driver since start
+func recordLatencySince(storage driver.Driver, start time.Time) {
+	RecordLatency(storage.GetStorage().MountPath, time.Since(start))
+}
+
+func activeOf(mountPath string) *atomic.Int64 {
+	active, _ := activeMap.LoadOrStore(mountPath, new(atomic.Int64))
+	return active
+}
+
+// clientIP returns the ip of the client which sent the request of ctx
+func clientIP(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c.ClientIP()
+	}
+	ip, _ := ctx.Value("ip").(string)
+	return ip
+}
+
+// trackActive counts the request of ctx as active on the storage until the request is done
+func trackActive(ctx context.Context, storage driver.Driver) {
+	// gin.Context is never done, but its request is
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	if ctx.Done() == nil {
+		return
+	}
+	active := activeOf(storage.GetStorage().MountPath)
+	active.Add(1)
+	context.AfterFunc(ctx, func() {
+		active.Add(-1)
+	})
+}
+
+// strategyOf returns the balance strategy of the storages sharing the virtual path
+func strategyOf(virtualPath string, storages []driver.Driver) string {
+	for _, s := range storages {
+		if s.GetStorage().MountPath == virtualPath {
+			return s.GetStorage().BalanceStrategy
+		}
+	}
+	return storages[0].GetStorage().BalanceStrategy
+}
+
+// balance picks one of the storages sharing the same virtual path by the strategy,
+// the ip of the client is only used by the ip hash strategy
+func balance(storages []driver.Driver, clientIP string) driver.Driver {
+	virtualPath := utils.GetActualMountPath(storages[0].GetStorage().MountPath)
+	switch strategyOf(virtualPath, storages) {
+	case BalanceWeightedRoundRobin:
+		return weightedRoundRobin(virtualPath, storages)
+	case BalanceLeastActive:
+		return leastActive(virtualPath, storages)
+	case BalanceLatency:
+		return lowestLatency(virtualPath, storages)
+	case BalanceIPHash:
+		if clientIP != "" {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(clientIP))
+			return storages[h.Sum32()%uint32(len(storages))]
+		}
+	}
+	return roundRobin(virtualPath, storages)
+}
+
+func roundRobin(virtualPath string, storages []driver.Driver) driver.Driver {
+	i, _ := balanceMap.LoadOrStore(virtualPath, 0)
+	i = (i + 1) % len(storages)
+	balanceMap.Store(virtualPath, i)
+	return storages[i]
+}
+
+// weightedRoundRobin is the smooth weighted round-robin of nginx
+func weightedRoundRobin(virtualPath string, storages []driver.Driver) driver.Driver {
+	weightsMu.Lock()
+	defer weightsMu.Unlock()
+	current, ok := weights[virtualPath]
+	if !ok {
+		current = make(map[string]int)
+		weights[virtualPath] = current
+	}
+	total := 0
+	var best driver.Driver
+	for _, s := range storages {
+		weight := s.GetStorage().Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+		mountPath := s.GetStorage().MountPath
+		current[mountPath] += weight
+		if best == nil || current[mountPath] > current[best.GetStorage().MountPath] {
+			best = s
+		}
+	}
+	current[best.GetStorage().MountPath] -= total
+	return best
+}
+
+func leastActive(virtualPath string, storages []driver.Driver) driver.Driver {
+	var (
+		candidates []driver.Driver
+		least      int64
+	)
+	for _, s := range storages {
+		active := activeOf(s.GetStorage().MountPath).Load()
+		if candidates == nil || active < least {
+			candidates = []driver.Driver{s}
+			least = active
+		} else if active == least {
+			candidates = append(candidates, s)
+		}
+	}
+	return roundRobin(virtualPath, candidates)
+}
+
+// lowestLatency picks the storage with the lowest average latency,
+// the storages without any latency recorded are only picked if none has one
+func lowestLatency(virtualPath string, storages []driver.Driver) driver.Driver {
+	var (
+		best   driver.Driver
+		lowest time.Duration
+	)
+	for _, s := range storages {
+		latency, ok := latencyMap.Load(s.GetStorage().MountPath)
+		if ok && (best == nil || latency < lowest) {
+			best = s
+			lowest = latency
+		}
+	}
+	if best == nil {
+		return roundRobin(virtualPath, storages)
+	}
+	return best
+}
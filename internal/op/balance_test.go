@@ -0,0 +1,66 @@
+package op
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type balanceDriver struct {
+	driver.Driver
+	storage model.Storage
+}
+
+func (d *balanceDriver) GetStorage() *model.Storage {
+	return &d.storage
+}
+
+func balanceStorages(mountPaths ...string) []driver.Driver {
+	storages := make([]driver.Driver, 0, len(mountPaths))
+	for _, mountPath := range mountPaths {
+		storages = append(storages, &balanceDriver{storage: model.Storage{MountPath: mountPath, BalanceStrategy: BalanceLatency}})
+	}
+	return storages
+}
+
+func TestRecordLatency(t *testing.T) {
+	RecordLatency("/avg", 100*time.Millisecond)
+	if latency, _ := latencyMap.Load("/avg"); latency != 100*time.Millisecond {
+		t.Errorf("first sample = %s, want %s", latency, 100*time.Millisecond)
+	}
+	RecordLatency("/avg", 200*time.Millisecond)
+	if latency, _ := latencyMap.Load("/avg"); latency != 130*time.Millisecond {
+		t.Errorf("average = %s, want %s", latency, 130*time.Millisecond)
+	}
+}
+
+func TestLowestLatency(t *testing.T) {
+	storages := balanceStorages("/lat", "/lat.balance1", "/lat.balance2")
+	// none recorded yet, falls back to round-robin
+	seen := make(map[string]bool)
+	for range storages {
+		seen[balance(storages, "").GetStorage().MountPath] = true
+	}
+	if len(seen) != len(storages) {
+		t.Errorf("round-robin picked %v, want all storages", seen)
+	}
+
+	RecordLatency("/lat", 300*time.Millisecond)
+	RecordLatency("/lat.balance1", 100*time.Millisecond)
+	if got := balance(storages, "").GetStorage().MountPath; got != "/lat.balance1" {
+		t.Errorf("picked %s, want /lat.balance1", got)
+	}
+	// a single slow request doesn't outweigh the history
+	RecordLatency("/lat.balance1", 500*time.Millisecond)
+	if got := balance(storages, "").GetStorage().MountPath; got != "/lat.balance1" {
+		t.Errorf("picked %s after one slow request, want /lat.balance1", got)
+	}
+	for range 5 {
+		RecordLatency("/lat.balance1", 500*time.Millisecond)
+	}
+	if got := balance(storages, "").GetStorage().MountPath; got != "/lat" {
+		t.Errorf("picked %s after slow requests, want /lat", got)
+	}
+}
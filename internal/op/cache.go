@@ -0,0 +1,129 @@
+package op
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// the objs and links of drivers with Config.MemCacheOnly are kept in the memory caches,
+// since they can't be restored from the encoded form
+var (
+	listCache    = cache.New("list", &cache.Codec[[]model.Obj]{Encode: encodeObjs, Decode: decodeObjs})
+	memListCache = cache.New[[]model.Obj]("list:mem", nil)
+	linkCache    = cache.New("link", &cache.Codec[*model.Link]{Encode: encodeLink, Decode: decodeLink})
+	memLinkCache = cache.New[*model.Link]("link:mem", nil)
+)
+
+func listCacheOf(storage driver.Driver) *cache.Cache[[]model.Obj] {
+	if storage.Config().MemCacheOnly {
+		return memListCache
+	}
+	return listCache
+}
+
+func linkCacheOf(storage driver.Driver) *cache.Cache[*model.Link] {
+	if storage.Config().MemCacheOnly {
+		return memLinkCache
+	}
+	return linkCache
+}
+
+func cacheExpiration(storage driver.Driver) time.Duration {
+	return time.Minute * time.Duration(storage.GetStorage().CacheExpiration)
+}
+
+type cachedObj struct {
+	ID       string    `json:"id,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Ctime    time.Time `json:"ctime"`
+	IsFolder bool      `json:"is_folder,omitempty"`
+	Hash     string    `json:"hash,omitempty"`
+	Thumb    string    `json:"thumb,omitempty"`
+	URL      string    `json:"url,omitempty"`
+}
+
+func encodeObjs(objs []model.Obj) ([]byte, error) {
+	res := make([]cachedObj, len(objs))
+	for i, obj := range objs {
+		raw := obj
+		for {
+			unwrap, ok := raw.(model.ObjUnwrap)
+			if !ok {
+				break
+			}
+			raw = unwrap.Unwrap()
+		}
+		res[i] = cachedObj{
+			ID:       obj.GetID(),
+			Path:     obj.GetPath(),
+			Name:     raw.GetName(),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+			Ctime:    obj.CreateTime(),
+			IsFolder: obj.IsDir(),
+			Hash:     obj.GetHash().String(),
+		}
+		res[i].Thumb, _ = model.GetThumb(obj)
+		res[i].URL, _ = model.GetUrl(obj)
+	}
+	return json.Marshal(res)
+}
+
+func decodeObjs(data []byte) ([]model.Obj, error) {
+	var cached []cachedObj
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	objs := make([]model.Obj, len(cached))
+	for i, c := range cached {
+		o := model.Object{
+			ID:       c.ID,
+			Path:     c.Path,
+			Name:     c.Name,
+			Size:     c.Size,
+			Modified: c.Modified,
+			Ctime:    c.Ctime,
+			IsFolder: c.IsFolder,
+		}
+		if c.Hash != "" {
+			o.HashInfo = utils.FromString(c.Hash)
+		}
+		var obj model.Obj
+		switch {
+		case c.Thumb != "" && c.URL != "":
+			obj = &model.ObjThumbURL{Object: o, Thumbnail: model.Thumbnail{Thumbnail: c.Thumb}, Url: model.Url{Url: c.URL}}
+		case c.Thumb != "":
+			obj = &model.ObjThumb{Object: o, Thumbnail: model.Thumbnail{Thumbnail: c.Thumb}}
+		case c.URL != "":
+			obj = &model.ObjectURL{Object: o, Url: model.Url{Url: c.URL}}
+		default:
+			obj = &o
+		}
+		objs[i] = model.WrapObjName(obj)
+	}
+	return objs, nil
+}
+
+func encodeLink(link *model.Link) ([]byte, error) {
+	if link.MFile != nil || link.RangeReadCloser != nil {
+		return nil, errors.New("only the link with url can be encoded")
+	}
+	return json.Marshal(link)
+}
+
+func decodeLink(data []byte) (*model.Link, error) {
+	var link model.Link
+	if err := json.Unmarshal(data, &link); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &link, nil
+}
@@ -0,0 +1,39 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cache"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// the details are asked for each PROPFIND of the webdav clients, so they're cached shortly
+var detailsCache = cache.New[*model.StorageDetails]("details", nil)
+
+const detailsExpiration = 30 * time.Second
+
+// GetStorageDetails returns the space of the storage, errs.NotImplement is returned
+// if the driver doesn't implement driver.WithDetails
+func GetStorageDetails(ctx context.Context, storage driver.Driver) (*model.StorageDetails, error) {
+	wd, ok := storage.(driver.WithDetails)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	key := storage.GetStorage().MountPath
+	if details, ok := detailsCache.Get(key); ok {
+		return details, nil
+	}
+	details, err := wd.GetDetails(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage details")
+	}
+	detailsCache.Set(key, details, detailsExpiration)
+	return details, nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -177,3 +179,124 @@ func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
 	}
 	return items
 }
+
+// ValidateStorage checks the storage against the config and the items of its driver, the defaults
+// of the empty items are filled in. The unknown fields of the addition and the values not in the
+// options of the selects are rejected. It's called by both the admin api and the cli before
+// CreateStorage and UpdateStorage
+func ValidateStorage(storage *model.Storage) error {
+	info, ok := driverInfoMap[storage.Driver]
+	if !ok {
+		return errors.Errorf("no driver named: %s", storage.Driver)
+	}
+	if !info.Config.LocalSort && (storage.OrderBy != "" || storage.OrderDirection != "") {
+		return errors.Errorf("driver %s doesn't support local sort", storage.Driver)
+	}
+	for _, item := range info.Common {
+		var value *string
+		switch item.Name {
+		case "webdav_policy":
+			value = &storage.WebdavPolicy
+		case "order_by":
+			value = &storage.OrderBy
+		case "order_direction":
+			value = &storage.OrderDirection
+		case "extract_folder":
+			value = &storage.ExtractFolder
+		default:
+			continue
+		}
+		if *value == "" && item.Required {
+			*value = item.Default
+		}
+		if err := checkOption(item, *value); err != nil {
+			return err
+		}
+	}
+
+	addition := make(map[string]interface{})
+	if storage.Addition != "" {
+		if err := utils.Json.UnmarshalFromString(storage.Addition, &addition); err != nil {
+			return errors.Wrap(err, "addition is not a json object")
+		}
+	}
+	for _, item := range info.Additional {
+		value, ok := addition[item.Name]
+		if (!ok || value == nil || value == "") && item.Default != "" {
+			value = defaultValue(item)
+			addition[item.Name] = value
+		}
+		// a required bool is false when it's missing, like the switch in the web
+		if item.Required && item.Type != conf.TypeBool && (value == nil || value == "") {
+			return errors.Errorf("%s is required", item.Name)
+		}
+		if s, ok := value.(string); ok {
+			if err := checkOption(item, s); err != nil {
+				return err
+			}
+		}
+	}
+	newAddition := driverMap[storage.Driver]().GetAddition()
+	fields := additionFields(reflect.TypeOf(newAddition))
+	for _, item := range info.Additional {
+		fields = append(fields, item.Name)
+	}
+	for name := range addition {
+		if !utils.SliceContains(fields, name) {
+			return errors.Errorf("unknown addition of driver %s: %s", storage.Driver, name)
+		}
+	}
+	data, err := utils.Json.MarshalToString(addition)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// the types are checked by the addition of the driver
+	if err := utils.Json.UnmarshalFromString(data, newAddition); err != nil {
+		return errors.Wrap(err, "invalid addition")
+	}
+	storage.Addition = data
+	return nil
+}
+
+func checkOption(item driver.Item, value string) error {
+	if item.Type != conf.TypeSelect || item.Options == "" || value == "" {
+		return nil
+	}
+	if !utils.SliceContains(strings.Split(item.Options, ","), value) {
+		return errors.Errorf("%s must be one of %s", item.Name, item.Options)
+	}
+	return nil
+}
+
+// defaultValue converts the default of the item to its type
+func defaultValue(item driver.Item) interface{} {
+	switch item.Type {
+	case conf.TypeString, conf.TypeSelect, conf.TypeText:
+		return item.Default
+	}
+	var v interface{}
+	if err := utils.Json.UnmarshalFromString(item.Default, &v); err != nil {
+		return item.Default
+	}
+	return v
+}
+
+// additionFields returns the json names of the fields of the addition, including the ignored ones
+// which are saved by the driver itself
+func additionFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Anonymous {
+			fields = append(fields, additionFields(field.Type)...)
+			continue
+		}
+		if name, ok := field.Tag.Lookup("json"); ok {
+			fields = append(fields, strings.Split(name, ",")[0])
+		}
+	}
+	return fields
+}
@@ -5,7 +5,6 @@ import (
 	stdpath "path"
 	"time"
 
-	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -18,12 +17,12 @@ import (
 
 // In order to facilitate adding some other things before and after file op
 
-var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
 var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
 	key := Key(storage, path)
-	objs, ok := listCache.Get(key)
+	c := listCacheOf(storage)
+	objs, ok := c.Get(key)
 	if ok {
 		for i, obj := range objs {
 			if obj.GetName() == oldObj.GetName() {
@@ -31,13 +30,14 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		c.Set(key, objs, cacheExpiration(storage))
 	}
 }
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 	key := Key(storage, path)
-	objs, ok := listCache.Get(key)
+	c := listCacheOf(storage)
+	objs, ok := c.Get(key)
 	if ok {
 		for i, oldObj := range objs {
 			if oldObj.GetName() == obj.GetName() {
@@ -45,7 +45,7 @@ func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		c.Set(key, objs, cacheExpiration(storage))
 	}
 }
 
@@ -53,7 +53,8 @@ var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 	key := Key(storage, path)
-	objs, ok := listCache.Get(key)
+	c := listCacheOf(storage)
+	objs, ok := c.Get(key)
 	if ok {
 		for i, obj := range objs {
 			if obj.GetName() == newObj.GetName() {
@@ -79,12 +80,13 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 			})
 		}
 
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		c.Set(key, objs, cacheExpiration(storage))
 	}
 }
 
 func ClearCache(storage driver.Driver, path string) {
-	objs, ok := listCache.Get(Key(storage, path))
+	c := listCacheOf(storage)
+	objs, ok := c.Get(Key(storage, path))
 	if ok {
 		for _, obj := range objs {
 			if obj.IsDir() {
@@ -92,7 +94,7 @@ func ClearCache(storage driver.Driver, path string) {
 			}
 		}
 	}
-	listCache.Del(Key(storage, path))
+	c.Del(Key(storage, path))
 }
 
 func Key(storage driver.Driver, path string) string {
@@ -107,8 +109,9 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	path = utils.FixAndCleanPath(path)
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
+	c := listCacheOf(storage)
 	if !utils.IsBool(refresh...) {
-		if files, ok := listCache.Get(key); ok {
+		if files, ok := c.Get(key); ok {
 			log.Debugf("use cache when list %s", path)
 			return files, nil
 		}
@@ -122,10 +125,12 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
+		start := time.Now()
 		files, err := storage.List(ctx, dir, args)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
+		recordLatencySince(storage, start)
 		// set path
 		for _, f := range files {
 			if s, ok := f.(model.SetPath); ok && f.GetPath() == "" && dir.GetPath() != "" {
@@ -150,10 +155,10 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
-				listCache.Set(key, files, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+				c.Set(key, files, cacheExpiration(storage))
 			} else {
 				log.Debugf("del cache: %s", key)
-				listCache.Del(key)
+				c.Del(key)
 			}
 		}
 		return files, nil
@@ -237,7 +242,6 @@ func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.O
 	return model.UnwrapObj(obj), err
 }
 
-var linkCache = cache.NewMemCache(cache.WithShards[*model.Link](16))
 var linkG singleflight.Group[*model.Link]
 
 // Link get link, if is an url. should have an expiry time
@@ -253,19 +257,22 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, nil, errors.WithStack(errs.NotFile)
 	}
 	key := Key(storage, path)
-	if link, ok := linkCache.Get(key); ok {
+	c := linkCacheOf(storage)
+	if link, ok := c.Get(key); ok {
 		return link, file, nil
 	}
 	fn := func() (*model.Link, error) {
+		start := time.Now()
 		link, err := storage.Link(ctx, file, args)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
+		recordLatencySince(storage, start)
 		if link.Expiration != nil {
 			if link.IPCacheKey {
 				key = key + ":" + args.IP
 			}
-			linkCache.Set(key, link, cache.WithEx[*model.Link](*link.Expiration))
+			c.Set(key, link, *link.Expiration)
 		}
 		return link, nil
 	}
@@ -347,6 +354,9 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 				default:
 					return nil, errs.NotImplement
 				}
+				if err == nil {
+					objChanged(storage, ObjCreate, path)
+				}
 				return nil, errors.WithStack(err)
 			}
 			return nil, errors.WithMessage(err, "failed to check if dir exists")
@@ -401,6 +411,9 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		objChanged(storage, ObjMove, stdpath.Join(dstDirPath, srcRawObj.GetName()), srcPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -435,6 +448,9 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		objChanged(storage, ObjMove, stdpath.Join(srcDirPath, dstName), srcPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -473,6 +489,9 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		objChanged(storage, ObjCopy, stdpath.Join(dstDirPath, srcObj.GetName()), srcPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -501,6 +520,7 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 			if rawObj.IsDir() {
 				ClearCache(storage, path)
 			}
+			objChanged(storage, ObjRemove, path)
 		}
 	default:
 		return errs.NotImplement
@@ -553,26 +573,38 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		up = func(p float64) {}
 	}
 
-	switch s := storage.(type) {
-	case driver.PutResult:
-		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
-		if err == nil {
-			if newObj != nil {
-				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
-			} else if !utils.IsBool(lazyCache...) {
-				ClearCache(storage, dstDirPath)
-			}
-		}
-	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
+	// the staged parts are uploaded as the chunks by the drivers supporting it
+	parts, isParts := file.(model.FileParts)
+	if s, ok := storage.(driver.PutParts); ok && isParts {
+		err = s.PutParts(ctx, parentDir, file, parts.GetParts(), up)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
-	default:
-		return errs.NotImplement
+	} else {
+		switch s := storage.(type) {
+		case driver.PutResult:
+			var newObj model.Obj
+			newObj, err = s.Put(ctx, parentDir, file, up)
+			if err == nil {
+				if newObj != nil {
+					addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
+				} else if !utils.IsBool(lazyCache...) {
+					ClearCache(storage, dstDirPath)
+				}
+			}
+		case driver.Put:
+			err = s.Put(ctx, parentDir, file, up)
+			if err == nil && !utils.IsBool(lazyCache...) {
+				ClearCache(storage, dstDirPath)
+			}
+		default:
+			return errs.NotImplement
+		}
 	}
 	log.Debugf("put file [%s] done", file.GetName())
+	if err == nil {
+		objChanged(storage, ObjCreate, dstPath)
+	}
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
@@ -587,7 +619,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 				return err
 			} else {
 				key := Key(storage, stdpath.Join(dstDirPath, file.GetName()))
-				linkCache.Del(key)
+				linkCacheOf(storage).Del(key)
 			}
 		}
 	}
@@ -29,6 +29,54 @@ func HandleObjsUpdateHook(parent string, objs []model.Obj) {
 	}
 }
 
+type ObjChangeType int
+
+const (
+	// ObjCreate means the obj at Path is created or overwritten
+	ObjCreate ObjChangeType = iota
+	// ObjRemove means the obj at Path is removed
+	ObjRemove
+	// ObjMove means the obj at OldPath is moved or renamed to Path
+	ObjMove
+	// ObjCopy means the obj at OldPath is copied to Path
+	ObjCopy
+)
+
+// ObjChange is a change of obj made through alist or found by the driver,
+// the paths are full paths which start with the mount path
+type ObjChange struct {
+	Type    ObjChangeType
+	Path    string
+	OldPath string
+}
+
+type ObjChangeHook = func(change ObjChange)
+
+var (
+	objChangeHooks = make([]ObjChangeHook, 0)
+)
+
+func RegisterObjChangeHook(hook ObjChangeHook) {
+	objChangeHooks = append(objChangeHooks, hook)
+}
+
+func HandleObjChangeHook(change ObjChange) {
+	for _, hook := range objChangeHooks {
+		hook(change)
+	}
+}
+
+func objChanged(storage driver.Driver, typ ObjChangeType, path string, oldPath ...string) {
+	change := ObjChange{
+		Type: typ,
+		Path: utils.GetFullPath(storage.GetStorage().MountPath, path),
+	}
+	if len(oldPath) > 0 {
+		change.OldPath = utils.GetFullPath(storage.GetStorage().MountPath, oldPath[0])
+	}
+	HandleObjChangeHook(change)
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
@@ -99,6 +147,21 @@ func HandleSettingItemHook(item *model.SettingItem) (hasHook bool, err error) {
 	return false, nil
 }
 
+// S3KeysDeleteHook is called after the s3 keys are deleted
+type S3KeysDeleteHook func()
+
+var s3KeysDeleteHooks = make([]S3KeysDeleteHook, 0)
+
+func RegisterS3KeysDeleteHook(hook S3KeysDeleteHook) {
+	s3KeysDeleteHooks = append(s3KeysDeleteHooks, hook)
+}
+
+func HandleS3KeysDeleteHook() {
+	for _, hook := range s3KeysDeleteHooks {
+		hook()
+	}
+}
+
 // Storage
 type StorageHook func(typ string, storage driver.Driver)
 
@@ -2,6 +2,7 @@ package op
 
 import (
 	stdpath "path"
+	"strings"
 	"time"
 
 	"github.com/Xhofe/go-cache"
@@ -10,6 +11,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/dlclark/regexp2"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
@@ -62,6 +64,16 @@ func getMetaByPath(path string) (*model.Meta, error) {
 	return meta, err
 }
 
+// CheckHide compiles the regexps in the lines of hide, the illegal one is returned with the error
+func CheckHide(hide string) (string, error) {
+	for _, r := range strings.Split(hide, "\n") {
+		if _, err := regexp2.Compile(r, regexp2.None); err != nil {
+			return r, err
+		}
+	}
+	return "", nil
+}
+
 func DeleteMetaById(id uint) error {
 	old, err := db.GetMetaById(id)
 	if err != nil {
@@ -94,3 +106,8 @@ func GetMetaById(id uint) (*model.Meta, error) {
 func GetMetas(pageIndex, pageSize int) (metas []model.Meta, count int64, err error) {
 	return db.GetMetas(pageIndex, pageSize)
 }
+
+// ClearMetaCache drops all the cached metas, used after the metas are changed in database directly
+func ClearMetaCache() {
+	metaCache.Clear()
+}
@@ -1,9 +1,11 @@
 package op
 
 import (
-	"github.com/alist-org/alist/v3/internal/errs"
+	"context"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/errs"
+
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -12,8 +14,22 @@ import (
 // GetStorageAndActualPath Get the corresponding storage and actual path
 // for path: remove the mount path prefix and join the actual root folder if exists
 func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath string, err error) {
+	return getStorageAndActualPath(rawPath, "")
+}
+
+// GetStorageAndActualPathFor is GetStorageAndActualPath for the request of ctx,
+// the storage is balanced with the client of the request and taken as active until the request is done
+func GetStorageAndActualPathFor(ctx context.Context, rawPath string) (storage driver.Driver, actualPath string, err error) {
+	storage, actualPath, err = getStorageAndActualPath(rawPath, clientIP(ctx))
+	if err == nil {
+		trackActive(ctx, storage)
+	}
+	return
+}
+
+func getStorageAndActualPath(rawPath, clientIP string) (storage driver.Driver, actualPath string, err error) {
 	rawPath = utils.FixAndCleanPath(rawPath)
-	storage = GetBalancedStorage(rawPath)
+	storage = getBalancedStorage(rawPath, clientIP)
 	if storage == nil {
 		if rawPath == "/" {
 			err = errs.NewErr(errs.StorageNotFound, "please add a storage first")
@@ -0,0 +1,71 @@
+package op
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+const accessKeyIdLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// CreateS3Key generates an access key pair for the user
+func CreateS3Key(userId uint, name string) (*model.S3Key, error) {
+	id := []byte("AK")
+	for len(id) < 20 {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(accessKeyIdLetters))))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		id = append(id, accessKeyIdLetters[n.Int64()])
+	}
+	secret := make([]byte, 30)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	k := &model.S3Key{
+		UserId:          userId,
+		Name:            name,
+		AccessKeyId:     string(id),
+		SecretAccessKey: base64.RawURLEncoding.EncodeToString(secret),
+		Created:         time.Now(),
+	}
+	if err := db.CreateS3Key(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func GetS3KeysByUserId(userId uint, pageIndex, pageSize int) ([]model.S3Key, int64, error) {
+	return db.GetS3KeysByUserId(userId, pageIndex, pageSize)
+}
+
+func GetS3KeyByAccessKeyId(accessKeyId string) (*model.S3Key, error) {
+	return db.GetS3KeyByAccessKeyId(accessKeyId)
+}
+
+// DeleteS3Key deletes the key of the user, the key of any user is deleted if userId is 0
+func DeleteS3Key(id, userId uint) error {
+	k, err := db.GetS3KeyById(id)
+	if err != nil {
+		return err
+	}
+	if userId != 0 && k.UserId != userId {
+		return errors.Wrapf(errs.ObjectNotFound, "failed get s3 key")
+	}
+	if err := db.DeleteS3KeyById(id); err != nil {
+		return err
+	}
+	HandleS3KeysDeleteHook()
+	return nil
+}
+
+// GetAllS3Keys returns the keys of all users
+func GetAllS3Keys() ([]model.S3Key, error) {
+	return db.GetAllS3Keys()
+}
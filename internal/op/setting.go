@@ -196,3 +196,15 @@ func DeleteSettingItemByKey(key string) error {
 	settingCacheUpdate()
 	return db.DeleteSettingItemByKey(key)
 }
+
+// ReloadSettingItems calls the hooks of the items saved in database directly and drops the cached settings
+func ReloadSettingItems(items []model.SettingItem) error {
+	errs := make([]error, 0)
+	for i := range items {
+		if _, err := HandleSettingItemHook(&items[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	settingCacheUpdate()
+	return utils.MergeErrors(errs...)
+}
@@ -0,0 +1,87 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateShare(s *model.Share) error {
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.Created = time.Now()
+	s.Accessed, s.Downloads = 0, 0
+	for i := 0; ; i++ {
+		s.ID = random.String(8)
+		if _, err := db.GetShareById(s.ID); err != nil {
+			break
+		}
+		if i >= 10 {
+			return errors.New("failed generate share id")
+		}
+	}
+	return db.CreateShare(s)
+}
+
+func GetShareById(id string) (*model.Share, error) {
+	return db.GetShareById(id)
+}
+
+func GetShares(userId uint, pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	return db.GetShares(userId, pageIndex, pageSize)
+}
+
+// UpdateShare updates the editable fields of the share, the counters are kept
+func UpdateShare(s *model.Share) error {
+	old, err := db.GetShareById(s.ID)
+	if err != nil {
+		return err
+	}
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.UserId = old.UserId
+	s.Created = old.Created
+	s.Accessed = old.Accessed
+	s.Downloads = old.Downloads
+	return db.UpdateShare(s)
+}
+
+func DeleteShareById(id string) error {
+	return db.DeleteShareById(id)
+}
+
+// GetValidShare returns the share and its owner if the share can still be accessed
+func GetValidShare(id string) (*model.Share, *model.User, error) {
+	s, err := db.GetShareById(id)
+	if err != nil {
+		return nil, nil, errors.WithStack(errs.ShareNotFound)
+	}
+	if s.IsExpired() {
+		return nil, nil, errors.WithStack(errs.ShareExpired)
+	}
+	user, err := GetUserById(s.UserId)
+	if err != nil || user.Disabled || !user.CanShare() {
+		return nil, nil, errors.WithStack(errs.ShareNotFound)
+	}
+	return s, user, nil
+}
+
+func IncreaseShareAccessed(id string) error {
+	return db.IncreaseShareAccessed(id)
+}
+
+// IncreaseShareDownloads counts a download of the share,
+// errs.ShareExhausted is returned if the max downloads has been reached
+func IncreaseShareDownloads(id string) error {
+	if err := db.IncreaseShareDownloads(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.WithStack(errs.ShareExhausted)
+		}
+		return err
+	}
+	return nil
+}
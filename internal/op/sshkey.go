@@ -0,0 +1,70 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// CreateSSHPublicKey parses the authorized_keys formatted KeyStr of k and saves it,
+// the comment of the key is used as title if title is empty
+func CreateSSHPublicKey(k *model.SSHPublicKey) error {
+	pubKey, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(k.KeyStr))
+	if err != nil {
+		return errors.WithStack(errs.InvalidSSHKey)
+	}
+	k.Fingerprint = ssh.FingerprintSHA256(pubKey)
+	if _, err := db.GetSSHPublicKeyByFingerprint(k.Fingerprint); err == nil {
+		return errors.WithStack(errs.DuplicateSSHKey)
+	}
+	k.KeyStr = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey)))
+	if k.Title == "" {
+		k.Title = comment
+	}
+	k.AddedTime = time.Now()
+	k.LastUsedTime = k.AddedTime
+	return db.CreateSSHPublicKey(k)
+}
+
+func GetSSHPublicKeyByUserId(userId uint, pageIndex, pageSize int) (keys []model.SSHPublicKey, count int64, err error) {
+	return db.GetSSHPublicKeyByUserId(userId, pageIndex, pageSize)
+}
+
+func GetSSHPublicKeyByIdAndUserId(id uint, userId uint) (*model.SSHPublicKey, error) {
+	key, err := db.GetSSHPublicKeyById(id)
+	if err != nil {
+		return nil, err
+	}
+	if key.UserId != userId {
+		return nil, errors.Wrapf(errs.ObjectNotFound, "failed get old key")
+	}
+	return key, nil
+}
+
+// GetUserBySSHPublicKey returns the owner of pubKey, the client may not own the private key yet
+func GetUserBySSHPublicKey(pubKey ssh.PublicKey) (*model.User, error) {
+	key, err := db.GetSSHPublicKeyByFingerprint(ssh.FingerprintSHA256(pubKey))
+	if err != nil {
+		return nil, err
+	}
+	return GetUserById(key.UserId)
+}
+
+// UpdateSSHPublicKeyLastUsed updates the last used time of the key after the authentication succeeded
+func UpdateSSHPublicKeyLastUsed(fingerprint string) error {
+	key, err := db.GetSSHPublicKeyByFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+	key.LastUsedTime = time.Now()
+	return db.UpdateSSHPublicKey(key)
+}
+
+func DeleteSSHPublicKeyById(id uint) error {
+	return db.DeleteSSHPublicKeyById(id)
+}
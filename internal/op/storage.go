@@ -46,6 +46,9 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
 	var err error
+	if err = checkBalanceStrategy(storage.BalanceStrategy); err != nil {
+		return 0, err
+	}
 	// check driver first
 	driverName := storage.Driver
 	driverNew, err := GetDriver(driverName)
@@ -97,6 +100,8 @@ func CopyStorageById(ctx context.Context, id uint) (uint, error) {
 
 	// check driver first
 	new_storage.MountPath = storage.MountPath + "_copyed2"
+	// the copy is not in the storages file
+	new_storage.Managed = false
 	new_storage.Modified = time.Now()
 	new_storage.MountPath = utils.FixAndCleanPath(new_storage.MountPath)
 	driverName := new_storage.Driver
@@ -206,6 +211,20 @@ func DisableStorage(ctx context.Context, id uint) error {
 	return nil
 }
 
+// UnloadStorage drops the loaded storage and removes it from the memory, the storage in database is kept
+func UnloadStorage(ctx context.Context, mountPath string) error {
+	storageDriver, err := GetStorageByMountPath(mountPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage driver")
+	}
+	if err := storageDriver.Drop(ctx); err != nil {
+		return errors.Wrap(err, "failed drop storage")
+	}
+	storagesMap.Delete(mountPath)
+	go callStorageHooks("del", storageDriver)
+	return nil
+}
+
 // UpdateStorage update storage
 // get old storage first
 // drop the storage then reinitialize
@@ -217,6 +236,9 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if oldStorage.Driver != storage.Driver {
 		return errors.Errorf("driver cannot be changed")
 	}
+	if err := checkBalanceStrategy(storage.BalanceStrategy); err != nil {
+		return err
+	}
 
 	if storage.SyncGroup {
 		storage.Modified = time.Now()
@@ -450,23 +472,29 @@ func GetStorageVirtualFilesByPath(prefix string) []model.Obj {
 	return files
 }
 
-var balanceMap generic_sync.MapOf[string, int]
-
-// GetBalancedStorage get storage by path
+// GetBalancedStorage get storage by path, the storages which don't work are skipped
+// unless none of them works, the unhealthy storages don't work either
 func GetBalancedStorage(path string) driver.Driver {
+	return getBalancedStorage(path, "")
+}
+
+func getBalancedStorage(path, clientIP string) driver.Driver {
 	path = utils.FixAndCleanPath(path)
 	storages := getStoragesByPath(path)
-	storageNum := len(storages)
-	switch storageNum {
+	if len(storages) > 1 {
+		working := utils.SliceFilter(storages, func(s driver.Driver) bool {
+			return s.GetStorage().Status == WORK
+		})
+		if len(working) > 0 {
+			storages = working
+		}
+	}
+	switch len(storages) {
 	case 0:
 		return nil
 	case 1:
 		return storages[0]
 	default:
-		virtualPath := utils.GetActualMountPath(storages[0].GetStorage().MountPath)
-		i, _ := balanceMap.LoadOrStore(virtualPath, 0)
-		i = (i + 1) % storageNum
-		balanceMap.Store(virtualPath, i)
-		return storages[i]
+		return balance(storages, clientIP)
 	}
 }
@@ -82,6 +82,22 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err := db.DeleteSSHPublicKeyByUserId(id); err != nil {
+		return err
+	}
+	if err := db.DeleteSharesByUserId(id); err != nil {
+		return err
+	}
+	if err := db.DeleteUsagesByUserId(id); err != nil {
+		return err
+	}
+	if err := db.DeleteApiTokensByUserId(id); err != nil {
+		return err
+	}
+	if err := db.DeleteS3KeysByUserId(id); err != nil {
+		return err
+	}
+	HandleS3KeysDeleteHook()
 	return db.DeleteUserById(id)
 }
 
@@ -128,3 +144,10 @@ func DelUserCache(username string) error {
 	userCache.Del(username)
 	return nil
 }
+
+// ClearUserCache drops all the cached users, used after the users are changed in database directly
+func ClearUserCache() {
+	adminUser = nil
+	guestUser = nil
+	userCache.Clear()
+}
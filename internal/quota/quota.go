@@ -0,0 +1,151 @@
+// Package quota tracks the bytes and files which users upload to each storage and enforces the quotas.
+// The usage is counted for the user who does the operation, which is taken from the "user" value of ctx,
+// operations without a user such as sync jobs are not counted. The usage is always counted, even without quotas,
+// so that it's right when a quota is set later.
+package quota
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func userOf(ctx context.Context) *model.User {
+	user, _ := ctx.Value("user").(*model.User)
+	return user
+}
+
+// Check returns an error if the user of ctx can't put files of size bytes into dirPath,
+// it's only a hint before the upload starts, the usage is reserved by Reserve
+func Check(ctx context.Context, dirPath string, size int64, files int64) error {
+	user := userOf(ctx)
+	if user == nil {
+		return nil
+	}
+	if user.QuotaBytes > 0 || user.QuotaFiles > 0 {
+		usedBytes, usedFiles, err := db.GetUsage(user.ID)
+		if err != nil {
+			return err
+		}
+		if user.QuotaBytes > 0 && usedBytes+size > user.QuotaBytes {
+			return errors.Wrapf(errs.QuotaExceeded, "used %d of %d bytes, %d more bytes can't be uploaded",
+				usedBytes, user.QuotaBytes, size)
+		}
+		if user.QuotaFiles > 0 && usedFiles+files > user.QuotaFiles {
+			return errors.Wrapf(errs.QuotaExceeded, "used %d of %d files", usedFiles, user.QuotaFiles)
+		}
+	}
+	storage, _, err := op.GetStorageAndActualPath(dirPath)
+	if err != nil || storage.GetStorage().QuotaBytes <= 0 {
+		return nil
+	}
+	used, err := db.GetMountUsage(storage.GetStorage().MountPath)
+	if err != nil {
+		return err
+	}
+	if used+size > storage.GetStorage().QuotaBytes {
+		return errors.Wrapf(errs.StorageQuotaExceeded, "used %d of %d bytes, %d more bytes can't be uploaded",
+			used, storage.GetStorage().QuotaBytes, size)
+	}
+	return nil
+}
+
+// Reserve checks the quotas and adds bytes and files to the usage of the user of ctx in the storage of path at once,
+// so concurrent uploads can't exceed the quotas. The usage should be given back by Add if the upload fails.
+func Reserve(ctx context.Context, path string, bytes, files int64) error {
+	user := userOf(ctx)
+	if user == nil || bytes == 0 && files == 0 {
+		return nil
+	}
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil
+	}
+	return db.ReserveUsage(user.ID, storage.GetStorage().MountPath, bytes, files,
+		user.QuotaBytes, user.QuotaFiles, storage.GetStorage().QuotaBytes)
+}
+
+// Add adds bytes and files to the usage of the user of ctx in the storage of path
+func Add(ctx context.Context, path string, bytes, files int64) {
+	user := userOf(ctx)
+	if user == nil || bytes == 0 && files == 0 {
+		return
+	}
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return
+	}
+	if err := db.AddUsage(user.ID, storage.GetStorage().MountPath, bytes, files); err != nil {
+		log.Errorf("failed update usage of %s: %+v", user.Username, err)
+	}
+}
+
+// Stat returns the bytes and count of files of the object at path, the dirs are walked recursively.
+// It returns 0 if ctx has no user or the object doesn't exist.
+func Stat(ctx context.Context, path string) (bytes, files int64) {
+	if userOf(ctx) == nil {
+		return 0, 0
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return 0, 0
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		return 0, 0
+	}
+	if !obj.IsDir() {
+		return obj.GetSize(), 1
+	}
+	return walk(ctx, storage, actualPath)
+}
+
+func walk(ctx context.Context, storage driver.Driver, dir string) (bytes, files int64) {
+	objs, err := op.List(ctx, storage, dir, model.ListArgs{})
+	if err != nil {
+		return 0, 0
+	}
+	for _, obj := range objs {
+		if obj.IsDir() {
+			b, f := walk(ctx, storage, stdpath.Join(dir, obj.GetName()))
+			bytes, files = bytes+b, files+f
+		} else {
+			bytes, files = bytes+obj.GetSize(), files+1
+		}
+	}
+	return bytes, files
+}
+
+// WithUser returns a context with the user of userId, it's used for the tasks which only keep the id of the user
+func WithUser(ctx context.Context, userId uint) context.Context {
+	if userId == 0 {
+		return ctx
+	}
+	user, err := op.GetUserById(userId)
+	if err != nil {
+		log.Warnf("failed get user %d for counting the usage: %+v", userId, err)
+		return ctx
+	}
+	return context.WithValue(ctx, "user", user)
+}
+
+// UserId returns the id of the user of ctx, or 0 if there's no user
+func UserId(ctx context.Context) uint {
+	if user := userOf(ctx); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// Detach returns a context which only keeps the user of ctx,
+// it's used for the tasks which run after the request is finished
+func Detach(ctx context.Context) context.Context {
+	return context.WithValue(context.Background(), "user", userOf(ctx))
+}
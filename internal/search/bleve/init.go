@@ -4,11 +4,13 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/search/searcher"
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
 	log "github.com/sirupsen/logrus"
 )
 
 var config = searcher.Config{
-	Name: "bleve",
+	Name:    "bleve",
+	Content: true,
 }
 
 func Init(indexPath *string) (bleve.Index, error) {
@@ -23,8 +25,19 @@ func Init(indexPath *string) (bleve.Index, error) {
 		parentFieldMapping := bleve.NewTextFieldMapping()
 		searchNodeMapping.AddFieldMappingsAt("parent", parentFieldMapping)
 		// TODO: appoint analyzer
-		nameFieldMapping := bleve.NewKeywordFieldMapping()
-		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+		nameFieldMapping := bleve.NewTextFieldMapping()
+		// the whole name is kept in name_exact to match patterns and sort
+		nameExactFieldMapping := bleve.NewKeywordFieldMapping()
+		nameExactFieldMapping.Name = "name_exact"
+		nameExactFieldMapping.Store = false
+		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping, nameExactFieldMapping)
+		searchNodeMapping.AddFieldMappingsAt("size", bleve.NewNumericFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("modified", bleve.NewDateTimeFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("file_type", bleve.NewNumericFieldMapping())
+		// the content is stored with the term vectors to highlight the snippets
+		contentFieldMapping := bleve.NewTextFieldMapping()
+		contentFieldMapping.IncludeTermVectors = true
+		searchNodeMapping.AddFieldMappingsAt("content", contentFieldMapping)
 		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
 		fileIndex, err = bleve.New(*indexPath, indexMapping)
 		if err != nil {
@@ -32,16 +45,45 @@ func Init(indexPath *string) (bleve.Index, error) {
 		}
 	} else if err != nil {
 		return nil, err
+	} else if !hasNameExact(fileIndex) {
+		log.Warnf("the bleve index at %s has no name_exact field, rebuild it to match the names by patterns", *indexPath)
 	}
 	return fileIndex, nil
 }
 
+// hasNameExact reports whether the index is built with the name_exact field,
+// which the indexes built by older versions don't have
+func hasNameExact(index bleve.Index) bool {
+	m, ok := index.Mapping().(*mapping.IndexMappingImpl)
+	if !ok {
+		return false
+	}
+	node, ok := m.TypeMapping["SearchNode"]
+	if !ok {
+		return false
+	}
+	name, ok := node.Properties["name"]
+	if !ok {
+		return false
+	}
+	for _, field := range name.Fields {
+		if field.Name == "name_exact" {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
-	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
-		b, err := Init(&conf.Conf.BleveDir)
+	searcher.RegisterSearcher(config, func(name string) (searcher.Searcher, error) {
+		indexPath := conf.Conf.BleveDir
+		if name != "" {
+			indexPath += "_" + name
+		}
+		b, err := Init(&indexPath)
 		if err != nil {
 			return nil, err
 		}
-		return &Bleve{BIndex: b}, nil
+		return &Bleve{BIndex: b, Path: indexPath, outdated: !hasNameExact(b)}, nil
 	})
 }
@@ -3,10 +3,12 @@ package bleve
 import (
 	"context"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
-	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/search/searcher"
@@ -19,6 +21,9 @@ import (
 
 type Bleve struct {
 	BIndex bleve.Index
+	Path   string
+	// outdated is true if the index has no name_exact field, it's fixed by rebuilding the index
+	outdated bool
 }
 
 func (b *Bleve) Config() searcher.Config {
@@ -26,45 +31,172 @@ func (b *Bleve) Config() searcher.Config {
 }
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	if b.outdated && (req.NameMatch == model.NameMatchRegex || req.NameMatch == model.NameMatchGlob || len(req.Extensions) > 0) {
+		return nil, 0, errs.SearchIndexOutdated
+	}
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	switch {
+	case req.InContent:
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("content")
+		query.SetOperator(query2.MatchQueryOperatorAnd)
+		queries = append(queries, query)
+	case req.NameMatch == model.NameMatchRegex:
+		query := bleve.NewRegexpQuery(req.Keywords)
+		query.SetField("name_exact")
+		queries = append(queries, query)
+	case req.NameMatch == model.NameMatchGlob:
+		query := bleve.NewRegexpQuery("(?i)" + globToRegexp(req.Keywords))
+		query.SetField("name_exact")
+		queries = append(queries, query)
+	default:
+		if req.Keywords == "" {
+			queries = append(queries, bleve.NewMatchAllQuery())
+			break
+		}
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("name")
+		queries = append(queries, query)
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
+		isDirQuery.SetField("is_dir")
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(req)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	sortBy := "name_exact"
+	if req.OrderBy != "" && req.OrderBy != "name" {
+		sortBy = req.OrderBy
+	}
+	if req.OrderDirection == "desc" {
+		sortBy = "-" + sortBy
+	}
+	// the best matched content comes first by default,
+	// and the outdated index can't be sorted by the names
+	if req.InContent && req.OrderBy == "" || b.outdated && strings.HasSuffix(sortBy, "name_exact") {
+		sortBy = "-_score"
+	}
+	search.SortBy([]string{sortBy})
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
-	search.Fields = []string{"*"}
+	// the content is only used to highlight
+	search.Fields = []string{"parent", "name", "is_dir", "size", "modified", "file_type"}
+	if req.InContent {
+		search.Highlight = bleve.NewHighlightWithStyle("html")
+		search.Highlight.AddField("content")
+	}
 	searchResults, err := b.BIndex.Search(search)
 	if err != nil {
 		log.Errorf("search error: %+v", err)
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
+		node := model.SearchNode{
 			Parent: src.Fields["parent"].(string),
 			Name:   src.Fields["name"].(string),
 			IsDir:  src.Fields["is_dir"].(bool),
 			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
+		}
+		// the nodes indexed by older versions have no modified and file_type
+		if modified, ok := src.Fields["modified"].(string); ok {
+			node.Modified, _ = time.Parse(time.RFC3339, modified)
+		}
+		if fileType, ok := src.Fields["file_type"].(float64); ok {
+			node.FileType = int(fileType)
+		}
+		node.Snippet = strings.Join(src.Fragments["content"], " … ")
+		return node, nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
+func filterQueries(req model.SearchReq) []query2.Query {
+	var queries []query2.Query
+	inclusive := true
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		var min, max *float64
+		if req.MinSize > 0 {
+			minSize := float64(req.MinSize)
+			min = &minSize
+		}
+		if req.MaxSize > 0 {
+			maxSize := float64(req.MaxSize)
+			max = &maxSize
+		}
+		query := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		query.SetField("size")
+		queries = append(queries, query)
+	}
+	if !req.ModifiedAfter.IsZero() || !req.ModifiedBefore.IsZero() {
+		query := bleve.NewDateRangeInclusiveQuery(req.ModifiedAfter, req.ModifiedBefore, &inclusive, &inclusive)
+		query.SetField("modified")
+		queries = append(queries, query)
+	}
+	if len(req.FileTypes) > 0 {
+		var typeQueries []query2.Query
+		for _, t := range req.FileTypes {
+			v := float64(t)
+			query := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			query.SetField("file_type")
+			typeQueries = append(typeQueries, query)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(typeQueries...))
+	}
+	if len(req.Extensions) > 0 {
+		var extQueries []query2.Query
+		for _, ext := range req.Extensions {
+			query := bleve.NewRegexpQuery(`(?i).*\.` + regexp.QuoteMeta(ext))
+			query.SetField("name_exact")
+			extQueries = append(extQueries, query)
+		}
+		isDirQuery := bleve.NewBoolFieldQuery(false)
+		isDirQuery.SetField("is_dir")
+		queries = append(queries, isDirQuery, bleve.NewDisjunctionQuery(extQueries...))
+	}
+	return queries
+}
+
+// globToRegexp converts a glob pattern of path.Match to a regular expression
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	inClass := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+			sb.WriteByte(c)
+		case c == '[':
+			inClass = true
+			sb.WriteByte(c)
+		case c == '*':
+			sb.WriteString(".*")
+		case c == '?':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
-	return b.BIndex.Index(uuid.NewString(), node)
+	// the node is indexed by pointer so that the SearchNode mapping is applied
+	return b.BIndex.Index(uuid.NewString(), &node)
 }
 
 func (b *Bleve) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
 	batch := b.BIndex.NewBatch()
-	for _, node := range nodes {
-		batch.Index(uuid.NewString(), node)
+	for i := range nodes {
+		batch.Index(uuid.NewString(), &nodes[i])
 	}
 	return b.BIndex.Batch(batch)
 }
@@ -90,15 +222,16 @@ func (b *Bleve) Clear(ctx context.Context) error {
 		return err
 	}
 	log.Infof("Removing old index...")
-	err = os.RemoveAll(conf.Conf.BleveDir)
+	err = os.RemoveAll(b.Path)
 	if err != nil {
 		log.Errorf("clear bleve error: %+v", err)
 	}
-	bIndex, err := Init(&conf.Conf.BleveDir)
+	bIndex, err := Init(&b.Path)
 	if err != nil {
 		return err
 	}
 	b.BIndex = bIndex
+	b.outdated = false
 	return nil
 }
 
@@ -8,33 +8,33 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/search/searcher"
-	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/mq"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	mapset "github.com/deckarep/golang-set/v2"
 	log "github.com/sirupsen/logrus"
 )
 
-var (
-	Running = atomic.Bool{}
-	Quit    chan struct{}
-)
+// Running is set while the default index is building
+var Running = atomic.Bool{}
 
 func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth int, count bool) error {
+	return defaultIndex.Build(ctx, indexPaths, ignorePaths, maxDepth, count)
+}
+
+func (i *Instance) Build(ctx context.Context, indexPaths, ignorePaths []string, maxDepth int, count bool) error {
 	var (
 		err      error
 		objCount uint64 = 0
 		fi       model.Obj
 	)
-	log.Infof("build index for: %+v", indexPaths)
+	log.Infof("build index [%s] for: %+v", i.Name(), indexPaths)
 	log.Infof("ignore paths: %+v", ignorePaths)
-	Running.Store(true)
-	Quit = make(chan struct{}, 1)
+	i.running.Store(true)
+	i.quit = make(chan struct{}, 1)
 	indexMQ := mq.NewInMemoryMQ[ObjWithParent]()
 	go func() {
 		ticker := time.NewTicker(time.Second)
@@ -53,7 +53,7 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 					if len(messages) != 0 {
 						log.Debugf("current index: %s", messages[len(messages)-1].Content.Parent)
 					}
-					if err = BatchIndex(ctx, utils.MustSliceConvert(messages,
+					if err = i.BatchIndex(ctx, utils.MustSliceConvert(messages,
 						func(src mq.Message[ObjWithParent]) ObjWithParent {
 							return src.Content
 						})); err != nil {
@@ -62,7 +62,7 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 						objCount = objCount + uint64(len(messages))
 					}
 					if count {
-						WriteProgress(&model.IndexProgress{
+						i.WriteProgress(&model.IndexProgress{
 							ObjCount:     objCount,
 							IsDone:       false,
 							LastDoneTime: nil,
@@ -70,14 +70,14 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 					}
 				})
 
-			case <-Quit:
-				Running.Store(false)
+			case <-i.quit:
+				i.running.Store(false)
 				ticker.Stop()
 				eMsg := ""
 				now := time.Now()
 				originErr := err
 				indexMQ.ConsumeAll(func(messages []mq.Message[ObjWithParent]) {
-					if err = BatchIndex(ctx, utils.MustSliceConvert(messages,
+					if err = i.BatchIndex(ctx, utils.MustSliceConvert(messages,
 						func(src mq.Message[ObjWithParent]) ObjWithParent {
 							return src.Content
 						})); err != nil {
@@ -92,7 +92,7 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 						log.Infof("success build index, count: %d", objCount)
 					}
 					if count {
-						WriteProgress(&model.IndexProgress{
+						i.WriteProgress(&model.IndexProgress{
 							ObjCount:     objCount,
 							IsDone:       true,
 							LastDoneTime: &now,
@@ -105,8 +105,8 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 		}
 	}()
 	defer func() {
-		if Running.Load() {
-			Quit <- struct{}{}
+		if i.running.Load() {
+			i.quit <- struct{}{}
 		}
 	}()
 	admin, err := op.GetAdmin()
@@ -114,14 +114,14 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 		return err
 	}
 	if count {
-		WriteProgress(&model.IndexProgress{
+		i.WriteProgress(&model.IndexProgress{
 			ObjCount: 0,
 			IsDone:   false,
 		})
 	}
 	for _, indexPath := range indexPaths {
 		walkFn := func(indexPath string, info model.Obj) error {
-			if !Running.Load() {
+			if !i.running.Load() {
 				return filepath.SkipDir
 			}
 			for _, avoidPath := range ignorePaths {
@@ -155,76 +155,67 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 }
 
 func Del(ctx context.Context, prefix string) error {
-	return instance.Del(ctx, prefix)
+	return defaultIndex.searcher.Del(ctx, prefix)
 }
 
 func Clear(ctx context.Context) error {
-	return instance.Clear(ctx)
+	return defaultIndex.searcher.Clear(ctx)
 }
 
 func Config(ctx context.Context) searcher.Config {
-	return instance.Config()
+	return defaultIndex.Config()
 }
 
+// Update updates the indexes which have the objs in parent with the objs listed
 func Update(parent string, objs []model.Obj) {
-	if instance == nil || !instance.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) || Running.Load() {
-		return
-	}
-	if isIgnorePath(parent) {
-		return
+	for _, index := range updatableIndexes() {
+		if index.covers(parent) {
+			index.update(parent, objs)
+		}
 	}
+}
+
+func (i *Instance) update(parent string, objs []model.Obj) {
 	ctx := context.Background()
-	// only update when index have built
-	progress, err := Progress()
-	if err != nil {
-		log.Errorf("update search index error while get progress: %+v", err)
-		return
-	}
-	if !progress.IsDone {
-		return
-	}
-	nodes, err := instance.Get(ctx, parent)
+	nodes, err := i.searcher.Get(ctx, parent)
 	if err != nil {
 		log.Errorf("update search index error while get nodes: %+v", err)
 		return
 	}
 	now := mapset.NewSet[string]()
-	for i := range objs {
-		now.Add(objs[i].GetName())
+	for _, obj := range objs {
+		now.Add(obj.GetName())
 	}
 	old := mapset.NewSet[string]()
-	for i := range nodes {
-		old.Add(nodes[i].Name)
+	for _, node := range nodes {
+		old.Add(node.Name)
 	}
 	// delete data that no longer exists
 	toDelete := old.Difference(now)
 	toAdd := now.Difference(old)
-	for i := range nodes {
-		if toDelete.Contains(nodes[i].Name) && !op.HasStorage(path.Join(parent, nodes[i].Name)) {
-			log.Debugf("delete index: %s", path.Join(parent, nodes[i].Name))
-			err = instance.Del(ctx, path.Join(parent, nodes[i].Name))
+	for _, node := range nodes {
+		if toDelete.Contains(node.Name) && !op.HasStorage(path.Join(parent, node.Name)) {
+			log.Debugf("delete index: %s", path.Join(parent, node.Name))
+			err = i.searcher.Del(ctx, path.Join(parent, node.Name))
 			if err != nil {
 				log.Errorf("update search index error while del old node: %+v", err)
 				return
 			}
 		}
 	}
-	for i := range objs {
-		if toAdd.Contains(objs[i].GetName()) {
-			if !objs[i].IsDir() {
-				log.Debugf("add index: %s", path.Join(parent, objs[i].GetName()))
-				err = Index(ctx, parent, objs[i])
+	for _, obj := range objs {
+		if toAdd.Contains(obj.GetName()) {
+			if !obj.IsDir() {
+				log.Debugf("add index: %s", path.Join(parent, obj.GetName()))
+				err = i.Index(ctx, parent, obj)
 				if err != nil {
 					log.Errorf("update search index error while index new node: %+v", err)
 					return
 				}
 			} else {
 				// build index if it's a folder
-				dir := path.Join(parent, objs[i].GetName())
-				err = BuildIndex(ctx,
-					[]string{dir},
-					conf.SlicesMap[conf.IgnorePaths],
-					setting.GetInt(conf.MaxIndexDepth, 20)-strings.Count(dir, "/"), false)
+				dir := path.Join(parent, obj.GetName())
+				err = i.Build(ctx, []string{dir}, i.ignorePaths(), i.depthOf(dir), false)
 				if err != nil {
 					log.Errorf("update search index error while build index: %+v", err)
 					return
@@ -236,4 +227,5 @@ func Update(parent string, objs []model.Obj) {
 
 func init() {
 	op.RegisterObjsUpdateHook(Update)
+	op.RegisterObjChangeHook(OnObjChange)
 }
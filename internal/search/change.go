@@ -0,0 +1,140 @@
+package search
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/mq"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	changeMQ     = mq.NewInMemoryMQ[op.ObjChange]()
+	changeSignal = make(chan struct{}, 1)
+	changeOnce   sync.Once
+)
+
+// OnObjChange queues the change, the changes are applied to the indexes one by one in background
+func OnObjChange(change op.ObjChange) {
+	affected := false
+	for _, index := range updatableIndexes() {
+		if index.covers(change.Path) || (change.OldPath != "" && index.covers(change.OldPath)) {
+			affected = true
+			break
+		}
+	}
+	if !affected {
+		return
+	}
+	changeOnce.Do(func() {
+		go consumeChanges()
+	})
+	changeMQ.Publish(mq.Message[op.ObjChange]{Content: change})
+	select {
+	case changeSignal <- struct{}{}:
+	default:
+	}
+}
+
+func consumeChanges() {
+	for range changeSignal {
+		changeMQ.ConsumeAll(func(messages []mq.Message[op.ObjChange]) {
+			for _, m := range messages {
+				applyChange(m.Content)
+			}
+		})
+	}
+}
+
+func applyChange(change op.ObjChange) {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		log.Errorf("update search index error while get admin: %+v", err)
+		return
+	}
+	ctx := context.WithValue(context.Background(), "user", admin)
+	log.Debugf("update index: %+v", change)
+	for _, index := range updatableIndexes() {
+		if err := index.applyChange(ctx, change); err != nil {
+			log.Errorf("update search index [%s] of %s error: %+v", index.Name(), change.Path, err)
+		}
+	}
+}
+
+func (i *Instance) applyChange(ctx context.Context, change op.ObjChange) error {
+	switch change.Type {
+	case op.ObjCreate, op.ObjCopy:
+		return i.reindex(ctx, change.Path)
+	case op.ObjRemove:
+		if !i.overlaps(change.Path) {
+			return nil
+		}
+		return i.searcher.Del(ctx, change.Path)
+	case op.ObjMove:
+		if i.overlaps(change.OldPath) {
+			if err := i.searcher.Del(ctx, change.OldPath); err != nil {
+				return err
+			}
+		}
+		return i.reindex(ctx, change.Path)
+	}
+	return nil
+}
+
+// reindex replaces the index of the obj at path and its children
+func (i *Instance) reindex(ctx context.Context, p string) error {
+	if !i.overlaps(p) {
+		return nil
+	}
+	if err := i.searcher.Del(ctx, p); err != nil {
+		return err
+	}
+	if !i.covers(p) {
+		return nil
+	}
+	obj, err := fs.Get(ctx, p, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		// it has been removed again
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return i.indexObj(ctx, p, obj)
+}
+
+func (i *Instance) indexObj(ctx context.Context, p string, obj model.Obj) error {
+	if !obj.IsDir() {
+		return i.Index(ctx, path.Dir(p), obj)
+	}
+	return i.indexTree(ctx, p, obj)
+}
+
+// indexTree indexes the dir and its children without marking the index as running,
+// so that the changes made meanwhile are still queued
+func (i *Instance) indexTree(ctx context.Context, dir string, obj model.Obj) error {
+	var objs []ObjWithParent
+	err := fs.WalkFS(ctx, i.depthOf(dir), dir, obj, func(p string, info model.Obj) error {
+		if !i.covers(p) {
+			return filepath.SkipDir
+		}
+		objs = append(objs, ObjWithParent{Parent: path.Dir(p), Obj: info})
+		if len(objs) >= 1000 {
+			if err := i.BatchIndex(ctx, objs); err != nil {
+				return err
+			}
+			objs = objs[:0]
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return i.BatchIndex(ctx, objs)
+}
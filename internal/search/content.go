@@ -0,0 +1,143 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/extract"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type contentKind int
+
+const (
+	contentNone contentKind = iota
+	contentPlain
+	contentHTML
+	contentPDF
+	contentDocx
+	contentXlsx
+)
+
+func contentKindOf(name string) contentKind {
+	switch strings.ToLower(strings.TrimPrefix(path.Ext(name), ".")) {
+	case "htm", "html":
+		return contentHTML
+	case "pdf":
+		return contentPDF
+	case "docx":
+		return contentDocx
+	case "xlsx":
+		return contentXlsx
+	}
+	if utils.GetFileType(name) == conf.TEXT {
+		return contentPlain
+	}
+	return contentNone
+}
+
+// fillContent extracts the text of the files into the nodes if the content index is enabled,
+// at most conf.IndexContentMaxSize KB of a file is read
+func (i *Instance) fillContent(ctx context.Context, nodes []model.SearchNode) {
+	if !i.searcher.Config().Content || !setting.GetBool(conf.IndexContent) {
+		return
+	}
+	limit := setting.GetInt(conf.IndexContentMaxSize, 1024) * 1024
+	for n := range nodes {
+		if nodes[n].IsDir {
+			continue
+		}
+		kind := contentKindOf(nodes[n].Name)
+		if kind == contentNone {
+			continue
+		}
+		p := path.Join(nodes[n].Parent, nodes[n].Name)
+		text, err := extractContent(ctx, p, kind, limit)
+		if err != nil {
+			log.Warnf("failed extract content of %s: %+v", p, err)
+			continue
+		}
+		nodes[n].Content = text
+	}
+}
+
+func extractContent(ctx context.Context, p string, kind contentKind, limit int) (string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(p)
+	if err != nil {
+		return "", err
+	}
+	link, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return "", err
+	}
+	size := obj.GetSize()
+	// the documents can't be extracted from a part of them
+	if kind != contentPlain && kind != contentHTML && size > int64(limit) {
+		if link.MFile != nil {
+			_ = link.MFile.Close()
+		}
+		return "", nil
+	}
+	rc, err := openLink(ctx, link, size)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	switch kind {
+	case contentPlain:
+		return extract.Plain(rc, limit)
+	case contentHTML:
+		return extract.HTML(io.LimitReader(rc, int64(limit)), limit)
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, int64(limit)))
+	if err != nil {
+		return "", err
+	}
+	r := bytes.NewReader(data)
+	switch kind {
+	case contentPDF:
+		return extract.PDF(r, r.Size(), limit)
+	case contentDocx:
+		return extract.Docx(r, r.Size(), limit)
+	default:
+		return extract.Xlsx(r, r.Size(), limit)
+	}
+}
+
+// openLink opens the body of the file from the link in the way the driver provides
+func openLink(ctx context.Context, link *model.Link, size int64) (io.ReadCloser, error) {
+	if link.MFile != nil {
+		if _, err := link.MFile.Seek(0, io.SeekStart); err != nil {
+			_ = link.MFile.Close()
+			return nil, err
+		}
+		return link.MFile, nil
+	}
+	rrc := link.RangeReadCloser
+	if rrc == nil {
+		var err error
+		rrc, err = stream.GetRangeReadCloserFromLink(size, link)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rc, err := rrc.RangeRead(ctx, http_range.Range{Length: -1})
+	if err != nil {
+		_ = rrc.Close()
+		return nil, err
+	}
+	return utils.NewReadCloser(rc, func() error {
+		_ = rc.Close()
+		return rrc.Close()
+	}), nil
+}
@@ -17,7 +17,7 @@ var config = searcher.Config{
 }
 
 func init() {
-	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
+	searcher.RegisterSearcher(config, func(name string) (searcher.Searcher, error) {
 		db := db.GetDb()
 		switch conf.Conf.Database.Type {
 		case "mysql":
@@ -37,6 +37,6 @@ func init() {
 				return nil, err
 			}
 		}
-		return &DB{}, nil
+		return &DB{index: name}, nil
 	})
 }
@@ -10,7 +10,7 @@ var config = searcher.Config{
 }
 
 func init() {
-	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
-		return &DB{}, nil
+	searcher.RegisterSearcher(config, func(name string) (searcher.Searcher, error) {
+		return &DB{index: name}, nil
 	})
 }
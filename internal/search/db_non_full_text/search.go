@@ -8,30 +8,32 @@ import (
 	"github.com/alist-org/alist/v3/internal/search/searcher"
 )
 
-type DB struct{}
+type DB struct {
+	index string
+}
 
 func (D DB) Config() searcher.Config {
 	return config
 }
 
 func (D DB) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
-	return db.SearchNode(req, false)
+	return db.SearchNode(D.index, req, false)
 }
 
 func (D DB) Index(ctx context.Context, node model.SearchNode) error {
-	return db.CreateSearchNode(&node)
+	return db.CreateSearchNode(D.index, &node)
 }
 
 func (D DB) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
-	return db.BatchCreateSearchNodes(&nodes)
+	return db.BatchCreateSearchNodes(D.index, &nodes)
 }
 
 func (D DB) Get(ctx context.Context, parent string) ([]model.SearchNode, error) {
-	return db.GetSearchNodesByParent(parent)
+	return db.GetSearchNodesByParent(D.index, parent)
 }
 
 func (D DB) Del(ctx context.Context, path string) error {
-	return db.DeleteSearchNodesByParent(path)
+	return db.DeleteSearchNodesByParent(D.index, path)
 }
 
 func (D DB) Release(ctx context.Context) error {
@@ -39,7 +41,7 @@ func (D DB) Release(ctx context.Context) error {
 }
 
 func (D DB) Clear(ctx context.Context) error {
-	return db.ClearSearchNodes()
+	return db.ClearSearchNodes(D.index)
 }
 
 var _ searcher.Searcher = (*DB)(nil)
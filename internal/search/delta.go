@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	deltaRunning  atomic.Bool
+	lastAutoDelta time.Time
+)
+
+// DeltaRefresh updates the indexes of the mounts by re-listing only the dirs modified
+// since the last refresh of the mount, or since the index was built for the first refresh.
+// The other dirs are checked with the modified time in the listing of their parent
+func DeltaRefresh(ctx context.Context, mountPaths []string) error {
+	indexes := updatableIndexes()
+	if len(indexes) == 0 {
+		return errors.New("index can't be updated now")
+	}
+	if !deltaRunning.CompareAndSwap(false, true) {
+		return errors.New("delta refresh is running")
+	}
+	defer deltaRunning.Store(false)
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, "user", admin)
+	for _, index := range indexes {
+		if err := index.deltaRefresh(ctx, mountPaths); err != nil {
+			return errors.WithMessagef(err, "failed delta refresh index [%s]", index.Name())
+		}
+	}
+	return nil
+}
+
+func (i *Instance) deltaRefresh(ctx context.Context, mountPaths []string) error {
+	progress, err := i.Progress()
+	if err != nil {
+		return err
+	}
+	for _, mountPath := range mountPaths {
+		for _, root := range i.rootsIn(mountPath) {
+			start := time.Now()
+			i.mu.Lock()
+			since, ok := i.lastDelta[root]
+			i.mu.Unlock()
+			if !ok && progress.LastDoneTime != nil {
+				since = *progress.LastDoneTime
+			}
+			count, err := i.deltaDir(ctx, root, since, true)
+			if err != nil {
+				return errors.WithMessagef(err, "failed delta refresh %s", root)
+			}
+			log.Infof("delta refresh %s done, %d dirs re-listed", root, count)
+			i.mu.Lock()
+			i.lastDelta[root] = start
+			i.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// rootsIn returns the dirs to refresh for the mount, that's the mount itself if it's in the index,
+// or the index paths in the mount
+func (i *Instance) rootsIn(mountPath string) []string {
+	if _, ok := i.root(mountPath); ok {
+		return []string{mountPath}
+	}
+	var roots []string
+	for _, p := range i.paths() {
+		if isSubPath(mountPath, p) {
+			roots = append(roots, p)
+		}
+	}
+	return roots
+}
+
+// deltaDir updates the index of the children of dir if it's changed and goes on with the sub dirs,
+// it returns the number of the re-listed dirs
+func (i *Instance) deltaDir(ctx context.Context, dir string, since time.Time, changed bool) (int, error) {
+	if i.running.Load() {
+		return 0, errors.New("index is running")
+	}
+	if !i.covers(dir) {
+		return 0, nil
+	}
+	nodes, err := i.searcher.Get(ctx, dir)
+	if err != nil {
+		return 0, err
+	}
+	if !changed {
+		// the children of dir are kept, but the sub dirs may be modified,
+		// their modified time is got from the listing of the driver, not the cache
+		objs, err := fs.List(ctx, dir, &fs.ListArgs{Refresh: true, NoLog: true})
+		if err != nil {
+			return 0, err
+		}
+		listed := make(map[string]model.Obj, len(objs))
+		for _, obj := range objs {
+			listed[obj.GetName()] = obj
+		}
+		count := 0
+		for _, node := range nodes {
+			if !node.IsDir {
+				continue
+			}
+			p := path.Join(dir, node.Name)
+			obj, ok := listed[node.Name]
+			if !ok || !obj.IsDir() {
+				if err := i.searcher.Del(ctx, p); err != nil {
+					return count, err
+				}
+				continue
+			}
+			n, err := i.deltaDir(ctx, p, since, obj.ModTime().After(since))
+			count += n
+			if err != nil {
+				return count, err
+			}
+		}
+		return count, nil
+	}
+
+	objs, err := fs.List(ctx, dir, &fs.ListArgs{Refresh: true, NoLog: true})
+	if err != nil {
+		return 0, err
+	}
+	count := 1
+	indexed := make(map[string]model.SearchNode, len(nodes))
+	for _, node := range nodes {
+		indexed[node.Name] = node
+	}
+	for _, obj := range objs {
+		p := path.Join(dir, obj.GetName())
+		node, ok := indexed[obj.GetName()]
+		delete(indexed, obj.GetName())
+		if !i.covers(p) {
+			continue
+		}
+		switch {
+		case ok && node.IsDir && obj.IsDir():
+			n, err := i.deltaDir(ctx, p, since, obj.ModTime().After(since))
+			count += n
+			if err != nil {
+				return count, err
+			}
+			continue
+		case ok && !node.IsDir && !obj.IsDir() &&
+			node.Size == obj.GetSize() && node.Modified.Unix() == obj.ModTime().Unix():
+			continue
+		case ok:
+			if err := i.searcher.Del(ctx, p); err != nil {
+				return count, err
+			}
+		}
+		if err := i.indexObj(ctx, p, obj); err != nil {
+			return count, err
+		}
+	}
+	// the remaining nodes no longer exist
+	for name := range indexed {
+		p := path.Join(dir, name)
+		if op.HasStorage(p) {
+			continue
+		}
+		if err := i.searcher.Del(ctx, p); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// AutoDeltaRefresh refreshes all the mounts once the interval of conf.IndexDeltaRefresh has passed
+func AutoDeltaRefresh() {
+	interval := time.Duration(setting.GetInt(conf.IndexDeltaRefresh, 0)) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	if lastAutoDelta.IsZero() {
+		lastAutoDelta = time.Now()
+		return
+	}
+	if time.Since(lastAutoDelta) < interval || len(updatableIndexes()) == 0 {
+		return
+	}
+	lastAutoDelta = time.Now()
+	var mountPaths []string
+	for _, storage := range op.GetAllStorages() {
+		mountPath := storage.GetStorage().MountPath
+		if storage.GetStorage().Status != op.WORK || isIgnorePath(mountPath) {
+			continue
+		}
+		mountPaths = append(mountPaths, mountPath)
+	}
+	if err := DeltaRefresh(context.Background(), mountPaths); err != nil {
+		log.Errorf("delta refresh error: %+v", err)
+	}
+}
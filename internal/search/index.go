@@ -0,0 +1,225 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Instance is a search index with its searcher. The default index covers all the paths and its searcher
+// is set by the search_index setting, the named indexes cover the paths set by model.SearchIndex
+type Instance struct {
+	searcher searcher.Searcher
+	running  *atomic.Bool
+	quit     chan struct{}
+	// conf is nil for the default index
+	conf *model.SearchIndex
+	cron *cron.Cron
+	mu   sync.Mutex
+	// start time of the last delta refresh of each path
+	lastDelta map[string]time.Time
+}
+
+var defaultIndex = &Instance{running: &Running, lastDelta: make(map[string]time.Time)}
+
+// DefaultIndex returns the index set by the search_index setting
+func DefaultIndex() *Instance {
+	return defaultIndex
+}
+
+// Name is empty for the default index
+func (i *Instance) Name() string {
+	if i.conf == nil {
+		return ""
+	}
+	return i.conf.Name
+}
+
+// Available reports whether the searcher of the index is created
+func (i *Instance) Available() bool {
+	return i.searcher != nil
+}
+
+func (i *Instance) IsRunning() bool {
+	return i.running.Load()
+}
+
+func (i *Instance) Config() searcher.Config {
+	return i.searcher.Config()
+}
+
+func (i *Instance) paths() []string {
+	if i.conf == nil {
+		return []string{"/"}
+	}
+	return i.conf.GetPaths()
+}
+
+// ignorePaths are the global ignore paths and the ignore paths of the index
+func (i *Instance) ignorePaths() []string {
+	ignorePaths := conf.SlicesMap[conf.IgnorePaths]
+	if i.conf != nil {
+		ignorePaths = append(append([]string{}, ignorePaths...), i.conf.GetIgnorePaths()...)
+	}
+	return ignorePaths
+}
+
+func (i *Instance) maxDepth() int {
+	if i.conf != nil && i.conf.MaxDepth != 0 {
+		return i.conf.MaxDepth
+	}
+	return setting.GetInt(conf.MaxIndexDepth, 20)
+}
+
+// root returns the index path which p is in
+func (i *Instance) root(p string) (string, bool) {
+	for _, root := range i.paths() {
+		if isSubPath(root, p) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// covers reports whether p is in the paths of the index and not ignored
+func (i *Instance) covers(p string) bool {
+	if _, ok := i.root(p); !ok {
+		return false
+	}
+	for _, ignorePath := range i.ignorePaths() {
+		if strings.HasPrefix(p, ignorePath) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether some objs in dir may be in the index
+func (i *Instance) overlaps(dir string) bool {
+	for _, root := range i.paths() {
+		if isSubPath(root, dir) || isSubPath(dir, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// depthOf returns the remaining depth of the index under dir
+func (i *Instance) depthOf(dir string) int {
+	root, _ := i.root(dir)
+	return i.maxDepth() - (pathDepth(dir) - pathDepth(root))
+}
+
+func pathDepth(p string) int {
+	if p == "/" {
+		return 0
+	}
+	return strings.Count(p, "/")
+}
+
+// isSubPath reports whether sub is path itself or in path
+func isSubPath(path, sub string) bool {
+	return path == sub || path == "/" || strings.HasPrefix(sub, path+"/")
+}
+
+func (i *Instance) Progress() (*model.IndexProgress, error) {
+	if i.conf == nil {
+		return Progress()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	progress := i.conf.Progress
+	return &progress, nil
+}
+
+func (i *Instance) WriteProgress(progress *model.IndexProgress) {
+	if i.conf == nil {
+		WriteProgress(progress)
+		return
+	}
+	i.mu.Lock()
+	i.conf.Progress = *progress
+	i.mu.Unlock()
+	if err := db.UpdateSearchIndexProgress(i.conf.ID, *progress); err != nil {
+		log.Errorf("save progress of index %s error: %+v", i.conf.Name, err)
+	}
+}
+
+// canUpdate reports whether the built index can be updated in place
+func (i *Instance) canUpdate() bool {
+	if i.searcher == nil || !i.searcher.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) || i.running.Load() {
+		return false
+	}
+	progress, err := i.Progress()
+	if err != nil {
+		log.Errorf("update search index error while get progress: %+v", err)
+		return false
+	}
+	return progress.IsDone
+}
+
+// Rebuild clears the index and builds it for all its paths
+func (i *Instance) Rebuild(ctx context.Context) error {
+	if i.running.Load() {
+		return errs.SearchIndexRunning
+	}
+	if err := i.searcher.Clear(ctx); err != nil {
+		return errors.WithMessage(err, "failed clear index")
+	}
+	return i.Build(ctx, i.paths(), i.ignorePaths(), i.maxDepth(), true)
+}
+
+// Refresh rebuilds the index of the paths
+func (i *Instance) Refresh(ctx context.Context, paths []string, maxDepth int) error {
+	if i.running.Load() {
+		return errs.SearchIndexRunning
+	}
+	for _, p := range paths {
+		if !i.covers(p) {
+			return errors.Wrapf(errs.InvalidSearchIndex, "%s is not in the index", p)
+		}
+	}
+	for _, p := range paths {
+		if err := i.searcher.Del(ctx, p); err != nil {
+			return errors.WithMessagef(err, "failed delete index on %s", p)
+		}
+	}
+	return i.Build(ctx, paths, i.ignorePaths(), maxDepth, false)
+}
+
+func (i *Instance) Stop() error {
+	if !i.running.Load() {
+		return errs.SearchIndexNotRunning
+	}
+	i.quit <- struct{}{}
+	return nil
+}
+
+// Clear removes all the objs from the index and resets the progress
+func (i *Instance) Clear(ctx context.Context) error {
+	if i.running.Load() {
+		return errs.SearchIndexRunning
+	}
+	if err := i.searcher.Clear(ctx); err != nil {
+		return err
+	}
+	i.WriteProgress(&model.IndexProgress{
+		ObjCount:     0,
+		IsDone:       true,
+		LastDoneTime: nil,
+		Error:        "",
+	})
+	return nil
+}
@@ -0,0 +1,261 @@
+package search
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	indexesMu sync.RWMutex
+	// the enabled named indexes
+	indexes = make(map[uint]*Instance)
+	// the name is used in the paths of the bleve index and the uids of the meilisearch index
+	indexNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// InitIndexes loads the enabled named indexes and schedules their rebuilds
+func InitIndexes() {
+	all, err := db.GetAllSearchIndexes()
+	if err != nil {
+		log.Errorf("failed get search indexes: %+v", err)
+		return
+	}
+	for i := range all {
+		if all[i].Disabled {
+			continue
+		}
+		// the building is interrupted by the restart
+		if !all[i].Progress.IsDone {
+			all[i].Progress.IsDone = true
+			if err := db.UpdateSearchIndexProgress(all[i].ID, all[i].Progress); err != nil {
+				log.Errorf("failed save progress of index %s: %+v", all[i].Name, err)
+			}
+		}
+		if err := load(&all[i]); err != nil {
+			log.Errorf("failed load index %s: %+v", all[i].Name, err)
+		}
+	}
+}
+
+func load(s *model.SearchIndex) error {
+	newSearcher, ok := searcher.NewMap[s.Backend]
+	if !ok {
+		return errors.Wrapf(errs.InvalidSearchIndex, "not support index: %s", s.Backend)
+	}
+	sr, err := newSearcher(s.Name)
+	if err != nil {
+		return err
+	}
+	index := &Instance{
+		searcher:  sr,
+		running:   new(atomic.Bool),
+		conf:      s,
+		lastDelta: make(map[string]time.Time),
+	}
+	if s.Interval > 0 {
+		index.cron = cron.NewCron(time.Duration(s.Interval) * time.Minute)
+		index.cron.Do(func() {
+			if err := index.Rebuild(context.Background()); err != nil && !errors.Is(err, errs.SearchIndexRunning) {
+				log.Errorf("failed rebuild index %s: %+v", s.Name, err)
+			}
+		})
+	}
+	indexesMu.Lock()
+	indexes[s.ID] = index
+	indexesMu.Unlock()
+	return nil
+}
+
+// unload stops the schedule of the index and releases its searcher
+func unload(id uint) {
+	indexesMu.Lock()
+	index, ok := indexes[id]
+	delete(indexes, id)
+	indexesMu.Unlock()
+	if !ok {
+		return
+	}
+	if index.cron != nil {
+		// Stop blocks until the running rebuild finishes
+		go index.cron.Stop()
+	}
+	if err := index.searcher.Release(context.Background()); err != nil {
+		log.Errorf("failed release index %s: %+v", index.conf.Name, err)
+	}
+}
+
+// GetIndex returns the enabled named index
+func GetIndex(id uint) (*Instance, error) {
+	indexesMu.RLock()
+	defer indexesMu.RUnlock()
+	index, ok := indexes[id]
+	if !ok {
+		return nil, errors.Wrapf(errs.InvalidSearchIndex, "index %d is not enabled", id)
+	}
+	return index, nil
+}
+
+// allIndexes returns the available indexes, the default index is the first one
+func allIndexes() []*Instance {
+	var res []*Instance
+	if defaultIndex.searcher != nil {
+		res = append(res, defaultIndex)
+	}
+	indexesMu.RLock()
+	named := make([]*Instance, 0, len(indexes))
+	for _, index := range indexes {
+		named = append(named, index)
+	}
+	indexesMu.RUnlock()
+	sort.Slice(named, func(a, b int) bool {
+		return named[a].conf.ID < named[b].conf.ID
+	})
+	return append(res, named...)
+}
+
+// updatableIndexes returns the indexes which can be updated in place
+func updatableIndexes() []*Instance {
+	return utils.SliceFilter(allIndexes(), func(index *Instance) bool {
+		return index.canUpdate()
+	})
+}
+
+func checkIndexConf(s *model.SearchIndex) error {
+	if !indexNameRegexp.MatchString(s.Name) {
+		return errors.Wrapf(errs.InvalidSearchIndex, "invalid name: %s", s.Name)
+	}
+	if _, ok := searcher.NewMap[s.Backend]; !ok {
+		return errors.Wrapf(errs.InvalidSearchIndex, "not support index: %s", s.Backend)
+	}
+	if s.Interval < 0 || s.MaxDepth < 0 {
+		return errors.Wrapf(errs.InvalidSearchIndex, "interval and max depth can't be negative")
+	}
+	paths := s.GetPaths()
+	if len(paths) == 0 {
+		return errors.Wrapf(errs.InvalidSearchIndex, "paths can't be empty")
+	}
+	for i := range paths {
+		paths[i] = utils.FixAndCleanPath(paths[i])
+	}
+	s.Paths = strings.Join(paths, "\n")
+	ignorePaths := s.GetIgnorePaths()
+	for i := range ignorePaths {
+		ignorePaths[i] = utils.FixAndCleanPath(ignorePaths[i])
+	}
+	s.IgnorePaths = strings.Join(ignorePaths, "\n")
+	return nil
+}
+
+func GetIndexConf(id uint) (*model.SearchIndex, error) {
+	s, err := db.GetSearchIndexById(id)
+	if err != nil {
+		return nil, err
+	}
+	if index, err := GetIndex(id); err == nil {
+		s.Running = index.IsRunning()
+	}
+	return s, nil
+}
+
+func GetIndexConfs(pageIndex, pageSize int) ([]model.SearchIndex, int64, error) {
+	confs, total, err := db.GetSearchIndexes(pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range confs {
+		if index, err := GetIndex(confs[i].ID); err == nil {
+			confs[i].Running = index.IsRunning()
+		}
+	}
+	return confs, total, nil
+}
+
+func CreateIndex(s *model.SearchIndex) error {
+	if err := checkIndexConf(s); err != nil {
+		return err
+	}
+	s.ID = 0
+	s.Progress = model.IndexProgress{IsDone: true}
+	if err := db.CreateSearchIndex(s); err != nil {
+		return err
+	}
+	if s.Disabled {
+		return nil
+	}
+	return load(s)
+}
+
+// UpdateIndex updates the settings of the index, the objs indexed are cleared
+// if the objs of the index are changed
+func UpdateIndex(s *model.SearchIndex) error {
+	if err := checkIndexConf(s); err != nil {
+		return err
+	}
+	old, err := db.GetSearchIndexById(s.ID)
+	if err != nil {
+		return err
+	}
+	if index, err := GetIndex(s.ID); err == nil && index.IsRunning() {
+		return errs.SearchIndexRunning
+	}
+	s.Progress = old.Progress
+	changed := s.Name != old.Name || s.Backend != old.Backend || s.Paths != old.Paths ||
+		s.IgnorePaths != old.IgnorePaths || s.MaxDepth != old.MaxDepth
+	unload(s.ID)
+	if changed {
+		if err := clearOld(old); err != nil {
+			return err
+		}
+		s.Progress = model.IndexProgress{IsDone: true}
+	}
+	if err := db.UpdateSearchIndex(s); err != nil {
+		return err
+	}
+	if s.Disabled {
+		return nil
+	}
+	return load(s)
+}
+
+// clearOld clears the objs indexed with the old settings
+func clearOld(old *model.SearchIndex) error {
+	newSearcher, ok := searcher.NewMap[old.Backend]
+	if !ok {
+		return nil
+	}
+	sr, err := newSearcher(old.Name)
+	if err != nil {
+		return err
+	}
+	defer sr.Release(context.Background())
+	return sr.Clear(context.Background())
+}
+
+func DeleteIndex(id uint) error {
+	s, err := db.GetSearchIndexById(id)
+	if err != nil {
+		return err
+	}
+	if index, err := GetIndex(id); err == nil && index.IsRunning() {
+		return errs.SearchIndexRunning
+	}
+	unload(id)
+	if err := clearOld(s); err != nil {
+		log.Errorf("failed clear index %s: %+v", s.Name, err)
+	}
+	return db.DeleteSearchIndexById(id)
+}
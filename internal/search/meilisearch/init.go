@@ -16,15 +16,20 @@ var config = searcher.Config{
 }
 
 func init() {
-	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
+	searcher.RegisterSearcher(config, func(name string) (searcher.Searcher, error) {
+		indexUid := conf.Conf.Meilisearch.IndexPrefix + "alist"
+		if name != "" {
+			indexUid += "_" + name
+		}
 		m := Meilisearch{
 			Client: meilisearch.NewClient(meilisearch.ClientConfig{
 				Host:   conf.Conf.Meilisearch.Host,
 				APIKey: conf.Conf.Meilisearch.APIKey,
 			}),
-			IndexUid:             conf.Conf.Meilisearch.IndexPrefix + "alist",
-			FilterableAttributes: []string{"parent", "is_dir", "name"},
+			IndexUid:             indexUid,
+			FilterableAttributes: []string{"parent", "is_dir", "name", "size", "modified_unix", "file_type", "ext"},
 			SearchableAttributes: []string{"name"},
+			SortableAttributes:   []string{"name", "size", "modified_unix"},
 		}
 
 		_, err := m.Client.GetIndex(m.IndexUid)
@@ -72,6 +77,17 @@ func init() {
 			}
 		}
 
+		attributes, err = m.Client.Index(m.IndexUid).GetSortableAttributes()
+		if err != nil {
+			return nil, err
+		}
+		if attributes == nil || !utils.SliceAllContains(*attributes, m.SortableAttributes...) {
+			_, err = m.Client.Index(m.IndexUid).UpdateSortableAttributes(&m.SortableAttributes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		pagination, err := m.Client.Index(m.IndexUid).GetPagination()
 		if err != nil {
 			return nil, err
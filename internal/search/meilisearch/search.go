@@ -9,6 +9,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/meilisearch/meilisearch-go"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +18,76 @@ import (
 type searchDocument struct {
 	ID string `json:"id"`
 	model.SearchNode
+	// ModifiedUnix and Ext are only used to filter and sort
+	ModifiedUnix int64  `json:"modified_unix"`
+	Ext          string `json:"ext"`
+}
+
+func newSearchDocument(node model.SearchNode) *searchDocument {
+	doc := &searchDocument{
+		ID:           uuid.NewString(),
+		SearchNode:   node,
+		ModifiedUnix: node.Modified.Unix(),
+	}
+	if !node.IsDir {
+		doc.Ext = strings.ToLower(strings.TrimPrefix(path.Ext(node.Name), "."))
+	}
+	return doc
+}
+
+func toSearchNode(src map[string]any) model.SearchNode {
+	node := model.SearchNode{
+		Parent: src["parent"].(string),
+		Name:   src["name"].(string),
+		IsDir:  src["is_dir"].(bool),
+		Size:   int64(src["size"].(float64)),
+	}
+	// the documents indexed by older versions have no modified and file_type
+	if modified, ok := src["modified"].(string); ok {
+		node.Modified, _ = time.Parse(time.RFC3339Nano, modified)
+	}
+	if fileType, ok := src["file_type"].(float64); ok {
+		node.FileType = int(fileType)
+	}
+	return node
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "\\'") + "'"
+}
+
+func searchFilter(req model.SearchReq) string {
+	var filters []string
+	if req.Scope != 0 {
+		filters = append(filters, fmt.Sprintf("is_dir = %v", req.Scope == 1))
+	}
+	if req.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", req.MinSize))
+	}
+	if req.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", req.MaxSize))
+	}
+	if !req.ModifiedAfter.IsZero() {
+		filters = append(filters, fmt.Sprintf("modified_unix >= %d", req.ModifiedAfter.Unix()))
+	}
+	if !req.ModifiedBefore.IsZero() {
+		filters = append(filters, fmt.Sprintf("modified_unix <= %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.FileTypes) > 0 {
+		types := make([]string, len(req.FileTypes))
+		for i, t := range req.FileTypes {
+			types[i] = strconv.Itoa(t)
+		}
+		filters = append(filters, fmt.Sprintf("file_type IN [%s]", strings.Join(types, ",")))
+	}
+	if len(req.Extensions) > 0 {
+		exts := make([]string, len(req.Extensions))
+		for i, ext := range req.Extensions {
+			exts[i] = quote(ext)
+		}
+		filters = append(filters, "is_dir = false", fmt.Sprintf("ext IN [%s]", strings.Join(exts, ",")))
+	}
+	return strings.Join(filters, " AND ")
 }
 
 type Meilisearch struct {
@@ -23,6 +95,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 }
 
 func (m *Meilisearch) Config() searcher.Config {
@@ -35,21 +108,29 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		Page:                 int64(req.Page),
 		HitsPerPage:          int64(req.PerPage),
 	}
-	if req.Scope != 0 {
-		mReq.Filter = fmt.Sprintf("is_dir = %v", req.Scope == 1)
+	if filter := searchFilter(req); filter != "" {
+		mReq.Filter = filter
+	}
+	if req.OrderBy != "" {
+		attr := req.OrderBy
+		if attr == "modified" {
+			attr = "modified_unix"
+		}
+		direction := "asc"
+		if req.OrderDirection == "desc" {
+			direction = "desc"
+		}
+		mReq.Sort = []string{attr + ":" + direction}
+	}
+	if req.NameMatch != "" {
+		return m.matchDocuments(ctx, req)
 	}
 	search, err := m.Client.Index(m.IndexUid).Search(req.Keywords, mReq)
 	if err != nil {
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		return toSearchNode(src.(map[string]any)), nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -57,17 +138,62 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 	return nodes, search.TotalHits, nil
 }
 
+// matchDocumentsBatch is the count of documents read at once when matching the names
+const matchDocumentsBatch = 1000
+
+// matchDocuments matches the names of all the filtered documents, meilisearch can't match patterns
+// and its search is capped by maxTotalHits, so the documents are paged through instead
+func (m *Meilisearch) matchDocuments(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	var nodes []model.SearchNode
+	for offset := int64(0); ; offset += matchDocumentsBatch {
+		var result meilisearch.DocumentsResult
+		err := m.Client.Index(m.IndexUid).GetDocuments(&meilisearch.DocumentsQuery{
+			Filter: searchFilter(req),
+			Offset: offset,
+			Limit:  matchDocumentsBatch,
+		}, &result)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, src := range result.Results {
+			if node := toSearchNode(src); req.MatchName(node.Name) {
+				nodes = append(nodes, node)
+			}
+		}
+		if offset+matchDocumentsBatch >= result.Total {
+			break
+		}
+	}
+	sortNodes(nodes, req.OrderBy, req.OrderDirection)
+	return utils.SlicePage(nodes, req.Page, req.PerPage), int64(len(nodes)), nil
+}
+
+func sortNodes(nodes []model.SearchNode, orderBy, direction string) {
+	less := func(a, b model.SearchNode) bool {
+		switch orderBy {
+		case "size":
+			return a.Size < b.Size
+		case "modified":
+			return a.Modified.Before(b.Modified)
+		default:
+			return a.Name < b.Name
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if direction == "desc" {
+			return less(nodes[j], nodes[i])
+		}
+		return less(nodes[i], nodes[j])
+	})
+}
+
 func (m *Meilisearch) Index(ctx context.Context, node model.SearchNode) error {
 	return m.BatchIndex(ctx, []model.SearchNode{node})
 }
 
 func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
 	documents, _ := utils.SliceConvert(nodes, func(src model.SearchNode) (*searchDocument, error) {
-
-		return &searchDocument{
-			ID:         uuid.NewString(),
-			SearchNode: src,
-		}, nil
+		return newSearchDocument(src), nil
 	})
 
 	_, err := m.Client.Index(m.IndexUid).AddDocuments(documents)
@@ -100,13 +226,8 @@ func (m *Meilisearch) getDocumentsByParent(ctx context.Context, parent string) (
 	}
 	return utils.SliceConvert(result.Results, func(src map[string]any) (*searchDocument, error) {
 		return &searchDocument{
-			ID: src["id"].(string),
-			SearchNode: model.SearchNode{
-				Parent: src["parent"].(string),
-				Name:   src["name"].(string),
-				IsDir:  src["is_dir"].(bool),
-				Size:   int64(src["size"].(float64)),
-			},
+			ID:         src["id"].(string),
+			SearchNode: toSearchNode(src),
 		}, nil
 	})
 }
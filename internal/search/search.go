@@ -3,29 +3,31 @@ package search
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/search/searcher"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-var instance searcher.Searcher = nil
-
-// Init or reset index
+// Init or reset the default index
 func Init(mode string) error {
-	if instance != nil {
+	if defaultIndex.searcher != nil {
 		// unchanged, do nothing
-		if instance.Config().Name == mode {
+		if defaultIndex.searcher.Config().Name == mode {
 			return nil
 		}
-		err := instance.Release(context.Background())
+		err := defaultIndex.searcher.Release(context.Background())
 		if err != nil {
 			log.Errorf("release instance err: %+v", err)
 		}
-		instance = nil
+		defaultIndex.searcher = nil
 	}
 	if Running.Load() {
 		return fmt.Errorf("index is running")
@@ -38,29 +40,118 @@ func Init(mode string) error {
 	if !ok {
 		return fmt.Errorf("not support index: %s", mode)
 	}
-	i, err := s()
+	i, err := s("")
 	if err != nil {
 		log.Errorf("init searcher error: %+v", err)
 	} else {
-		instance = i
+		defaultIndex.searcher = i
 	}
 	return err
 }
 
+// Available reports whether there is any index to search in
+func Available() bool {
+	return len(allIndexes()) > 0
+}
+
+// Search queries all the indexes which may have the objs in the parent and merges the results
 func Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
-	return instance.Search(ctx, req)
+	var targets []*Instance
+	for _, index := range allIndexes() {
+		if index.overlaps(req.Parent) {
+			targets = append(targets, index)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, 0, errs.SearchNotAvailable
+	}
+	if req.InContent {
+		targets = utils.SliceFilter(targets, func(index *Instance) bool {
+			return index.Config().Content
+		})
+		if len(targets) == 0 {
+			return nil, 0, errs.ContentSearchNotSupported
+		}
+	}
+	if len(targets) == 1 {
+		return targets[0].searcher.Search(ctx, req)
+	}
+	// the first page*per_page nodes of every index are enough to make up the page after merging
+	sub := req
+	sub.Page = 1
+	sub.PerPage = req.Page * req.PerPage
+	var (
+		nodes []model.SearchNode
+		total int64
+		seen  = make(map[string]struct{})
+	)
+	for _, index := range targets {
+		res, count, err := index.searcher.Search(ctx, sub)
+		if err != nil {
+			return nil, 0, errors.WithMessagef(err, "failed search in index [%s]", index.Name())
+		}
+		total += count
+		for _, node := range res {
+			// the paths of the indexes may overlap
+			key := path.Join(node.Parent, node.Name)
+			if _, ok := seen[key]; ok {
+				total--
+				continue
+			}
+			seen[key] = struct{}{}
+			nodes = append(nodes, node)
+		}
+	}
+	sortNodes(nodes, req)
+	return utils.SlicePage(nodes, req.Page, req.PerPage), total, nil
+}
+
+// sortNodes sorts the merged nodes in the order of the request, the nodes of
+// the content search are kept in the order of their scores in each index
+func sortNodes(nodes []model.SearchNode, req model.SearchReq) {
+	if req.InContent && req.OrderBy == "" {
+		return
+	}
+	less := func(a, b *model.SearchNode) bool {
+		return a.Name < b.Name
+	}
+	switch req.OrderBy {
+	case "size":
+		less = func(a, b *model.SearchNode) bool {
+			return a.Size < b.Size
+		}
+	case "modified":
+		less = func(a, b *model.SearchNode) bool {
+			return a.Modified.Before(b.Modified)
+		}
+	}
+	desc := req.OrderDirection == "desc"
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if desc {
+			return less(&nodes[j], &nodes[i])
+		}
+		return less(&nodes[i], &nodes[j])
+	})
 }
 
 func Index(ctx context.Context, parent string, obj model.Obj) error {
-	if instance == nil {
+	return defaultIndex.Index(ctx, parent, obj)
+}
+
+func (i *Instance) Index(ctx context.Context, parent string, obj model.Obj) error {
+	if i.searcher == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
-	})
+	nodes := []model.SearchNode{{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime().UTC(),
+		FileType: utils.GetObjType(obj.GetName(), obj.IsDir()),
+	}}
+	i.fillContent(ctx, nodes)
+	return i.searcher.Index(ctx, nodes[0])
 }
 
 type ObjWithParent struct {
@@ -69,22 +160,29 @@ type ObjWithParent struct {
 }
 
 func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
-	if instance == nil {
+	return defaultIndex.BatchIndex(ctx, objs)
+}
+
+func (i *Instance) BatchIndex(ctx context.Context, objs []ObjWithParent) error {
+	if i.searcher == nil {
 		return errs.SearchNotAvailable
 	}
 	if len(objs) == 0 {
 		return nil
 	}
 	var searchNodes []model.SearchNode
-	for i := range objs {
+	for _, obj := range objs {
 		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
+			Parent:   obj.Parent,
+			Name:     obj.GetName(),
+			IsDir:    obj.IsDir(),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime().UTC(),
+			FileType: utils.GetObjType(obj.GetName(), obj.IsDir()),
 		})
 	}
-	return instance.BatchIndex(ctx, searchNodes)
+	i.fillContent(ctx, searchNodes)
+	return i.searcher.BatchIndex(ctx, searchNodes)
 }
 
 func init() {
@@ -1,6 +1,7 @@
 package searcher
 
-type New func() (Searcher, error)
+// New creates the searcher of the index with the name, the name is empty for the default index
+type New func(name string) (Searcher, error)
 
 var NewMap = map[string]New{}
 
@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// PartsStream is the stream of the parts staged on the local disk, the parts are read in order
+type PartsStream struct {
+	*FileStream
+	Parts []model.FilePart
+}
+
+func (s *PartsStream) GetParts() []model.FilePart {
+	return s.Parts
+}
+
+var _ model.FileParts = (*PartsStream)(nil)
+
+func NewPartsStream(ctx context.Context, obj model.Obj, mimetype string, parts []model.FilePart) *PartsStream {
+	r := &partsReader{parts: parts}
+	fs := &FileStream{
+		Ctx:      ctx,
+		Obj:      obj,
+		Reader:   r,
+		Mimetype: mimetype,
+	}
+	fs.Add(r)
+	return &PartsStream{FileStream: fs, Parts: parts}
+}
+
+// partsReader opens the parts one by one, so there is only one part opened at the same time
+type partsReader struct {
+	parts []model.FilePart
+	cur   *os.File
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(r.parts[0].Path)
+			if err != nil {
+				return 0, err
+			}
+			r.cur, r.parts = f, r.parts[1:]
+		}
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			_ = r.cur.Close()
+			r.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
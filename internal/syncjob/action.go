@@ -0,0 +1,97 @@
+package syncjob
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/tache"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+const (
+	ActionCopy   = "copy"
+	ActionMkdir  = "mkdir"
+	ActionDelete = "delete"
+	ActionRename = "rename"
+)
+
+const (
+	SideSrc = "src"
+	SideDst = "dst"
+)
+
+// Action is a change which a run makes, it's returned by the dry run
+type Action struct {
+	Type string `json:"type"`
+	// Side is the side which is changed, the object is copied from the other side for ActionCopy
+	Side string `json:"side"`
+	// Path is relative to the src path or the dst path of the job
+	Path string `json:"path"`
+	// To is the new path of ActionRename
+	To     string `json:"to,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// hashEqual compares the hash of a and b, ok is false if they don't have the same type of hash
+func hashEqual(a, b utils.HashInfo) (equal bool, ok bool) {
+	for ht, h := range a.Export() {
+		if d := b.GetHash(ht); h != "" && d != "" {
+			return strings.EqualFold(h, d), true
+		}
+	}
+	return false, false
+}
+
+// sameContent reports whether the files a and b are the same,
+// the modified time is compared if they don't have the same type of hash
+func sameContent(a, b model.Obj) bool {
+	if a.IsDir() || b.IsDir() {
+		return a.IsDir() == b.IsDir()
+	}
+	if a.GetSize() != b.GetSize() {
+		return false
+	}
+	if equal, ok := hashEqual(a.GetHash(), b.GetHash()); ok {
+		return equal
+	}
+	return sameModTime(a.ModTime(), b.ModTime())
+}
+
+func sameModTime(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d <= modTimeWindow && d >= -modTimeWindow
+}
+
+// waitTasks waits for all copy tasks to finish and calls done for each task with the error of it,
+// the tasks which haven't finished are canceled if ctx is done
+func waitTasks(ctx context.Context, tasks []*fs.CopyTask, done func(t *fs.CopyTask, err error)) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	pending := tasks
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			for _, t := range pending {
+				fs.CopyTaskManager.Cancel(t.GetID())
+				done(t, ctx.Err())
+			}
+			return
+		case <-ticker.C:
+		}
+		rest := pending[:0]
+		for _, t := range pending {
+			switch t.GetState() {
+			case tache.StateSucceeded:
+				done(t, nil)
+			case tache.StateFailed, tache.StateCanceled:
+				done(t, t.GetErr())
+			default:
+				rest = append(rest, t)
+			}
+		}
+		pending = rest
+	}
+}
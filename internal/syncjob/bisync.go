@@ -0,0 +1,529 @@
+package syncjob
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type change int
+
+const (
+	unchanged change = iota
+	created
+	modified
+	deleted
+)
+
+func (c change) String() string {
+	return [...]string{"unchanged", "created", "modified", "deleted"}[c]
+}
+
+// side is a side of a bisync job
+type side struct {
+	name    string
+	storage driver.Driver
+	// root is the actual path of the job in the storage
+	root    string
+	objs    map[string]model.Obj
+	changes map[string]change
+}
+
+func (s *side) fullPath(rel string) string {
+	return stdpath.Join(s.storage.GetStorage().MountPath, s.root, rel)
+}
+
+func (s *side) actualPath(rel string) string {
+	return stdpath.Join(s.root, rel)
+}
+
+// list lists all objects of the side recursively, the keys of objs are relative paths
+func (s *side) list(ctx context.Context) error {
+	s.objs = make(map[string]model.Obj)
+	obj, err := op.Get(ctx, s.storage, s.root)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get %s dir", s.name)
+	}
+	if !obj.IsDir() {
+		return errors.Errorf("%s path is not a dir", s.name)
+	}
+	return s.walk(ctx, "/")
+}
+
+func (s *side) walk(ctx context.Context, rel string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	dir := s.actualPath(rel)
+	objs, err := op.List(ctx, s.storage, dir, model.ListArgs{}, true)
+	if err != nil {
+		return errors.WithMessagef(err, "failed list %s", s.fullPath(rel))
+	}
+	for _, obj := range objs {
+		if dir == "/" && obj.GetName() == fs.TrashDirName {
+			continue
+		}
+		p := stdpath.Join(rel, obj.GetName())
+		s.objs[p] = obj
+		if obj.IsDir() {
+			if err := s.walk(ctx, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type bisync struct {
+	ctx      context.Context
+	job      *model.SyncJob
+	dryRun   bool
+	src, dst *side
+	states   map[string]*model.SyncState
+	actions  []Action
+	// handled paths are not planned again after renames are detected
+	handled map[string]bool
+	// failed paths keep their old states so that they are synced again in the next run
+	failed  map[string]bool
+	summary model.SyncSummary
+	errs    []string
+}
+
+func (b *bisync) fail(path string, err error) {
+	b.summary.Failed++
+	b.failed[path] = true
+	if len(b.errs) < maxErrors {
+		b.errs = append(b.errs, fmt.Sprintf("%s: %v", path, err))
+	}
+}
+
+func (b *bisync) other(s *side) *side {
+	if s == b.src {
+		return b.dst
+	}
+	return b.src
+}
+
+// runBisync propagates the creates, modifies, deletes and renames of each side to the other side,
+// the changes are detected by comparing the objects with the states saved by the last run
+func runBisync(ctx context.Context, job *model.SyncJob, dryRun bool) (model.SyncSummary, []Action) {
+	now := time.Now()
+	b := &bisync{
+		ctx:     ctx,
+		job:     job,
+		dryRun:  dryRun,
+		handled: make(map[string]bool),
+		failed:  make(map[string]bool),
+	}
+	b.summary.StartedAt = &now
+	if err := b.run(); err != nil {
+		b.summary.Failed++
+		b.errs = append(b.errs, err.Error())
+	}
+	finished := time.Now()
+	b.summary.FinishedAt = &finished
+	b.summary.Errors = strings.Join(b.errs, "\n")
+	return b.summary, b.actions
+}
+
+func (b *bisync) run() error {
+	b.src, b.dst = &side{name: SideSrc}, &side{name: SideDst}
+	var err error
+	b.src.storage, b.src.root, err = op.GetStorageAndActualPath(b.job.SrcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	b.dst.storage, b.dst.root, err = op.GetStorageAndActualPath(b.job.DstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	states, err := db.GetSyncStates(b.job.ID)
+	if err != nil {
+		return err
+	}
+	b.states = make(map[string]*model.SyncState, len(states))
+	for i := range states {
+		b.states[states[i].Path] = &states[i]
+	}
+	if err := b.src.list(b.ctx); err != nil {
+		return err
+	}
+	if err := b.dst.list(b.ctx); err != nil {
+		if !errs.IsObjectNotFound(err) || len(b.states) > 0 {
+			return err
+		}
+		// the dst dir will be created by the copy tasks of the first run
+		b.dst.objs = make(map[string]model.Obj)
+	}
+	// an empty side usually means that the storage is broken, nothing should be deleted
+	for _, s := range []*side{b.src, b.dst} {
+		if len(s.objs) == 0 && len(b.states) > 0 {
+			return errors.Errorf("%s is empty but it wasn't in last run, refuse to delete all objects of the other side", s.name)
+		}
+	}
+	b.detect(b.src)
+	b.detect(b.dst)
+	b.detectRenames(b.src)
+	b.detectRenames(b.dst)
+	b.plan()
+	if b.dryRun {
+		for _, a := range b.actions {
+			switch a.Type {
+			case ActionCopy:
+				b.summary.Copied++
+			case ActionDelete:
+				b.summary.Deleted++
+			case ActionRename:
+				b.summary.Renamed++
+			}
+		}
+		return nil
+	}
+	b.apply()
+	if b.ctx.Err() != nil {
+		return b.ctx.Err()
+	}
+	return b.saveStates()
+}
+
+// detect compares the objects of s with the states
+func (b *bisync) detect(s *side) {
+	s.changes = make(map[string]change)
+	for p, obj := range s.objs {
+		st, ok := b.states[p]
+		if !ok {
+			s.changes[p] = created
+		} else if changed(s, obj, st) {
+			s.changes[p] = modified
+		}
+	}
+	for p := range b.states {
+		if _, ok := s.objs[p]; !ok {
+			s.changes[p] = deleted
+		}
+	}
+}
+
+func stateOf(s *side, st *model.SyncState) (size int64, modified time.Time, hash utils.HashInfo) {
+	if s.name == SideSrc {
+		return st.SrcSize, st.SrcModified, utils.FromString(st.SrcHash)
+	}
+	return st.DstSize, st.DstModified, utils.FromString(st.DstHash)
+}
+
+func changed(s *side, obj model.Obj, st *model.SyncState) bool {
+	if obj.IsDir() != st.IsDir {
+		return true
+	}
+	if obj.IsDir() {
+		return false
+	}
+	size, modified, hash := stateOf(s, st)
+	if obj.GetSize() != size {
+		return true
+	}
+	if equal, ok := hashEqual(obj.GetHash(), hash); ok {
+		return !equal
+	}
+	return !sameModTime(obj.ModTime(), modified)
+}
+
+// detectRenames finds the files which are deleted and created with the same content on s,
+// the rename is done on the other side instead of deleting and copying if the other side isn't changed
+func (b *bisync) detectRenames(s *side) {
+	o := b.other(s)
+	createdBySize := make(map[int64][]string)
+	for p, c := range s.changes {
+		if c == created && !s.objs[p].IsDir() {
+			createdBySize[s.objs[p].GetSize()] = append(createdBySize[s.objs[p].GetSize()], p)
+		}
+	}
+	var deletes []string
+	for p, c := range s.changes {
+		if c == deleted && !b.states[p].IsDir {
+			deletes = append(deletes, p)
+		}
+	}
+	sort.Strings(deletes)
+	for _, from := range deletes {
+		if b.handled[from] || o.changes[from] != unchanged {
+			continue
+		}
+		st := b.states[from]
+		size, modified, hash := stateOf(s, st)
+		for _, to := range createdBySize[size] {
+			if b.handled[to] {
+				continue
+			}
+			if _, ok := o.objs[to]; ok {
+				continue
+			}
+			obj := s.objs[to]
+			if equal, ok := hashEqual(obj.GetHash(), hash); ok && !equal || !ok && !sameModTime(obj.ModTime(), modified) {
+				continue
+			}
+			b.handled[from], b.handled[to] = true, true
+			b.actions = append(b.actions, Action{Type: ActionRename, Side: o.name, Path: from, To: to, Reason: "renamed"})
+			break
+		}
+	}
+}
+
+func (b *bisync) plan() {
+	paths := make(map[string]struct{})
+	for _, s := range []*side{b.src, b.dst} {
+		for p := range s.objs {
+			paths[p] = struct{}{}
+		}
+	}
+	for p := range b.states {
+		paths[p] = struct{}{}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		if !b.handled[p] {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Strings(sorted)
+	var actions []Action
+	for _, p := range sorted {
+		sc, dc := b.src.changes[p], b.dst.changes[p]
+		switch {
+		case sc == unchanged && dc == unchanged, sc == deleted && dc == deleted:
+			b.summary.Skipped++
+		case sc == unchanged, sc == deleted && dc != unchanged:
+			actions = append(actions, b.propagate(b.dst, p, dc))
+		case dc == unchanged, dc == deleted:
+			actions = append(actions, b.propagate(b.src, p, sc))
+		default:
+			// changed on both sides
+			so, do := b.src.objs[p], b.dst.objs[p]
+			if sameContent(so, do) {
+				b.summary.Skipped++
+				continue
+			}
+			if so.IsDir() || do.IsDir() {
+				b.summary.Conflicts++
+				b.fail(p, errors.New("a file and a dir have the same path"))
+				continue
+			}
+			b.summary.Conflicts++
+			actions = append(actions, b.resolve(p, so, do)...)
+		}
+	}
+	b.actions = append(b.actions, prune(actions)...)
+}
+
+// propagate returns the action which applies the change c of path p on s to the other side
+func (b *bisync) propagate(s *side, p string, c change) Action {
+	o := b.other(s)
+	if c == deleted {
+		return Action{Type: ActionDelete, Side: o.name, Path: p, Reason: fmt.Sprintf("deleted on %s", s.name)}
+	}
+	reason := fmt.Sprintf("%s on %s", c, s.name)
+	if o.changes[p] == deleted {
+		reason += fmt.Sprintf(", restored on %s", o.name)
+	}
+	if s.objs[p].IsDir() {
+		return Action{Type: ActionMkdir, Side: o.name, Path: p, Reason: reason}
+	}
+	return Action{Type: ActionCopy, Side: o.name, Path: p, Reason: reason}
+}
+
+// resolve returns the actions to resolve the conflict of the file p which is changed on both sides
+func (b *bisync) resolve(p string, so, do model.Obj) []Action {
+	switch b.job.ConflictPolicy {
+	case model.SyncConflictSource:
+		return []Action{{Type: ActionCopy, Side: SideDst, Path: p, Reason: "conflict, source wins"}}
+	case model.SyncConflictKeepBoth:
+		to := conflictName(p, time.Now())
+		return []Action{
+			{Type: ActionRename, Side: SideDst, Path: p, To: to, Reason: "conflict, keep both"},
+			{Type: ActionCopy, Side: SideSrc, Path: to, Reason: "conflict, keep both"},
+			{Type: ActionCopy, Side: SideDst, Path: p, Reason: "conflict, keep both"},
+		}
+	default:
+		if do.ModTime().After(so.ModTime()) {
+			return []Action{{Type: ActionCopy, Side: SideSrc, Path: p, Reason: "conflict, dst is newer"}}
+		}
+		return []Action{{Type: ActionCopy, Side: SideDst, Path: p, Reason: "conflict, src is newer"}}
+	}
+}
+
+// conflictName returns the name of the dst file kept by SyncConflictKeepBoth
+func conflictName(p string, t time.Time) string {
+	ext := stdpath.Ext(p)
+	return fmt.Sprintf("%s.conflict-%s%s", strings.TrimSuffix(p, ext), t.Format("20060102-150405"), ext)
+}
+
+// prune removes the deletes of the objects in the dirs which are deleted,
+// the delete of a dir is dropped if anything in it is copied to the other side
+func prune(actions []Action) []Action {
+	keep := make([]bool, len(actions))
+	for i := range actions {
+		keep[i] = true
+	}
+	for i, a := range actions {
+		if a.Type != ActionDelete {
+			continue
+		}
+		prefix := a.Path + "/"
+		for _, c := range actions {
+			if c.Side != a.Side && strings.HasPrefix(c.Path, prefix) {
+				// something in the dir is copied from this side
+				keep[i] = false
+				break
+			}
+		}
+		if !keep[i] {
+			continue
+		}
+		for j, c := range actions {
+			if j != i && c.Type == ActionDelete && c.Side == a.Side && strings.HasPrefix(c.Path, prefix) {
+				keep[j] = false
+			}
+		}
+	}
+	var result []Action
+	for i, a := range actions {
+		if keep[i] {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+func (b *bisync) sideOf(name string) *side {
+	if name == SideSrc {
+		return b.src
+	}
+	return b.dst
+}
+
+// apply does the renames and deletes first, then the mkdirs and copies
+func (b *bisync) apply() {
+	for _, a := range b.actions {
+		if b.ctx.Err() != nil {
+			return
+		}
+		s := b.sideOf(a.Side)
+		switch a.Type {
+		case ActionRename:
+			if err := rename(b.ctx, s.fullPath(a.Path), s.fullPath(a.To)); err != nil {
+				b.fail(a.Path, err)
+				b.failed[a.To] = true
+			} else {
+				b.summary.Renamed++
+			}
+		case ActionDelete:
+			if err := fs.Remove(b.ctx, s.fullPath(a.Path)); err != nil {
+				b.fail(a.Path, err)
+			} else {
+				b.summary.Deleted++
+			}
+		}
+	}
+	var tasks []*fs.CopyTask
+	paths := make(map[*fs.CopyTask]string)
+	for _, a := range b.actions {
+		if b.ctx.Err() != nil {
+			return
+		}
+		s := b.sideOf(a.Side)
+		switch a.Type {
+		case ActionMkdir:
+			if err := fs.MakeDir(b.ctx, s.fullPath(a.Path)); err != nil {
+				b.fail(a.Path, err)
+			}
+		case ActionCopy:
+			from := b.other(s)
+			t := &fs.CopyTask{
+				SrcObjPath:   from.actualPath(a.Path),
+				DstDirPath:   stdpath.Dir(s.actualPath(a.Path)),
+				Override:     true,
+				SrcStorageMp: from.storage.GetStorage().MountPath,
+				DstStorageMp: s.storage.GetStorage().MountPath,
+			}
+			// the file kept by SyncConflictKeepBoth isn't in the listing
+			if obj, ok := from.objs[a.Path]; ok {
+				t.Size = obj.GetSize()
+			}
+			fs.CopyTaskManager.Add(t)
+			tasks = append(tasks, t)
+			paths[t] = a.Path
+		}
+	}
+	waitTasks(b.ctx, tasks, func(t *fs.CopyTask, err error) {
+		if err != nil {
+			b.fail(paths[t], err)
+		} else {
+			b.summary.Copied++
+		}
+	})
+}
+
+// rename moves the object at from to to, both are full paths
+func rename(ctx context.Context, from, to string) error {
+	if stdpath.Dir(from) != stdpath.Dir(to) {
+		if err := fs.MakeDir(ctx, stdpath.Dir(to)); err != nil {
+			return err
+		}
+		if err := fs.Move(ctx, from, stdpath.Dir(to)); err != nil {
+			return err
+		}
+		from = stdpath.Join(stdpath.Dir(to), stdpath.Base(from))
+	}
+	if stdpath.Base(from) == stdpath.Base(to) {
+		return nil
+	}
+	return fs.Rename(ctx, from, stdpath.Base(to))
+}
+
+// saveStates lists both sides again and saves the objects which exist on both sides,
+// the old states of the failed paths are kept
+func (b *bisync) saveStates() error {
+	if err := b.src.list(b.ctx); err != nil {
+		return err
+	}
+	if err := b.dst.list(b.ctx); err != nil {
+		return err
+	}
+	var states []model.SyncState
+	for p := range b.failed {
+		if st, ok := b.states[p]; ok {
+			st.ID = 0
+			states = append(states, *st)
+		}
+	}
+	for p, so := range b.src.objs {
+		do, ok := b.dst.objs[p]
+		if !ok || so.IsDir() != do.IsDir() || b.failed[p] {
+			continue
+		}
+		states = append(states, model.SyncState{
+			JobId:       b.job.ID,
+			Path:        p,
+			IsDir:       so.IsDir(),
+			SrcSize:     so.GetSize(),
+			SrcModified: so.ModTime(),
+			SrcHash:     so.GetHash().String(),
+			DstSize:     do.GetSize(),
+			DstModified: do.ModTime(),
+			DstHash:     do.GetHash().String(),
+		})
+	}
+	return db.ReplaceSyncStates(b.job.ID, states)
+}
@@ -0,0 +1,136 @@
+package syncjob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func file(size int64, mod time.Time) model.Obj {
+	return &model.Object{Size: size, Modified: mod}
+}
+
+func state(p string, size int64, mod time.Time) *model.SyncState {
+	return &model.SyncState{Path: p, SrcSize: size, SrcModified: mod, DstSize: size, DstModified: mod}
+}
+
+func planOf(src, dst map[string]model.Obj, states []*model.SyncState, policy string) []Action {
+	b := &bisync{
+		job:     &model.SyncJob{ConflictPolicy: policy},
+		src:     &side{name: SideSrc, objs: src},
+		dst:     &side{name: SideDst, objs: dst},
+		states:  make(map[string]*model.SyncState),
+		handled: make(map[string]bool),
+		failed:  make(map[string]bool),
+	}
+	for _, st := range states {
+		b.states[st.Path] = st
+	}
+	b.detect(b.src)
+	b.detect(b.dst)
+	b.detectRenames(b.src)
+	b.detectRenames(b.dst)
+	b.plan()
+	return b.actions
+}
+
+func TestBisyncPlan(t *testing.T) {
+	later := base.Add(time.Hour)
+	tests := []struct {
+		name   string
+		src    map[string]model.Obj
+		dst    map[string]model.Obj
+		states []*model.SyncState
+		policy string
+		want   []Action
+	}{
+		{
+			name:   "unchanged",
+			src:    map[string]model.Obj{"/a": file(1, base)},
+			dst:    map[string]model.Obj{"/a": file(1, base)},
+			states: []*model.SyncState{state("/a", 1, base)},
+		},
+		{
+			name: "created on src",
+			src:  map[string]model.Obj{"/a": file(1, base)},
+			dst:  map[string]model.Obj{},
+			want: []Action{{Type: ActionCopy, Side: SideDst, Path: "/a"}},
+		},
+		{
+			name:   "modified on dst",
+			src:    map[string]model.Obj{"/a": file(1, base)},
+			dst:    map[string]model.Obj{"/a": file(2, later)},
+			states: []*model.SyncState{state("/a", 1, base)},
+			want:   []Action{{Type: ActionCopy, Side: SideSrc, Path: "/a"}},
+		},
+		{
+			name:   "deleted on dst",
+			src:    map[string]model.Obj{"/a": file(1, base), "/b": file(1, base)},
+			dst:    map[string]model.Obj{"/b": file(1, base)},
+			states: []*model.SyncState{state("/a", 1, base), state("/b", 1, base)},
+			want:   []Action{{Type: ActionDelete, Side: SideSrc, Path: "/a"}},
+		},
+		{
+			name:   "deleted on src and modified on dst",
+			src:    map[string]model.Obj{"/b": file(1, base)},
+			dst:    map[string]model.Obj{"/a": file(2, later), "/b": file(1, base)},
+			states: []*model.SyncState{state("/a", 1, base), state("/b", 1, base)},
+			want:   []Action{{Type: ActionCopy, Side: SideSrc, Path: "/a"}},
+		},
+		{
+			name:   "renamed on src",
+			src:    map[string]model.Obj{"/dir/b": file(1, base)},
+			dst:    map[string]model.Obj{"/a": file(1, base)},
+			states: []*model.SyncState{state("/a", 1, base)},
+			want:   []Action{{Type: ActionRename, Side: SideDst, Path: "/a", To: "/dir/b"}},
+		},
+		{
+			name:   "conflict newer wins",
+			src:    map[string]model.Obj{"/a": file(2, later)},
+			dst:    map[string]model.Obj{"/a": file(3, base.Add(time.Minute))},
+			states: []*model.SyncState{state("/a", 1, base)},
+			want:   []Action{{Type: ActionCopy, Side: SideDst, Path: "/a"}},
+		},
+		{
+			name:   "conflict source wins",
+			src:    map[string]model.Obj{"/a": file(2, base.Add(time.Minute))},
+			dst:    map[string]model.Obj{"/a": file(3, later)},
+			states: []*model.SyncState{state("/a", 1, base)},
+			policy: model.SyncConflictSource,
+			want:   []Action{{Type: ActionCopy, Side: SideDst, Path: "/a"}},
+		},
+		{
+			name: "deleted dir",
+			src:  map[string]model.Obj{},
+			dst:  map[string]model.Obj{"/d": &model.Object{IsFolder: true}, "/d/a": file(1, base), "/e": file(1, base)},
+			states: []*model.SyncState{
+				{Path: "/d", IsDir: true}, state("/d/a", 1, base), state("/e", 1, base), state("/f", 1, base),
+			},
+			want: []Action{{Type: ActionDelete, Side: SideDst, Path: "/d"}, {Type: ActionDelete, Side: SideDst, Path: "/e"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planOf(tt.src, tt.dst, tt.states, tt.policy)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				g, w := got[i], tt.want[i]
+				if g.Type != w.Type || g.Side != w.Side || g.Path != w.Path || g.To != w.To {
+					t.Errorf("action %d: got %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestConflictName(t *testing.T) {
+	got := conflictName("/dir/a.txt", base)
+	if want := "/dir/a.conflict-20240101-000000.txt"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,226 @@
+package syncjob
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+// modTimeWindow is the max difference of modified time between two files which are treated as same,
+// some storages only keep the modified time in seconds
+const modTimeWindow = time.Second
+
+// maxErrors is the max count of errors kept in the summary
+const maxErrors = 100
+
+type mirror struct {
+	ctx        context.Context
+	job        *model.SyncJob
+	srcStorage driver.Driver
+	dstStorage driver.Driver
+	// dryRun only records the actions without doing them
+	dryRun  bool
+	actions []Action
+	// srcRoot and dstRoot are the actual paths of the job in the storages
+	srcRoot string
+	dstRoot string
+	summary model.SyncSummary
+	errs    []string
+	tasks   []*fs.CopyTask
+}
+
+func (m *mirror) srcMp() string {
+	return m.srcStorage.GetStorage().MountPath
+}
+
+func (m *mirror) dstMp() string {
+	return m.dstStorage.GetStorage().MountPath
+}
+
+func (m *mirror) fail(path string, err error) {
+	m.summary.Failed++
+	if len(m.errs) < maxErrors {
+		m.errs = append(m.errs, fmt.Sprintf("%s: %v", path, err))
+	}
+}
+
+// runMirror copies the new or changed files of SrcPath to DstPath and waits for the copy tasks
+func runMirror(ctx context.Context, job *model.SyncJob, dryRun bool) (model.SyncSummary, []Action) {
+	now := time.Now()
+	m := &mirror{ctx: ctx, job: job, dryRun: dryRun}
+	m.summary.StartedAt = &now
+	if err := m.run(); err != nil {
+		m.fail(job.SrcPath, err)
+	}
+	finished := time.Now()
+	m.summary.FinishedAt = &finished
+	m.summary.Errors = strings.Join(m.errs, "\n")
+	return m.summary, m.actions
+}
+
+func (m *mirror) run() error {
+	var srcPath, dstPath string
+	var err error
+	m.srcStorage, srcPath, err = op.GetStorageAndActualPath(m.job.SrcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	m.dstStorage, dstPath, err = op.GetStorageAndActualPath(m.job.DstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	srcObj, err := op.Get(m.ctx, m.srcStorage, srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src dir")
+	}
+	if !srcObj.IsDir() {
+		return errors.New("src path is not a dir")
+	}
+	dstObj, err := op.Get(m.ctx, m.dstStorage, dstPath)
+	if err != nil && !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed get dst dir")
+	}
+	if err == nil && !dstObj.IsDir() {
+		return errors.New("dst path is not a dir")
+	}
+	m.srcRoot, m.dstRoot = srcPath, dstPath
+	m.syncDir(srcPath, dstPath, err != nil)
+	m.wait()
+	return nil
+}
+
+// syncDir compares the objects in srcDir and dstDir recursively and adds copy tasks,
+// dstMissing means that dstDir doesn't exist so that there is nothing to compare
+func (m *mirror) syncDir(srcDir, dstDir string, dstMissing bool) {
+	if m.ctx.Err() != nil {
+		return
+	}
+	srcObjs, err := op.List(m.ctx, m.srcStorage, srcDir, model.ListArgs{}, true)
+	if err != nil {
+		m.fail(stdpath.Join(m.srcMp(), srcDir), err)
+		return
+	}
+	dstObjs := make(map[string]model.Obj)
+	if !dstMissing {
+		objs, err := op.List(m.ctx, m.dstStorage, dstDir, model.ListArgs{}, true)
+		if err != nil {
+			m.fail(stdpath.Join(m.dstMp(), dstDir), err)
+			return
+		}
+		for _, obj := range objs {
+			if dstDir == "/" && obj.GetName() == fs.TrashDirName {
+				continue
+			}
+			dstObjs[obj.GetName()] = obj
+		}
+	}
+	for _, srcObj := range srcObjs {
+		name := srcObj.GetName()
+		if srcDir == "/" && name == fs.TrashDirName {
+			continue
+		}
+		srcPath, dstPath := stdpath.Join(srcDir, name), stdpath.Join(dstDir, name)
+		dstObj, exist := dstObjs[name]
+		delete(dstObjs, name)
+		if exist && dstObj.IsDir() != srcObj.IsDir() {
+			// the types are different, the dst object has to be removed first
+			if !m.job.Delete {
+				m.fail(stdpath.Join(m.dstMp(), dstPath), errors.New("object of different type exists"))
+				continue
+			}
+			if !m.remove(dstPath) {
+				continue
+			}
+			exist = false
+		}
+		if srcObj.IsDir() {
+			m.syncDir(srcPath, dstPath, !exist)
+			continue
+		}
+		if exist && !needCopy(srcObj, dstObj) {
+			m.summary.Skipped++
+			continue
+		}
+		m.copy(srcPath, dstDir, srcObj.GetSize(), exist)
+	}
+	if m.job.Delete {
+		for name := range dstObjs {
+			m.remove(stdpath.Join(dstDir, name))
+		}
+	}
+}
+
+// needCopy reports whether src differs from dst, the hash is used if both have the same type of hash,
+// otherwise the file is copied if src is newer than dst
+func needCopy(src, dst model.Obj) bool {
+	if src.GetSize() != dst.GetSize() {
+		return true
+	}
+	if equal, ok := hashEqual(src.GetHash(), dst.GetHash()); ok {
+		return !equal
+	}
+	return src.ModTime().Sub(dst.ModTime()) > modTimeWindow
+}
+
+// relPath returns the path relative to the root of the job
+func relPath(root, path string) string {
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(path, root), "/")
+}
+
+func (m *mirror) copy(srcPath, dstDir string, size int64, exist bool) {
+	if m.dryRun {
+		reason := "created"
+		if exist {
+			reason = "modified"
+		}
+		m.actions = append(m.actions, Action{Type: ActionCopy, Side: SideDst, Path: relPath(m.srcRoot, srcPath), Reason: reason})
+		m.summary.Copied++
+		return
+	}
+	t := &fs.CopyTask{
+		SrcObjPath:   srcPath,
+		DstDirPath:   dstDir,
+		Override:     true,
+		SrcStorageMp: m.srcMp(),
+		DstStorageMp: m.dstMp(),
+		Size:         size,
+	}
+	fs.CopyTaskManager.Add(t)
+	m.tasks = append(m.tasks, t)
+}
+
+// remove removes the dst object through fs.Remove, so that it's moved into the trash if enabled
+func (m *mirror) remove(dstPath string) bool {
+	if m.dryRun {
+		m.actions = append(m.actions, Action{Type: ActionDelete, Side: SideDst, Path: relPath(m.dstRoot, dstPath), Reason: "extraneous"})
+		m.summary.Deleted++
+		return true
+	}
+	path := stdpath.Join(m.dstMp(), dstPath)
+	if err := fs.Remove(m.ctx, path); err != nil {
+		m.fail(path, err)
+		return false
+	}
+	m.summary.Deleted++
+	return true
+}
+
+// wait waits for all copy tasks to finish
+func (m *mirror) wait() {
+	waitTasks(m.ctx, m.tasks, func(t *fs.CopyTask, err error) {
+		if err != nil {
+			m.fail(stdpath.Join(t.SrcStorageMp, t.SrcObjPath), err)
+		} else {
+			m.summary.Copied++
+		}
+	})
+}
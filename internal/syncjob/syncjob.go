@@ -0,0 +1,235 @@
+// Package syncjob runs the sync jobs between storages periodically
+package syncjob
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	mu      sync.Mutex
+	crons   = make(map[uint]*cron.Cron)
+	running = make(map[uint]context.CancelFunc)
+)
+
+// Init schedules all enabled sync jobs
+func Init() {
+	jobs, err := db.GetEnabledSyncJobs()
+	if err != nil {
+		log.Errorf("failed get sync jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		schedule(&jobs[i])
+	}
+}
+
+func schedule(job *model.SyncJob) {
+	mu.Lock()
+	defer mu.Unlock()
+	if c, ok := crons[job.ID]; ok {
+		// Stop blocks until the running job finishes
+		go c.Stop()
+		delete(crons, job.ID)
+	}
+	if job.Disabled || job.Interval <= 0 {
+		return
+	}
+	id := job.ID
+	c := cron.NewCron(time.Duration(job.Interval) * time.Minute)
+	c.Do(func() {
+		if _, err := Run(id); err != nil && !errors.Is(err, errs.SyncJobRunning) {
+			log.Errorf("failed run sync job %d: %+v", id, err)
+		}
+	})
+	crons[id] = c
+}
+
+func begin(id uint) (context.Context, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := running[id]; ok {
+		return nil, errs.SyncJobRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	running[id] = cancel
+	return ctx, nil
+}
+
+func end(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	if cancel, ok := running[id]; ok {
+		cancel()
+		delete(running, id)
+	}
+}
+
+func execute(ctx context.Context, job *model.SyncJob, dryRun bool) (model.SyncSummary, []Action) {
+	if job.Mode == model.SyncModeBisync {
+		return runBisync(ctx, job, dryRun)
+	}
+	return runMirror(ctx, job, dryRun)
+}
+
+func run(ctx context.Context, id uint) (*model.SyncSummary, error) {
+	job, err := db.GetSyncJobById(id)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("start sync job [%s]: %s -> %s", job.Name, job.SrcPath, job.DstPath)
+	summary, _ := execute(ctx, job, false)
+	log.Infof("sync job [%s] finished: copied %d, skipped %d, deleted %d, renamed %d, failed %d",
+		job.Name, summary.Copied, summary.Skipped, summary.Deleted, summary.Renamed, summary.Failed)
+	if err := db.UpdateSyncJobSummary(id, summary); err != nil {
+		return &summary, err
+	}
+	return &summary, nil
+}
+
+// Run runs the job and waits until it finishes
+func Run(id uint) (*model.SyncSummary, error) {
+	ctx, err := begin(id)
+	if err != nil {
+		return nil, err
+	}
+	defer end(id)
+	return run(ctx, id)
+}
+
+// Start runs the job in background
+func Start(id uint) error {
+	ctx, err := begin(id)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer end(id)
+		if _, err := run(ctx, id); err != nil {
+			log.Errorf("failed run sync job %d: %+v", id, err)
+		}
+	}()
+	return nil
+}
+
+// DryRun returns the actions which the job would do without doing them,
+// the counts in the summary are the counts of the planned actions
+func DryRun(id uint) (*model.SyncSummary, []Action, error) {
+	job, err := db.GetSyncJobById(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	summary, actions := execute(context.Background(), job, true)
+	return &summary, actions, nil
+}
+
+// Stop cancels the running job, the copy tasks which haven't finished are canceled too
+func Stop(id uint) {
+	end(id)
+}
+
+func IsRunning(id uint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, ok := running[id]
+	return ok
+}
+
+func check(job *model.SyncJob) error {
+	job.SrcPath = utils.FixAndCleanPath(job.SrcPath)
+	job.DstPath = utils.FixAndCleanPath(job.DstPath)
+	if job.Interval < 0 {
+		return errs.InvalidSyncPeriod
+	}
+	switch job.Mode {
+	case "", model.SyncModeMirror, model.SyncModeBisync:
+	default:
+		return errors.Wrapf(errs.InvalidSyncJob, "unknown mode: %s", job.Mode)
+	}
+	switch job.ConflictPolicy {
+	case "", model.SyncConflictNewer, model.SyncConflictKeepBoth, model.SyncConflictSource:
+	default:
+		return errors.Wrapf(errs.InvalidSyncJob, "unknown conflict policy: %s", job.ConflictPolicy)
+	}
+	if isSubPath(job.SrcPath, job.DstPath) || isSubPath(job.DstPath, job.SrcPath) {
+		return errs.SyncPathsOverlap
+	}
+	return nil
+}
+
+// isSubPath reports whether sub is path itself or in path
+func isSubPath(path, sub string) bool {
+	return path == sub || path == "/" || strings.HasPrefix(sub, path+"/")
+}
+
+func GetJob(id uint) (*model.SyncJob, error) {
+	job, err := db.GetSyncJobById(id)
+	if err != nil {
+		return nil, err
+	}
+	job.Running = IsRunning(id)
+	return job, nil
+}
+
+func GetJobs(pageIndex, pageSize int) ([]model.SyncJob, int64, error) {
+	jobs, total, err := db.GetSyncJobs(pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range jobs {
+		jobs[i].Running = IsRunning(jobs[i].ID)
+	}
+	return jobs, total, nil
+}
+
+func CreateJob(job *model.SyncJob) error {
+	if err := check(job); err != nil {
+		return err
+	}
+	job.ID = 0
+	job.LastRun = model.SyncSummary{}
+	if err := db.CreateSyncJob(job); err != nil {
+		return err
+	}
+	schedule(job)
+	return nil
+}
+
+// UpdateJob updates the settings of the job, the summary of last run is kept
+func UpdateJob(job *model.SyncJob) error {
+	if err := check(job); err != nil {
+		return err
+	}
+	old, err := db.GetSyncJobById(job.ID)
+	if err != nil {
+		return err
+	}
+	job.LastRun = old.LastRun
+	if err := db.UpdateSyncJob(job); err != nil {
+		return err
+	}
+	if job.SrcPath != old.SrcPath || job.DstPath != old.DstPath || job.Mode != old.Mode {
+		// the states don't match the paths any more
+		if err := db.DeleteSyncStatesByJobId(job.ID); err != nil {
+			return err
+		}
+	}
+	schedule(job)
+	return nil
+}
+
+func DeleteJob(id uint) error {
+	schedule(&model.SyncJob{ID: id, Disabled: true})
+	Stop(id)
+	return db.DeleteSyncJobById(id)
+}
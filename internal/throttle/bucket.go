@@ -0,0 +1,132 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// window is the number of seconds that the throughput is averaged over
+const window = 5
+
+type bucket struct {
+	scope   string
+	dir     Direction
+	limiter *rate.Limiter
+
+	mu    sync.Mutex
+	limit int64
+	total int64
+	last  time.Time
+	// used is the last time the bucket is got for a transfer
+	used time.Time
+	// bytes transferred in each of the last seconds, indexed by unix second % window
+	counts [window]int64
+	secs   [window]int64
+}
+
+// setLimit sets the limit in bytes/s, 0 means no limit
+func (b *bucket) setLimit(limit int64) {
+	if limit < 0 {
+		limit = 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit == limit {
+		return
+	}
+	b.limit = limit
+	if limit == 0 {
+		b.limiter.SetLimit(rate.Inf)
+		return
+	}
+	burst := limit
+	if burst < minBurst {
+		burst = minBurst
+	}
+	b.limiter.SetBurst(int(burst))
+	b.limiter.SetLimit(rate.Limit(limit))
+}
+
+// burst returns the max bytes that can be waited for at once, 0 if not limited
+func (b *bucket) burst() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit == 0 {
+		return 0
+	}
+	return b.limiter.Burst()
+}
+
+// wait blocks until n bytes are allowed and records them
+func (b *bucket) wait(ctx context.Context, n int) error {
+	b.record(n)
+	for n > 0 {
+		chunk := n
+		if burst := b.burst(); burst == 0 {
+			return nil
+		} else if chunk > burst {
+			chunk = burst
+		}
+		if err := b.limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+func (b *bucket) touch(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used = now
+}
+
+// idle returns how long the bucket hasn't been used or transferred anything
+func (b *bucket) idle(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	last := b.last
+	if b.used.After(last) {
+		last = b.used
+	}
+	return now.Sub(last)
+}
+
+func (b *bucket) record(n int) {
+	now := time.Now()
+	sec := now.Unix()
+	i := sec % window
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.secs[i] != sec {
+		b.secs[i] = sec
+		b.counts[i] = 0
+	}
+	b.counts[i] += int64(n)
+	b.total += int64(n)
+	b.last = now
+}
+
+func (b *bucket) stat(now time.Time) Stat {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var sum int64
+	sec := now.Unix()
+	for i := range b.secs {
+		// the current second is not complete, so the window ends at the last second
+		if b.secs[i] < sec && b.secs[i] >= sec-window {
+			sum += b.counts[i]
+		}
+	}
+	return Stat{
+		Scope:     b.scope,
+		Direction: b.dir,
+		Limit:     b.limit,
+		Rate:      sum / window,
+		Total:     b.total,
+		Last:      b.last,
+	}
+}
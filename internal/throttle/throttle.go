@@ -0,0 +1,166 @@
+// Package throttle limits the bandwidth of downloads and uploads with token buckets.
+// A transfer is limited by the global bucket, the bucket of the storage and the bucket of the user at the same time,
+// the user is taken from the "user" value of ctx and the storage is set by WithStorage.
+// All limits are in KiB/s and 0 means no limit, the buckets also meter the throughput so that it can be inspected.
+// The buckets of the storages and the users are evicted after being idle for idleTimeout.
+package throttle
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"golang.org/x/time/rate"
+)
+
+type Direction string
+
+const (
+	Download Direction = "download"
+	Upload   Direction = "upload"
+)
+
+const (
+	minBurst = 4 * 1024
+	// buckets which have been idle for so long are not listed in Stats and evicted
+	idleTimeout = time.Hour
+)
+
+type key struct {
+	scope string
+	dir   Direction
+}
+
+var (
+	mu      sync.Mutex
+	buckets = make(map[key]*bucket)
+	swept   time.Time
+)
+
+func get(scope string, dir Direction, limit int64) *bucket {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	sweep(now)
+	k := key{scope: scope, dir: dir}
+	b, ok := buckets[k]
+	if !ok {
+		b = &bucket{scope: scope, dir: dir, limiter: rate.NewLimiter(rate.Inf, minBurst)}
+		buckets[k] = b
+	}
+	b.setLimit(limit * 1024)
+	b.touch(now)
+	return b
+}
+
+// sweep evicts the idle buckets except the global ones at most once per idleTimeout, mu must be held.
+// The readers still holding an evicted bucket keep using it, and the next transfer gets a new one
+func sweep(now time.Time) {
+	if now.Sub(swept) < idleTimeout {
+		return
+	}
+	swept = now
+	for k, b := range buckets {
+		if k.scope != "global" && b.idle(now) > idleTimeout {
+			delete(buckets, k)
+		}
+	}
+}
+
+type storageKey struct{}
+
+// WithStorage returns a copy of ctx in which the transfers are also limited by storage
+func WithStorage(ctx context.Context, storage *model.Storage) context.Context {
+	return context.WithValue(ctx, storageKey{}, storage)
+}
+
+func limits(ctx context.Context, dir Direction) []*bucket {
+	globalKey, pick := conf.DownloadLimit, func(d, u int64) int64 { return d }
+	if dir == Upload {
+		globalKey, pick = conf.UploadLimit, func(d, u int64) int64 { return u }
+	}
+	bs := []*bucket{get("global", dir, int64(setting.GetInt(globalKey, 0)))}
+	if storage, ok := ctx.Value(storageKey{}).(*model.Storage); ok && storage != nil {
+		bs = append(bs, get("storage:"+storage.MountPath, dir, pick(storage.DownloadLimit, storage.UploadLimit)))
+	}
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		bs = append(bs, get("user:"+user.Username, dir, pick(user.DownloadLimit, user.UploadLimit)))
+	}
+	return bs
+}
+
+// NewReader returns a reader that reads from r no faster than the limits of ctx in direction dir
+func NewReader(ctx context.Context, dir Direction, r io.Reader) io.Reader {
+	return &reader{ctx: ctx, r: r, buckets: limits(ctx, dir)}
+}
+
+// NewReadCloser is like NewReader but keeps the Close of rc
+func NewReadCloser(ctx context.Context, dir Direction, rc io.ReadCloser) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{NewReader(ctx, dir, rc), rc}
+}
+
+type reader struct {
+	ctx     context.Context
+	r       io.Reader
+	buckets []*bucket
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	// a limited bucket can't grant more than its burst at once
+	for _, b := range r.buckets {
+		if burst := b.burst(); burst > 0 && len(p) > burst {
+			p = p[:burst]
+		}
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		for _, b := range r.buckets {
+			if werr := b.wait(r.ctx, n); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}
+	return n, err
+}
+
+// Stat is the current state of a bucket
+type Stat struct {
+	Scope     string    `json:"scope"`
+	Direction Direction `json:"direction"`
+	// Limit is in bytes/s, 0 means no limit
+	Limit int64 `json:"limit"`
+	// Rate is the average throughput in bytes/s of the last few seconds
+	Rate  int64     `json:"rate"`
+	Total int64     `json:"total"`
+	Last  time.Time `json:"last"`
+}
+
+// Stats returns the state of the global buckets and the buckets which have been used recently
+func Stats() []Stat {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	stats := make([]Stat, 0, len(buckets))
+	for k, b := range buckets {
+		s := b.stat(now)
+		if k.scope != "global" && now.Sub(s.Last) > idleTimeout {
+			continue
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Scope != stats[j].Scope {
+			return stats[i].Scope < stats[j].Scope
+		}
+		return stats[i].Direction < stats[j].Direction
+	})
+	return stats
+}
@@ -0,0 +1,193 @@
+// Package extract extracts the plain text of documents for the content search
+package extract
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+)
+
+// Plain reads at most limit bytes of the text
+func Plain(r io.Reader, limit int) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	// the last rune cut by the limit is dropped as well
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// HTML returns the text of the html without the scripts and styles
+func HTML(r io.Reader, limit int) (string, error) {
+	w := newWriter(limit)
+	z := html.NewTokenizer(r)
+	skip := 0
+	for !w.full() {
+		switch z.Next() {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return w.String(), nil
+			}
+			return w.String(), errors.WithStack(z.Err())
+		case html.StartTagToken:
+			if name, _ := z.TagName(); isSkippedTag(name) {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); isSkippedTag(name) && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				w.write(string(z.Text()))
+			}
+		}
+	}
+	return w.String(), nil
+}
+
+func isSkippedTag(name []byte) bool {
+	return string(name) == "script" || string(name) == "style"
+}
+
+// PDF returns the text of the pdf
+func PDF(r io.ReaderAt, size int64, limit int) (text string, err error) {
+	// the pdf library panics on some malformed files
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("failed read pdf: %v", e)
+		}
+	}()
+	reader, err := pdf.NewReader(r, size)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	w := newWriter(limit)
+	for i := 1; i <= reader.NumPage() && !w.full(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		content, err := page.GetPlainText(nil)
+		if err != nil {
+			return w.String(), errors.WithStack(err)
+		}
+		w.write(content)
+	}
+	return w.String(), nil
+}
+
+// Docx returns the text of the paragraphs of the docx
+func Docx(r io.ReaderAt, size int64, limit int) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	w := newWriter(limit)
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		err = xmlText(f, w, "t", "p")
+		break
+	}
+	return w.String(), err
+}
+
+// Xlsx returns the text of the shared strings and the inline strings of the sheets of the xlsx,
+// the numbers are not included
+func Xlsx(r io.ReaderAt, size int64, limit int) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var files []*zip.File
+	for _, f := range zr.File {
+		if f.Name == "xl/sharedStrings.xml" || strings.HasPrefix(f.Name, "xl/worksheets/") && path.Ext(f.Name) == ".xml" {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	w := newWriter(limit)
+	for _, f := range files {
+		if w.full() {
+			break
+		}
+		if err := xmlText(f, w, "t", "si"); err != nil {
+			return w.String(), err
+		}
+	}
+	return w.String(), nil
+}
+
+// xmlText writes the char data of the elements named textElem,
+// a new line is written at the end of each element named breakElem
+func xmlText(f *zip.File, w *writer, textElem, breakElem string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer rc.Close()
+	d := xml.NewDecoder(rc)
+	inText := false
+	for !w.full() {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			inText = t.Name.Local == textElem
+		case xml.EndElement:
+			inText = false
+			if t.Name.Local == breakElem {
+				w.write("\n")
+			}
+		case xml.CharData:
+			if inText {
+				w.write(string(t))
+			}
+		}
+	}
+	return nil
+}
+
+// writer collects the text until the limit is reached
+type writer struct {
+	sb        strings.Builder
+	limit     int
+	truncated bool
+}
+
+func newWriter(limit int) *writer {
+	return &writer{limit: limit}
+}
+
+func (w *writer) write(s string) {
+	if rest := w.limit - w.sb.Len(); len(s) > rest {
+		// the last rune cut by the limit is dropped as well
+		s = s[:rest]
+		w.truncated = true
+	}
+	w.sb.WriteString(strings.ToValidUTF8(s, ""))
+}
+
+func (w *writer) full() bool {
+	return w.truncated || w.sb.Len() >= w.limit
+}
+
+func (w *writer) String() string {
+	return w.sb.String()
+}
@@ -0,0 +1,44 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPlain(t *testing.T) {
+	// the rune cut by the limit is dropped
+	text, err := Plain(strings.NewReader("ab中文"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "ab" {
+		t.Errorf("expect ab, got %q", text)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	text, err := HTML(strings.NewReader(`<html><head><script>var a</script></head><body><p>hello <b>world</b></p></body></html>`), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello world" {
+		t.Errorf("expect hello world, got %q", text)
+	}
+}
+
+func TestDocx(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	_, _ = w.Write([]byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>hello</w:t></w:r><w:r><w:t xml:space="preserve"> world</w:t></w:r></w:p><w:p><w:r><w:t>again</w:t></w:r></w:p></w:body></w:document>`))
+	_ = zw.Close()
+	text, err := Docx(bytes.NewReader(buf.Bytes()), int64(buf.Len()), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello world\nagain\n" {
+		t.Errorf("expect paragraphs, got %q", text)
+	}
+}
@@ -99,3 +99,15 @@ func SliceReplace[T any](arr []T, replace func(src T) T) {
 		arr[i] = replace(src)
 	}
 }
+
+// SlicePage returns the items of the page, page starts from 1
+func SlicePage[T any](arr []T, page, perPage int) []T {
+	start, end := len(arr), len(arr)
+	if page-1 < len(arr)/perPage+1 {
+		start = (page - 1) * perPage
+	}
+	if perPage < len(arr)-start {
+		end = start + perPage
+	}
+	return arr[start:end]
+}
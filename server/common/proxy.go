@@ -10,6 +10,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/net"
 	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/internal/throttle"
 	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -23,7 +24,11 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 		if contentType != "" {
 			w.Header().Set("Content-Type", contentType)
 		}
-		http.ServeContent(w, r, file.GetName(), file.ModTime(), link.MFile)
+		content := struct {
+			io.Reader
+			io.Seeker
+		}{throttle.NewReader(r.Context(), throttle.Download, link.MFile), link.MFile}
+		http.ServeContent(w, r, file.GetName(), file.ModTime(), content)
 		return nil
 	} else if link.RangeReadCloser != nil {
 		attachFileName(w, file)
@@ -72,7 +77,7 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 		if r.Method == http.MethodHead {
 			return nil
 		}
-		_, err = io.Copy(w, res.Body)
+		_, err = io.Copy(w, throttle.NewReader(r.Context(), throttle.Download, res.Body))
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,729 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type command struct {
+	fn func(s *session, param string)
+	// can be used before login
+	open bool
+	// can be used before AUTH TLS when tls is forced
+	noTLS bool
+	// opens or uses the data connection, which must be protected by PROT P when tls is forced
+	data bool
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"USER": {fn: (*session).handleUSER, open: true},
+		"PASS": {fn: (*session).handlePASS, open: true},
+		"AUTH": {fn: (*session).handleAUTH, open: true, noTLS: true},
+		"PBSZ": {fn: (*session).handlePBSZ, open: true},
+		"PROT": {fn: (*session).handlePROT, open: true},
+		"FEAT": {fn: (*session).handleFEAT, open: true, noTLS: true},
+		"SYST": {fn: (*session).handleSYST, open: true, noTLS: true},
+		"NOOP": {fn: (*session).handleNOOP, open: true, noTLS: true},
+		"OPTS": {fn: (*session).handleOPTS, open: true},
+		"PWD":  {fn: (*session).handlePWD},
+		"XPWD": {fn: (*session).handlePWD},
+		"CWD":  {fn: (*session).handleCWD},
+		"XCWD": {fn: (*session).handleCWD},
+		"CDUP": {fn: (*session).handleCDUP},
+		"XCUP": {fn: (*session).handleCDUP},
+		"TYPE": {fn: (*session).handleTYPE},
+		"MODE": {fn: (*session).handleMODE},
+		"STRU": {fn: (*session).handleSTRU},
+		"ALLO": {fn: (*session).handleALLO},
+		"PASV": {fn: (*session).handlePASV, data: true},
+		"EPSV": {fn: (*session).handleEPSV, data: true},
+		"PORT": {fn: (*session).handlePORT, data: true},
+		"EPRT": {fn: (*session).handleEPRT, data: true},
+		"LIST": {fn: (*session).handleLIST, data: true},
+		"NLST": {fn: (*session).handleNLST, data: true},
+		"MLSD": {fn: (*session).handleMLSD, data: true},
+		"MLST": {fn: (*session).handleMLST},
+		"SIZE": {fn: (*session).handleSIZE},
+		"MDTM": {fn: (*session).handleMDTM},
+		"REST": {fn: (*session).handleREST},
+		"RETR": {fn: (*session).handleRETR, data: true},
+		"STOR": {fn: (*session).handleSTOR, data: true},
+		"APPE": {fn: (*session).handleAPPE, data: true},
+		"DELE": {fn: (*session).handleDELE},
+		"MKD":  {fn: (*session).handleMKD},
+		"XMKD": {fn: (*session).handleMKD},
+		"RMD":  {fn: (*session).handleRMD},
+		"XRMD": {fn: (*session).handleRMD},
+		"RNFR": {fn: (*session).handleRNFR},
+		"RNTO": {fn: (*session).handleRNTO},
+		"ABOR": {fn: (*session).handleABOR},
+	}
+}
+
+// handle dispatches a command, it returns true if the session should be closed
+func (s *session) handle(cmd, param string) bool {
+	if cmd == "QUIT" {
+		s.waitTransfer()
+		s.reply(221, "Goodbye")
+		return true
+	}
+	c, ok := commands[cmd]
+	if !ok {
+		s.reply(502, fmt.Sprintf("Command %s not implemented", cmd))
+		return false
+	}
+	if s.server.ForceTLS && !s.tlsCtrl && !c.noTLS {
+		s.reply(534, "TLS is required, use AUTH TLS first")
+		return false
+	}
+	if !c.open && s.user == nil {
+		s.reply(530, "Please login with USER and PASS")
+		return false
+	}
+	if s.server.ForceTLS && c.data && !s.tlsData {
+		s.reply(521, "Data connection must be protected, use PROT P first")
+		return false
+	}
+	// the commands are handled after the running transfer except ABOR
+	if cmd != "ABOR" {
+		s.waitTransfer()
+	}
+	// REST only applies to the next command
+	if cmd != "REST" && cmd != "RETR" && cmd != "STOR" && cmd != "APPE" {
+		s.restOffset = 0
+	}
+	if cmd != "RNTO" && cmd != "RNFR" {
+		s.renameFrom = ""
+	}
+	c.fn(s, param)
+	return false
+}
+
+func (s *session) replyErr(err error) {
+	log.Debugf("[ftp] %s: %+v", s.conn.RemoteAddr(), err)
+	switch {
+	case errs.IsObjectNotFound(err), errs.IsNotFoundError(err):
+		s.reply(550, "No such file or directory")
+	case errs.IsNotSupportError(err), errs.IsNotImplement(err):
+		s.reply(504, err.Error())
+	case errors.Is(err, errs.PermissionDenied):
+		s.reply(550, "Permission denied")
+	default:
+		s.reply(550, err.Error())
+	}
+}
+
+func (s *session) handleUSER(param string) {
+	if s.user != nil {
+		s.reply(530, "Already logged in")
+		return
+	}
+	s.username = param
+	s.reply(331, "User name okay, need password")
+}
+
+func (s *session) handlePASS(param string) {
+	if s.user != nil {
+		s.reply(230, "Already logged in")
+		return
+	}
+	if s.username == "" {
+		s.reply(503, "Login with USER first")
+		return
+	}
+	var user *model.User
+	var err error
+	if s.username == "anonymous" {
+		user, err = op.GetGuest()
+	} else {
+		user, err = op.GetUserByName(s.username)
+		if err == nil {
+			err = user.ValidateRawPassword(param)
+		}
+	}
+	if err == nil && (user.Disabled || !user.CanFTPAccess()) {
+		err = errs.PermissionDenied
+	}
+	audit.Login(s.username, s.remoteIP(), "ftp", err)
+	if err != nil {
+		log.Warnf("[ftp] failed login of %s from %s", s.username, s.remoteIP())
+		s.username = ""
+		s.reply(530, "Login incorrect")
+		return
+	}
+	s.user = user
+	s.reply(230, "User logged in")
+}
+
+func (s *session) handleAUTH(param string) {
+	if s.server.TLSConfig == nil {
+		s.reply(502, "TLS is not enabled")
+		return
+	}
+	if s.tlsCtrl {
+		s.reply(503, "Already using TLS")
+		return
+	}
+	switch strings.ToUpper(param) {
+	case "TLS", "TLS-C", "SSL":
+		s.reply(234, "AUTH command ok, expecting TLS negotiation")
+		if err := s.upgradeTLS(); err != nil {
+			log.Warnf("[ftp] tls handshake with %s failed: %+v", s.remoteIP(), err)
+			s.close()
+		}
+	default:
+		s.reply(504, "Unsupported AUTH type")
+	}
+}
+
+func (s *session) handlePBSZ(param string) {
+	if !s.tlsCtrl {
+		s.reply(503, "Use AUTH TLS first")
+		return
+	}
+	s.reply(200, "PBSZ=0")
+}
+
+func (s *session) handlePROT(param string) {
+	if !s.tlsCtrl {
+		s.reply(503, "Use AUTH TLS first")
+		return
+	}
+	switch strings.ToUpper(param) {
+	case "P":
+		s.tlsData = true
+		s.reply(200, "Protection level set to Private")
+	case "C":
+		if s.server.ForceTLS {
+			s.reply(534, "Clear data connection is not allowed")
+			return
+		}
+		s.tlsData = false
+		s.reply(200, "Protection level set to Clear")
+	default:
+		s.reply(504, "Unsupported protection level")
+	}
+}
+
+func (s *session) handleFEAT(param string) {
+	feats := []string{"Extensions supported:", " UTF8", " SIZE", " MDTM", " REST STREAM",
+		" EPSV", " EPRT", " MLST type*;size*;modify*;perm*;"}
+	if s.server.TLSConfig != nil {
+		feats = append(feats, " AUTH TLS", " PBSZ", " PROT")
+	}
+	feats = append(feats, "End")
+	s.reply(211, strings.Join(feats, "\n"))
+}
+
+func (s *session) handleSYST(param string) {
+	s.reply(215, "UNIX Type: L8")
+}
+
+func (s *session) handleNOOP(param string) {
+	s.reply(200, "OK")
+}
+
+func (s *session) handleOPTS(param string) {
+	if strings.EqualFold(param, "UTF8 ON") {
+		s.reply(200, "UTF8 mode enabled")
+		return
+	}
+	s.reply(501, "Unsupported option")
+}
+
+func (s *session) handlePWD(param string) {
+	s.reply(257, fmt.Sprintf("\"%s\" is the current directory", strings.ReplaceAll(s.cwd, "\"", "\"\"")))
+}
+
+func (s *session) handleCWD(param string) {
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	obj, err := fs.Get(s.fsCtx(reqPath), reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	if !obj.IsDir() {
+		s.reply(550, "Not a directory")
+		return
+	}
+	s.cwd = s.virtualPath(param)
+	s.reply(250, fmt.Sprintf("Directory changed to %s", s.cwd))
+}
+
+func (s *session) handleCDUP(param string) {
+	s.handleCWD("..")
+}
+
+func (s *session) handleTYPE(param string) {
+	switch strings.ToUpper(param) {
+	case "A", "A N":
+		s.reply(200, "Type set to ASCII")
+	case "I", "L 8":
+		s.reply(200, "Type set to binary")
+	default:
+		s.reply(504, "Unsupported type")
+	}
+}
+
+func (s *session) handleMODE(param string) {
+	if strings.ToUpper(param) == "S" {
+		s.reply(200, "Mode set to S")
+		return
+	}
+	s.reply(504, "Only stream mode is supported")
+}
+
+func (s *session) handleSTRU(param string) {
+	if strings.ToUpper(param) == "F" {
+		s.reply(200, "Structure set to F")
+		return
+	}
+	s.reply(504, "Only file structure is supported")
+}
+
+func (s *session) handleALLO(param string) {
+	s.reply(202, "No storage allocation necessary")
+}
+
+// pasv prepares a listener for the passive data connection and returns its port
+func (s *session) pasv() (int, error) {
+	s.closeData()
+	localIP, _, err := net.SplitHostPort(s.conn.LocalAddr().String())
+	if err != nil {
+		return 0, err
+	}
+	l, err := s.server.listenPasv(localIP)
+	if err != nil {
+		return 0, err
+	}
+	s.dataMu.Lock()
+	s.pasvListener = l
+	s.dataMu.Unlock()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func (s *session) handlePASV(param string) {
+	ip := net.ParseIP(s.server.PublicHost)
+	if ip == nil && s.server.PublicHost != "" {
+		if ips, err := net.LookupIP(s.server.PublicHost); err == nil && len(ips) > 0 {
+			ip = ips[0]
+		}
+	}
+	if ip == nil {
+		host, _, _ := net.SplitHostPort(s.conn.LocalAddr().String())
+		ip = net.ParseIP(host)
+	}
+	ip = ip.To4()
+	if ip == nil {
+		s.reply(425, "PASV requires IPv4, use EPSV instead")
+		return
+	}
+	port, err := s.pasv()
+	if err != nil {
+		s.reply(425, "Can't open passive connection")
+		return
+	}
+	s.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d)", ip[0], ip[1], ip[2], ip[3], port>>8, port&0xff))
+}
+
+func (s *session) handleEPSV(param string) {
+	port, err := s.pasv()
+	if err != nil {
+		s.reply(425, "Can't open passive connection")
+		return
+	}
+	s.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+}
+
+func (s *session) setActive(ip net.IP, port int) {
+	s.closeData()
+	if ip == nil || port <= 0 || port > 65535 || !ip.Equal(net.ParseIP(s.remoteIP())) {
+		// connecting to other hosts (FXP) is not allowed to prevent bounce attacks
+		s.reply(501, "Illegal address")
+		return
+	}
+	s.dataMu.Lock()
+	s.activeAddr = net.JoinHostPort(ip.String(), strconv.Itoa(port))
+	s.dataMu.Unlock()
+	s.reply(200, "Command okay")
+}
+
+func (s *session) handlePORT(param string) {
+	parts := strings.Split(param, ",")
+	if len(parts) != 6 {
+		s.reply(501, "Syntax error in parameters")
+		return
+	}
+	nums := make([]int, 6)
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 || n > 255 {
+			s.reply(501, "Syntax error in parameters")
+			return
+		}
+		nums[i] = n
+	}
+	ip := net.IPv4(byte(nums[0]), byte(nums[1]), byte(nums[2]), byte(nums[3]))
+	s.setActive(ip, nums[4]<<8|nums[5])
+}
+
+func (s *session) handleEPRT(param string) {
+	if len(param) < 4 {
+		s.reply(501, "Syntax error in parameters")
+		return
+	}
+	parts := strings.Split(param[1:len(param)-1], param[:1])
+	if len(parts) != 3 {
+		s.reply(501, "Syntax error in parameters")
+		return
+	}
+	port, err := strconv.Atoi(parts[2])
+	if err != nil {
+		s.reply(501, "Syntax error in parameters")
+		return
+	}
+	s.setActive(net.ParseIP(parts[1]), port)
+}
+
+// transfer opens the data connection and runs fn with it in background, so that ABOR can be handled
+// during the transfer. The data connection is closed when ctx is canceled by ABOR
+func (s *session) transfer(fn func(ctx context.Context, conn net.Conn) error) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+	s.startTransfer(cancel, done)
+	s.reply(150, "Opening data connection")
+	go func() {
+		defer close(done)
+		defer cancel()
+		conn, err := s.openData(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				s.reply(426, "Connection closed, transfer aborted")
+			} else {
+				s.reply(425, fmt.Sprintf("Can't open data connection: %s", err.Error()))
+			}
+			return
+		}
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+		err = fn(ctx, conn)
+		if stop() {
+			if cerr := conn.Close(); err == nil {
+				err = cerr
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			s.reply(426, "Connection closed, transfer aborted")
+		case err != nil:
+			log.Errorf("[ftp] transfer of %s failed: %+v", s.user.Username, err)
+			s.reply(426, fmt.Sprintf("Transfer aborted: %s", err.Error()))
+		default:
+			s.reply(226, "Transfer complete")
+		}
+	}()
+}
+
+// listArgs strips the options of LIST like -la which are sent by some clients
+func listArgs(param string) string {
+	for strings.HasPrefix(param, "-") {
+		_, param, _ = strings.Cut(param, " ")
+	}
+	return param
+}
+
+func (s *session) list(param string, format func(obj model.Obj) string) {
+	reqPath, err := s.realPath(listArgs(param))
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	ctx := s.fsCtx(reqPath)
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	objs := []model.Obj{obj}
+	if obj.IsDir() {
+		objs, err = fs.List(ctx, reqPath, &fs.ListArgs{NoLog: true})
+		if err != nil {
+			s.replyErr(err)
+			return
+		}
+	}
+	s.transfer(func(ctx context.Context, conn net.Conn) error {
+		for _, o := range objs {
+			if _, err := fmt.Fprintf(conn, "%s\r\n", format(o)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *session) handleLIST(param string) {
+	s.list(param, formatList)
+}
+
+func (s *session) handleNLST(param string) {
+	s.list(param, func(obj model.Obj) string {
+		return obj.GetName()
+	})
+}
+
+func (s *session) handleMLSD(param string) {
+	s.list(param, func(obj model.Obj) string {
+		return formatMLSx(obj, obj.GetName())
+	})
+}
+
+func (s *session) handleMLST(param string) {
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	obj, err := fs.Get(s.fsCtx(reqPath), reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	s.reply(250, fmt.Sprintf("Listing %s\n %s\nEnd", param, formatMLSx(obj, s.virtualPath(param))))
+}
+
+func (s *session) getFile(param string) (string, model.Obj, bool) {
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return "", nil, false
+	}
+	obj, err := fs.Get(s.fsCtx(reqPath), reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		s.replyErr(err)
+		return "", nil, false
+	}
+	if obj.IsDir() {
+		s.reply(550, "Not a regular file")
+		return "", nil, false
+	}
+	return reqPath, obj, true
+}
+
+func (s *session) handleSIZE(param string) {
+	if _, obj, ok := s.getFile(param); ok {
+		s.reply(213, strconv.FormatInt(obj.GetSize(), 10))
+	}
+}
+
+func (s *session) handleMDTM(param string) {
+	if _, obj, ok := s.getFile(param); ok {
+		s.reply(213, obj.ModTime().UTC().Format("20060102150405"))
+	}
+}
+
+func (s *session) handleREST(param string) {
+	offset, err := strconv.ParseInt(param, 10, 64)
+	if err != nil || offset < 0 {
+		s.reply(501, "Invalid offset")
+		return
+	}
+	s.restOffset = offset
+	s.reply(350, fmt.Sprintf("Restarting at %d, send STORE or RETRIEVE", offset))
+}
+
+func (s *session) handleRETR(param string) {
+	offset := s.restOffset
+	s.restOffset = 0
+	reqPath, _, ok := s.getFile(param)
+	if !ok {
+		return
+	}
+	s.transfer(func(ctx context.Context, conn net.Conn) error {
+		r, _, err := openReader(s.fsCtxOf(ctx, reqPath), reqPath, offset)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = utils.CopyWithBuffer(conn, r)
+		return err
+	})
+}
+
+func (s *session) store(param string, appendMode bool) {
+	offset := s.restOffset
+	s.restOffset = 0
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	if !s.canWrite(stdpath.Dir(reqPath)) {
+		s.replyErr(errs.PermissionDenied)
+		return
+	}
+	if appendMode || offset > 0 {
+		obj, err := fs.Get(s.fsCtx(reqPath), reqPath, &fs.GetArgs{NoLog: true})
+		if err == nil && obj.IsDir() {
+			s.reply(550, "Not a regular file")
+			return
+		}
+		size := int64(0)
+		if err == nil {
+			size = obj.GetSize()
+		}
+		if appendMode {
+			offset = size
+		}
+		if offset > size {
+			s.reply(554, "Restart offset is beyond the end of the file")
+			return
+		}
+	}
+	s.transfer(func(ctx context.Context, conn net.Conn) error {
+		return s.put(ctx, reqPath, conn, offset)
+	})
+}
+
+func (s *session) handleSTOR(param string) {
+	s.store(param, false)
+}
+
+func (s *session) handleAPPE(param string) {
+	s.store(param, true)
+}
+
+func (s *session) handleDELE(param string) {
+	reqPath, _, ok := s.getFile(param)
+	if !ok {
+		return
+	}
+	if !s.user.CanFTPManage() || !s.user.CanRemove() {
+		s.replyErr(errs.PermissionDenied)
+		return
+	}
+	if err := fs.Remove(s.fsCtx(reqPath), reqPath); err != nil {
+		s.replyErr(err)
+		return
+	}
+	s.reply(250, "File removed")
+}
+
+func (s *session) handleMKD(param string) {
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	if !s.canWrite(stdpath.Dir(reqPath)) {
+		s.replyErr(errs.PermissionDenied)
+		return
+	}
+	if err := fs.MakeDir(s.fsCtx(reqPath), reqPath); err != nil {
+		s.replyErr(err)
+		return
+	}
+	s.reply(257, fmt.Sprintf("\"%s\" created", strings.ReplaceAll(s.virtualPath(param), "\"", "\"\"")))
+}
+
+func (s *session) handleRMD(param string) {
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	if !s.user.CanFTPManage() || !s.user.CanRemove() {
+		s.replyErr(errs.PermissionDenied)
+		return
+	}
+	ctx := s.fsCtx(reqPath)
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	if !obj.IsDir() {
+		s.reply(550, "Not a directory")
+		return
+	}
+	if err := fs.Remove(ctx, reqPath); err != nil {
+		s.replyErr(err)
+		return
+	}
+	s.reply(250, "Directory removed")
+}
+
+func (s *session) handleRNFR(param string) {
+	reqPath, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	if _, err := fs.Get(s.fsCtx(reqPath), reqPath, &fs.GetArgs{NoLog: true}); err != nil {
+		s.replyErr(err)
+		return
+	}
+	s.renameFrom = reqPath
+	s.reply(350, "Ready for RNTO")
+}
+
+func (s *session) handleRNTO(param string) {
+	src := s.renameFrom
+	s.renameFrom = ""
+	if src == "" {
+		s.reply(503, "Use RNFR first")
+		return
+	}
+	dst, err := s.realPath(param)
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	srcDir, dstDir := stdpath.Dir(src), stdpath.Dir(dst)
+	srcName, dstName := stdpath.Base(src), stdpath.Base(dst)
+	if !s.user.CanFTPManage() ||
+		(srcDir != dstDir && !s.user.CanMove()) ||
+		(srcName != dstName && !s.user.CanRename()) {
+		s.replyErr(errs.PermissionDenied)
+		return
+	}
+	ctx := s.fsCtx(src)
+	if srcDir == dstDir {
+		err = fs.Rename(ctx, src, dstName)
+	} else {
+		err = fs.Move(ctx, src, dstDir)
+		if err == nil && srcName != dstName {
+			err = fs.Rename(ctx, stdpath.Join(dstDir, srcName), dstName)
+		}
+	}
+	if err != nil {
+		s.replyErr(err)
+		return
+	}
+	s.reply(250, "Rename successful")
+}
+
+func (s *session) handleABOR(param string) {
+	s.abortTransfer()
+	s.closeData()
+	s.reply(226, "Abort successful")
+}
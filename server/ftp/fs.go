@@ -0,0 +1,180 @@
+package ftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// virtualPath resolves the param of a command to the path that the user sees
+func (s *session) virtualPath(param string) string {
+	if !strings.HasPrefix(param, "/") {
+		param = stdpath.Join(s.cwd, param)
+	}
+	return utils.FixAndCleanPath(param)
+}
+
+// realPath resolves the param of a command to the alist path,
+// the meta of the path is checked so that hidden or password protected objects can't be accessed
+func (s *session) realPath(param string) (string, error) {
+	reqPath, err := s.user.JoinPath(s.virtualPath(param))
+	if err != nil {
+		return "", err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return "", err
+	}
+	if !common.CanAccess(s.user, meta, reqPath, "") {
+		return "", errs.PermissionDenied
+	}
+	return reqPath, nil
+}
+
+func (s *session) fsCtx(reqPath string) context.Context {
+	return s.fsCtxOf(s.ctx, reqPath)
+}
+
+// fsCtxOf is like fsCtx but derived from ctx, which is the context of a transfer
+func (s *session) fsCtxOf(ctx context.Context, reqPath string) context.Context {
+	meta, _ := op.GetNearestMeta(reqPath)
+	ctx = context.WithValue(context.WithValue(ctx, "user", s.user), "meta", meta)
+	return context.WithValue(ctx, "ip", s.remoteIP())
+}
+
+func (s *session) canWrite(reqPath string) bool {
+	if !s.user.CanFTPManage() {
+		return false
+	}
+	if s.user.CanWrite() {
+		return true
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		return false
+	}
+	return common.CanWrite(meta, reqPath)
+}
+
+// openReader opens the file at reqPath and seeks to offset
+func openReader(ctx context.Context, reqPath string, offset int64) (io.ReadCloser, model.Obj, error) {
+	link, obj, err := fs.Link(ctx, reqPath, model.LinkArgs{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset > obj.GetSize() {
+		offset = obj.GetSize()
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: obj}, link)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := ss.RangeRead(http_range.Range{Start: offset, Length: obj.GetSize() - offset})
+	if err != nil {
+		_ = ss.Close()
+		return nil, nil, err
+	}
+	return &readCloser{Reader: r, closer: ss}, obj, nil
+}
+
+type readCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (r *readCloser) Close() error {
+	if c, ok := r.Reader.(io.Closer); ok {
+		_ = c.Close()
+	}
+	return r.closer.Close()
+}
+
+// put uploads r as reqPath, the content of the existing file before offset is kept
+func (s *session) put(ctx context.Context, reqPath string, r io.Reader, offset int64) error {
+	ctx = s.fsCtxOf(ctx, reqPath)
+	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "ftp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+	}()
+	if offset > 0 {
+		old, _, err := openReader(ctx, reqPath, 0)
+		if err != nil {
+			return err
+		}
+		n, err := utils.CopyWithBufferN(tmpFile, old, offset)
+		_ = old.Close()
+		if err != nil {
+			return err
+		}
+		if n != offset {
+			return errors.WithStack(errs.StreamIncomplete)
+		}
+	}
+	if _, err := utils.CopyWithBuffer(tmpFile, r); err != nil {
+		return err
+	}
+	info, err := tmpFile.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	name := stdpath.Base(reqPath)
+	file := &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: time.Now(),
+			Ctime:    time.Now(),
+		},
+		Reader:   tmpFile,
+		Mimetype: utils.GetMimeType(name),
+	}
+	defer file.Close()
+	return fs.PutDirectly(ctx, stdpath.Dir(reqPath), file)
+}
+
+func formatList(obj model.Obj) string {
+	mode := "-rw-r--r--"
+	if obj.IsDir() {
+		mode = "drwxr-xr-x"
+	}
+	modTime := obj.ModTime()
+	timeStr := modTime.Format("Jan _2 15:04")
+	if modTime.Before(time.Now().AddDate(0, -6, 0)) || modTime.After(time.Now().AddDate(0, 0, 1)) {
+		timeStr = modTime.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s 1 alist alist %12d %s %s", mode, obj.GetSize(), timeStr, obj.GetName())
+}
+
+func formatMLSx(obj model.Obj, name string) string {
+	typ := "file"
+	perm := "adfrw"
+	if obj.IsDir() {
+		typ = "dir"
+		perm = "flcdmpe"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s;perm=%s; %s",
+		typ, obj.GetSize(), obj.ModTime().UTC().Format("20060102150405"), perm, name)
+}
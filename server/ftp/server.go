@@ -0,0 +1,162 @@
+// Package ftp implements a ftp/ftps (explicit tls) server for alist
+package ftp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type Server struct {
+	Addr        string
+	PublicHost  string
+	IdleTimeout time.Duration
+	// TLSConfig enables the AUTH TLS command if not nil
+	TLSConfig *tls.Config
+	ForceTLS  bool
+	// passive port range, any free port is used if pasvMin is 0
+	pasvMin, pasvMax int
+	pasvNext         atomic.Uint32
+
+	mu       sync.Mutex
+	listener net.Listener
+	sessions map[*session]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer creates the ftp server from conf.Conf.FTP
+func NewServer() (*Server, error) {
+	c := conf.Conf.FTP
+	s := &Server{
+		Addr:        fmt.Sprintf("%s:%d", conf.Conf.Scheme.Address, c.Port),
+		PublicHost:  c.PublicHost,
+		IdleTimeout: time.Duration(c.IdleTimeout) * time.Second,
+		ForceTLS:    c.ForceTLS,
+		sessions:    make(map[*session]struct{}),
+	}
+	if c.PasvPortRange != "" {
+		min, max, err := parsePortRange(c.PasvPortRange)
+		if err != nil {
+			return nil, err
+		}
+		s.pasvMin, s.pasvMax = min, max
+	}
+	if c.TLS || c.ForceTLS {
+		cert, err := tls.LoadX509KeyPair(conf.Conf.Scheme.CertFile, conf.Conf.Scheme.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed load certificate for ftps")
+		}
+		s.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return s, nil
+}
+
+func parsePortRange(r string) (int, int, error) {
+	parts := strings.SplitN(r, "-", 2)
+	min, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid pasv port range: %s", r)
+	}
+	max := min
+	if len(parts) == 2 {
+		max, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return 0, 0, errors.Errorf("invalid pasv port range: %s", r)
+		}
+	}
+	if min <= 0 || max > 65535 || min > max {
+		return 0, 0, errors.Errorf("invalid pasv port range: %s", r)
+	}
+	return min, max, nil
+}
+
+func (s *Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		sess := newSession(s, conn)
+		s.mu.Lock()
+		s.sessions[sess] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			sess.serve()
+			s.mu.Lock()
+			delete(s.sessions, sess)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Shutdown stops accepting new connections and closes all sessions
+func (s *Server) Shutdown() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for sess := range s.sessions {
+		sess.close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// listenPasv listens on a port for the passive data connection
+func (s *Server) listenPasv(ip string) (net.Listener, error) {
+	if s.pasvMin == 0 {
+		return net.Listen("tcp", net.JoinHostPort(ip, "0"))
+	}
+	n := s.pasvMax - s.pasvMin + 1
+	var lastErr error
+	for i := 0; i < n; i++ {
+		port := s.pasvMin + int((s.pasvNext.Add(1)-1)%uint32(n))
+		l, err := net.Listen("tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+		if err == nil {
+			return l, nil
+		}
+		lastErr = err
+	}
+	log.Warnf("[ftp] no free passive port in %d-%d", s.pasvMin, s.pasvMax)
+	return nil, lastErr
+}
@@ -0,0 +1,247 @@
+package ftp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupServer serves the local storage at /local with a temp dir for user u, whose password is p
+func setupServer(t *testing.T, opts ...func(s *Server)) (string, string) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.TempDir = t.TempDir()
+	db.Init(dB)
+	root := t.TempDir()
+	_, err = op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	user := &model.User{Username: "u", BasePath: "/local", Role: model.GENERAL,
+		Permission: 1<<3 | 1<<10 | 1<<11, Authn: "[]"}
+	user.SetPassword("p")
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{sessions: make(map[*session]struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() { _ = s.Shutdown() })
+	return l.Addr().String(), root
+}
+
+type client struct {
+	t *testing.T
+	*textproto.Conn
+}
+
+func login(t *testing.T, addr string) *client {
+	conn, err := textproto.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	c := &client{t: t, Conn: conn}
+	c.expect(220)
+	c.cmd(331, "USER u")
+	c.cmd(230, "PASS p")
+	return c
+}
+
+func (c *client) expect(code int) string {
+	c.t.Helper()
+	_, msg, err := c.ReadResponse(code)
+	if err != nil {
+		c.t.Fatalf("expect %d: %v", code, err)
+	}
+	return msg
+}
+
+func (c *client) cmd(code int, format string, args ...interface{}) string {
+	c.t.Helper()
+	if err := c.PrintfLine(format, args...); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.expect(code)
+}
+
+// pasv enters the passive mode and returns the address of the data connection
+func (c *client) pasv() string {
+	c.t.Helper()
+	msg := c.cmd(227, "PASV")
+	var h1, h2, h3, h4, p1, p2 int
+	if _, err := fmt.Sscanf(msg[strings.Index(msg, "("):], "(%d,%d,%d,%d,%d,%d)", &h1, &h2, &h3, &h4, &p1, &p2); err != nil {
+		c.t.Fatalf("invalid pasv reply %s: %v", msg, err)
+	}
+	return fmt.Sprintf("%d.%d.%d.%d:%d", h1, h2, h3, h4, p1<<8|p2)
+}
+
+// data enters the passive mode, sends the restart offset if it's > 0 and the command,
+// then opens the data connection
+func (c *client) data(rest int, format string, args ...interface{}) net.Conn {
+	c.t.Helper()
+	addr := c.pasv()
+	if rest > 0 {
+		c.cmd(350, "REST %d", rest)
+	}
+	c.cmd(150, format, args...)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return conn
+}
+
+func TestStoreAndRetrieve(t *testing.T) {
+	addr, root := setupServer(t)
+	c := login(t, addr)
+
+	conn := c.data(0, "STOR a.txt")
+	if _, err := io.WriteString(conn, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	c.expect(226)
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("stored a.txt = %q, err: %v", data, err)
+	}
+
+	conn = c.data(0, "APPE a.txt")
+	_, _ = io.WriteString(conn, " world")
+	_ = conn.Close()
+	c.expect(226)
+
+	conn = c.data(0, "RETR a.txt")
+	data, err := io.ReadAll(conn)
+	_ = conn.Close()
+	c.expect(226)
+	if err != nil || string(data) != "hello world" {
+		t.Errorf("retrieved a.txt = %q, err: %v", data, err)
+	}
+
+	conn = c.data(6, "RETR a.txt")
+	data, _ = io.ReadAll(conn)
+	_ = conn.Close()
+	c.expect(226)
+	if string(data) != "world" {
+		t.Errorf("retrieved a.txt from 6 = %q", data)
+	}
+
+	c.cmd(550, "RETR missing.txt")
+	c.cmd(550, "RETR ../../etc/passwd")
+}
+
+func TestAbort(t *testing.T) {
+	addr, root := setupServer(t)
+	c := login(t, addr)
+	big := strings.Repeat("x", 64<<20)
+	if err := os.WriteFile(filepath.Join(root, "big.bin"), []byte(big), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	// the download blocks since the data connection isn't read
+	conn := c.data(0, "RETR big.bin")
+	time.Sleep(100 * time.Millisecond)
+	c.cmd(426, "ABOR")
+	c.expect(226)
+	_ = conn.Close()
+
+	// the upload is aborted before the client finishes sending
+	conn = c.data(0, "STOR part.bin")
+	_, _ = io.WriteString(conn, "partial")
+	time.Sleep(100 * time.Millisecond)
+	c.cmd(426, "ABOR")
+	c.expect(226)
+	_ = conn.Close()
+	if _, err := os.Stat(filepath.Join(root, "part.bin")); !os.IsNotExist(err) {
+		t.Errorf("aborted upload should not be stored")
+	}
+
+	// without any transfer
+	c.cmd(226, "ABOR")
+	c.cmd(200, "NOOP")
+}
+
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestForceTLS(t *testing.T) {
+	cert := selfSignedCert(t)
+	addr, _ := setupServer(t, func(s *Server) {
+		s.ForceTLS = true
+		s.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	})
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	c := &client{t: t, Conn: textproto.NewConn(conn)}
+	c.expect(220)
+	c.cmd(534, "USER u")
+	c.cmd(234, "AUTH TLS")
+
+	c.Conn = textproto.NewConn(tls.Client(conn, &tls.Config{InsecureSkipVerify: true}))
+	c.cmd(331, "USER u")
+	c.cmd(230, "PASS p")
+	c.cmd(521, "PASV")
+	c.cmd(521, "PORT 127,0,0,1,4,1")
+	c.cmd(521, "LIST")
+	c.cmd(521, "RETR a.txt")
+	c.cmd(200, "PBSZ 0")
+	c.cmd(534, "PROT C")
+	c.cmd(521, "PASV")
+	c.cmd(200, "PROT P")
+	c.pasv()
+}
@@ -0,0 +1,251 @@
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// session is a single control connection
+type session struct {
+	server *Server
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// login state
+	username string
+	user     *model.User
+	// cwd is the current directory that the user sees, the base path of user is not included
+	cwd string
+
+	tlsCtrl bool
+	tlsData bool
+
+	restOffset int64
+	renameFrom string
+
+	dataMu       sync.Mutex
+	pasvListener net.Listener
+	activeAddr   string
+
+	// the running transfer, it's canceled by ABOR and the other commands wait for it
+	xferMu     sync.Mutex
+	xferCancel context.CancelFunc
+	xferDone   chan struct{}
+
+	// replyMu serializes the replies of the commands and the transfers
+	replyMu sync.Mutex
+}
+
+func newSession(server *Server, conn net.Conn) *session {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &session{
+		server: server,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+		ctx:    ctx,
+		cancel: cancel,
+		cwd:    "/",
+	}
+}
+
+func (s *session) remoteIP() string {
+	host, _, _ := net.SplitHostPort(s.conn.RemoteAddr().String())
+	return host
+}
+
+func (s *session) serve() {
+	defer func() {
+		s.close()
+		s.waitTransfer()
+	}()
+	log.Debugf("[ftp] new connection from %s", s.conn.RemoteAddr())
+	s.reply(220, "Welcome to alist ftp server")
+	for {
+		if s.server.IdleTimeout > 0 {
+			_ = s.conn.SetReadDeadline(time.Now().Add(s.server.IdleTimeout))
+		}
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() && s.transferring() {
+				// the control connection is idle during the transfer
+				continue
+			}
+			if errors.As(err, &ne) && ne.Timeout() {
+				s.reply(421, "Idle timeout, closing control connection")
+			} else if err != io.EOF {
+				log.Debugf("[ftp] read from %s: %+v", s.conn.RemoteAddr(), err)
+			}
+			return
+		}
+		cmd, param := parseLine(line)
+		if cmd == "" {
+			continue
+		}
+		if cmd == "PASS" {
+			log.Debugf("[ftp] %s: PASS ****", s.conn.RemoteAddr())
+		} else {
+			log.Debugf("[ftp] %s: %s %s", s.conn.RemoteAddr(), cmd, param)
+		}
+		if quit := s.handle(cmd, param); quit {
+			return
+		}
+	}
+}
+
+func parseLine(line string) (string, string) {
+	line = strings.TrimRight(line, "\r\n")
+	cmd, param, _ := strings.Cut(line, " ")
+	return strings.ToUpper(cmd), param
+}
+
+func (s *session) reply(code int, msg string) {
+	s.replyMu.Lock()
+	defer s.replyMu.Unlock()
+	lines := strings.Split(msg, "\n")
+	for i, l := range lines {
+		if i == len(lines)-1 {
+			_, _ = fmt.Fprintf(s.writer, "%d %s\r\n", code, l)
+		} else {
+			_, _ = fmt.Fprintf(s.writer, "%d-%s\r\n", code, l)
+		}
+	}
+	if err := s.writer.Flush(); err != nil {
+		log.Debugf("[ftp] write to %s: %+v", s.conn.RemoteAddr(), err)
+	}
+}
+
+// upgradeTLS wraps the control connection with tls after AUTH TLS
+func (s *session) upgradeTLS() error {
+	tlsConn := tls.Server(s.conn, s.server.TLSConfig)
+	if err := tlsConn.HandshakeContext(s.ctx); err != nil {
+		return err
+	}
+	s.conn = tlsConn
+	s.reader = bufio.NewReader(tlsConn)
+	s.writer = bufio.NewWriter(tlsConn)
+	s.tlsCtrl = true
+	return nil
+}
+
+func (s *session) close() {
+	s.cancel()
+	s.closeData()
+	_ = s.conn.Close()
+}
+
+func (s *session) closeData() {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	if s.pasvListener != nil {
+		_ = s.pasvListener.Close()
+		s.pasvListener = nil
+	}
+	s.activeAddr = ""
+}
+
+// openData returns the data connection prepared by PASV/EPSV or PORT/EPRT, it stops when ctx is canceled
+func (s *session) openData(ctx context.Context) (net.Conn, error) {
+	s.dataMu.Lock()
+	l, addr := s.pasvListener, s.activeAddr
+	s.pasvListener, s.activeAddr = nil, ""
+	s.dataMu.Unlock()
+	var conn net.Conn
+	var err error
+	switch {
+	case l != nil:
+		defer l.Close()
+		stop := context.AfterFunc(ctx, func() { _ = l.Close() })
+		defer stop()
+		if tl, ok := l.(*net.TCPListener); ok {
+			_ = tl.SetDeadline(time.Now().Add(30 * time.Second))
+		}
+		conn, err = l.Accept()
+		if err == nil && !sameHost(conn.RemoteAddr(), s.conn.RemoteAddr()) {
+			// prevent the data connection from being stolen
+			_ = conn.Close()
+			err = errors.New("data connection from a different host")
+		}
+	case addr != "":
+		d := net.Dialer{Timeout: 30 * time.Second}
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, errors.New("use PASV or PORT first")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.tlsData {
+		tlsConn := tls.Server(conn, s.server.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return conn, nil
+}
+
+func sameHost(a, b net.Addr) bool {
+	ha, _, _ := net.SplitHostPort(a.String())
+	hb, _, _ := net.SplitHostPort(b.String())
+	return net.ParseIP(ha).Equal(net.ParseIP(hb))
+}
+
+// startTransfer records the transfer which runs until done is closed
+func (s *session) startTransfer(cancel context.CancelFunc, done chan struct{}) {
+	s.xferMu.Lock()
+	defer s.xferMu.Unlock()
+	s.xferCancel, s.xferDone = cancel, done
+}
+
+func (s *session) transferring() bool {
+	s.xferMu.Lock()
+	done := s.xferDone
+	s.xferMu.Unlock()
+	if done == nil {
+		return false
+	}
+	select {
+	case <-done:
+		return false
+	default:
+		return true
+	}
+}
+
+// waitTransfer waits for the running transfer to finish
+func (s *session) waitTransfer() {
+	s.xferMu.Lock()
+	done := s.xferDone
+	s.xferMu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// abortTransfer cancels the running transfer and waits for it, the transfer replies 426 itself
+func (s *session) abortTransfer() {
+	s.xferMu.Lock()
+	cancel := s.xferCancel
+	s.xferMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	s.waitTransfer()
+}
@@ -0,0 +1,102 @@
+package handles
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ApiTokenCreateReq struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// PathPrefix is under the base path of the user
+	PathPrefix string     `json:"path_prefix"`
+	Expires    *time.Time `json:"expires"`
+}
+
+type ApiTokenCreateResp struct {
+	model.ApiToken
+	// Token is only responded when it's created
+	Token string `json:"token"`
+}
+
+func CreateMyApiToken(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req ApiTokenCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Expires != nil && !req.Expires.IsZero() && req.Expires.Before(time.Now()) {
+		common.ErrorStrResp(c, "expires is in the past", 400)
+		return
+	}
+	t := model.ApiToken{
+		Name:       req.Name,
+		Scopes:     strings.Join(req.Scopes, ","),
+		PathPrefix: req.PathPrefix,
+		Expires:    req.Expires,
+	}
+	token, err := op.CreateApiToken(userObj, &t)
+	if err != nil {
+		if errors.Is(err, errs.InvalidScope) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		return
+	}
+	common.SuccessResp(c, ApiTokenCreateResp{ApiToken: t, Token: token})
+}
+
+func ListMyApiTokens(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetApiTokensByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
+
+func RevokeMyApiToken(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err := op.DeleteApiToken(uint(tokenId), userObj.ID); err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -3,10 +3,13 @@ package handles
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"image/png"
 	"time"
 
 	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -59,12 +62,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	// check username
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
+		audit.Login(req.Username, ip, "password", err)
 		common.ErrorResp(c, err, 400)
 		loginCache.Set(ip, count+1)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
+		audit.Login(req.Username, ip, "password", err)
 		common.ErrorResp(c, err, 400)
 		loginCache.Set(ip, count+1)
 		return
@@ -72,6 +77,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	// check 2FA
 	if user.OtpSecret != "" {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
+			audit.Login(req.Username, ip, "password", errors.New("invalid 2FA code"))
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
 			loginCache.Set(ip, count+1)
 			return
@@ -83,13 +89,16 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
+	audit.Login(user.Username, ip, "password", nil)
 	common.SuccessResp(c, gin.H{"token": token})
 	loginCache.Del(ip)
 }
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp       bool  `json:"otp"`
+	UsedBytes int64 `json:"used_bytes"`
+	UsedFiles int64 `json:"used_files"`
 }
 
 // CurrentUser get current user by token
@@ -103,6 +112,9 @@ func CurrentUser(c *gin.Context) {
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	if !user.IsGuest() {
+		userResp.UsedBytes, userResp.UsedFiles, _ = db.GetUsage(user.ID)
+	}
 	common.SuccessResp(c, userResp)
 }
 
@@ -0,0 +1,96 @@
+package handles
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ExportBundleReq struct {
+	Format     string `json:"format" form:"format"`
+	Passphrase string `json:"passphrase" form:"passphrase"`
+}
+
+// ExportBundle responds the bundle as a file
+func ExportBundle(c *gin.Context) {
+	var req ExportBundleReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = bundle.FormatJSON
+	}
+	if req.Format != bundle.FormatJSON && req.Format != bundle.FormatYAML {
+		common.ErrorStrResp(c, "unknown bundle format: "+req.Format, 400)
+		return
+	}
+	b, err := bundle.Export(req.Passphrase)
+	var data []byte
+	if err == nil {
+		data, err = bundle.Marshal(b, req.Format)
+	}
+	audit.Admin(c, model.AuditBundleExport, req.Format, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	filename := fmt.Sprintf("alist-bundle-%s.%s", time.Now().Format("20060102150405"), req.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(200, "application/octet-stream", data)
+}
+
+type ImportBundleReq struct {
+	Mode   string `form:"mode"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// ImportBundle imports the bundle in the request body, the passphrase is in the Passphrase header
+func ImportBundle(c *gin.Context) {
+	var req ImportBundleReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = bundle.ModeMerge
+	}
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	b, err := bundle.Unmarshal(data)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	diff, err := bundle.Import(b, bundle.ImportOptions{
+		Mode:       req.Mode,
+		Passphrase: c.GetHeader("Passphrase"),
+		DryRun:     req.DryRun,
+	})
+	if !req.DryRun {
+		audit.Admin(c, model.AuditBundleImport, req.Mode, err)
+	}
+	if err != nil {
+		if errors.Is(err, errs.InvalidBundle) || errors.Is(err, errs.WrongBundlePassphrase) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		return
+	}
+	if !req.DryRun {
+		bundle.Reload(c, diff)
+	}
+	common.SuccessResp(c, diff)
+}
@@ -0,0 +1,52 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/cache"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ListCacheReq struct {
+	model.PageReq
+	Path string `json:"path" form:"path"`
+}
+
+// ListCaches lists the list and link cache entries under the path
+func ListCaches(c *gin.Context) {
+	var req ListCacheReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	entries := cache.List(utils.FixAndCleanPath(req.Path))
+	total := len(entries)
+	start, end := total, total
+	if req.Page-1 < total/req.PerPage+1 {
+		start = (req.Page - 1) * req.PerPage
+	}
+	if req.PerPage < total-start {
+		end = start + req.PerPage
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: entries[start:end],
+		Total:   int64(total),
+	})
+}
+
+type PurgeCacheReq struct {
+	Path string `json:"path" binding:"required"`
+}
+
+// PurgeCaches deletes the list and link cache entries under the path
+func PurgeCaches(c *gin.Context) {
+	var req PurgeCacheReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	n := cache.Purge(utils.FixAndCleanPath(req.Path))
+	common.SuccessResp(c, gin.H{"count": n})
+}
@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	"io"
 	stdpath "path"
@@ -12,6 +13,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/internal/throttle"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -106,7 +108,9 @@ func Proxy(c *gin.Context) {
 				return
 			}
 		}
-		err = common.Proxy(c.Writer, c.Request, link, file)
+		ctx := context.WithValue(c.Request.Context(), "user", c.Value("user"))
+		ctx = throttle.WithStorage(ctx, storage.GetStorage())
+		err = common.Proxy(c.Writer, c.Request.WithContext(ctx), link, file)
 		if err != nil {
 			common.ErrorResp(c, err, 500, true)
 			return
@@ -0,0 +1,79 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func FsTrashList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	userId := user.ID
+	if user.IsAdmin() {
+		userId = 0
+	}
+	items, total, err := db.GetTrashItems(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type TrashReq struct {
+	Ids []uint `json:"ids"`
+}
+
+// trashAction runs fn for each trash item in the request which belongs to the current user
+func trashAction(c *gin.Context, fn func(item *model.TrashItem) error) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.CanRemove() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	for _, id := range req.Ids {
+		item, err := db.GetTrashItemById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 404)
+			return
+		}
+		if !user.IsAdmin() && item.UserId != user.ID {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		if err := fn(item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func FsTrashRestore(c *gin.Context) {
+	trashAction(c, func(item *model.TrashItem) error {
+		return fs.RestoreTrash(c, item)
+	})
+}
+
+func FsTrashPurge(c *gin.Context) {
+	trashAction(c, func(item *model.TrashItem) error {
+		return fs.PurgeTrash(c, item)
+	})
+}
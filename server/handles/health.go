@@ -0,0 +1,12 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/health"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListStorageHealth lists the health states and the recent checks of the storages
+func ListStorageHealth(c *gin.Context) {
+	common.SuccessResp(c, health.GetAll())
+}
@@ -2,13 +2,14 @@ package handles
 
 import (
 	"context"
+	"strconv"
 
-	"github.com/alist-org/alist/v3/internal/conf"
-	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/search"
-	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,20 +19,39 @@ type UpdateIndexReq struct {
 	//IgnorePaths []string `json:"ignore_paths"`
 }
 
+// indexOf returns the named index if the id is in query, or the default index
+func indexOf(c *gin.Context) (*search.Instance, bool) {
+	if c.Query("id") == "" {
+		if !search.DefaultIndex().Available() {
+			common.ErrorResp(c, errs.SearchNotAvailable, 500)
+			return nil, false
+		}
+		return search.DefaultIndex(), true
+	}
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	index, err := search.GetIndex(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	return index, true
+}
+
 func BuildIndex(c *gin.Context) {
-	if search.Running.Load() {
+	index, ok := indexOf(c)
+	if !ok {
+		return
+	}
+	if index.IsRunning() {
 		common.ErrorStrResp(c, "index is running", 400)
 		return
 	}
 	go func() {
-		ctx := context.Background()
-		err := search.Clear(ctx)
-		if err != nil {
-			log.Errorf("clear index error: %+v", err)
-			return
-		}
-		err = search.BuildIndex(context.Background(), []string{"/"},
-			conf.SlicesMap[conf.IgnorePaths], setting.GetInt(conf.MaxIndexDepth, 20), true)
+		err := index.Rebuild(context.Background())
 		if err != nil {
 			log.Errorf("build index error: %+v", err)
 		}
@@ -45,6 +65,38 @@ func UpdateIndex(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	index, ok := indexOf(c)
+	if !ok {
+		return
+	}
+	if index.IsRunning() {
+		common.ErrorStrResp(c, "index is running", 400)
+		return
+	}
+	if !index.Config().AutoUpdate {
+		common.ErrorStrResp(c, "update is not supported for current index", 400)
+		return
+	}
+	go func() {
+		err := index.Refresh(context.Background(), req.Paths, req.MaxDepth)
+		if err != nil {
+			log.Errorf("update index error: %+v", err)
+		}
+	}()
+	common.SuccessResp(c)
+}
+
+type DeltaRefreshIndexReq struct {
+	// MountPaths are the mount paths to refresh, all the mounts if empty
+	MountPaths []string `json:"mount_paths"`
+}
+
+func DeltaRefreshIndex(c *gin.Context) {
+	var req DeltaRefreshIndexReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if search.Running.Load() {
 		common.ErrorStrResp(c, "index is running", 400)
 		return
@@ -53,50 +105,54 @@ func UpdateIndex(c *gin.Context) {
 		common.ErrorStrResp(c, "update is not supported for current index", 400)
 		return
 	}
-	go func() {
-		ctx := context.Background()
-		for _, path := range req.Paths {
-			err := search.Del(ctx, path)
-			if err != nil {
-				log.Errorf("delete index on %s error: %+v", path, err)
-				return
-			}
+	if len(req.MountPaths) == 0 {
+		for _, storage := range op.GetAllStorages() {
+			req.MountPaths = append(req.MountPaths, storage.GetStorage().MountPath)
 		}
-		err := search.BuildIndex(context.Background(), req.Paths,
-			conf.SlicesMap[conf.IgnorePaths], req.MaxDepth, false)
+	}
+	go func() {
+		err := search.DeltaRefresh(context.Background(), req.MountPaths)
 		if err != nil {
-			log.Errorf("update index error: %+v", err)
+			log.Errorf("delta refresh index error: %+v", err)
 		}
 	}()
 	common.SuccessResp(c)
 }
 
 func StopIndex(c *gin.Context) {
-	if !search.Running.Load() {
-		common.ErrorStrResp(c, "index is not running", 400)
+	index, ok := indexOf(c)
+	if !ok {
+		return
+	}
+	if err := index.Stop(); err != nil {
+		common.ErrorResp(c, err, 400)
 		return
 	}
-	search.Quit <- struct{}{}
 	common.SuccessResp(c)
 }
 
 func ClearIndex(c *gin.Context) {
-	if search.Running.Load() {
-		common.ErrorStrResp(c, "index is running", 400)
+	index, ok := indexOf(c)
+	if !ok {
+		return
+	}
+	if err := index.Clear(c); err != nil {
+		if errors.Is(err, errs.SearchIndexRunning) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
 		return
 	}
-	search.Clear(c)
-	search.WriteProgress(&model.IndexProgress{
-		ObjCount:     0,
-		IsDone:       true,
-		LastDoneTime: nil,
-		Error:        "",
-	})
 	common.SuccessResp(c)
 }
 
 func GetProgress(c *gin.Context) {
-	progress, err := search.Progress()
+	index, ok := indexOf(c)
+	if !ok {
+		return
+	}
+	progress, err := index.Progress()
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -93,6 +94,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	err = l.Bind(userDN, req.Password)
 	if err != nil {
 		utils.Log.Errorf("Failed to auth. %v", err)
+		audit.Login(req.Username, ip, "ldap", err)
 		common.ErrorResp(c, err, 400)
 		loginCache.Set(ip, count+1)
 		return
@@ -117,6 +119,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
+	audit.Login(user.Username, ip, "ldap", nil)
 	common.SuccessResp(c, gin.H{"token": token})
 	loginCache.Del(ip)
 }
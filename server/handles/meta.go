@@ -3,12 +3,12 @@ package handles
 import (
 	"fmt"
 	"strconv"
-	"strings"
 
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
-	"github.com/dlclark/regexp2"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
@@ -38,12 +38,15 @@ func CreateMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	r, err := validHide(req.Hide)
+	r, err := op.CheckHide(req.Hide)
 	if err != nil {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
-	if err := op.CreateMeta(&req); err != nil {
+	req.Managed = false
+	err = op.CreateMeta(&req)
+	audit.Admin(c, model.AuditMetaCreate, req.Path, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -56,29 +59,24 @@ func UpdateMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	r, err := validHide(req.Hide)
+	r, err := op.CheckHide(req.Hide)
 	if err != nil {
 		common.ErrorStrResp(c, fmt.Sprintf("%s is illegal: %s", r, err.Error()), 400)
 		return
 	}
-	if err := op.UpdateMeta(&req); err != nil {
+	if managedMeta(c, req.ID) {
+		return
+	}
+	req.Managed = false
+	err = op.UpdateMeta(&req)
+	audit.Admin(c, model.AuditMetaUpdate, req.Path, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
 	}
 }
 
-func validHide(hide string) (string, error) {
-	rs := strings.Split(hide, "\n")
-	for _, r := range rs {
-		_, err := regexp2.Compile(r, regexp2.None)
-		if err != nil {
-			return r, err
-		}
-	}
-	return "", nil
-}
-
 func DeleteMeta(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -86,13 +84,31 @@ func DeleteMeta(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteMetaById(uint(id)); err != nil {
+	if managedMeta(c, uint(id)) {
+		return
+	}
+	target := strconv.Itoa(id)
+	if meta, err := op.GetMetaById(uint(id)); err == nil {
+		target = meta.Path
+	}
+	err = op.DeleteMetaById(uint(id))
+	audit.Admin(c, model.AuditMetaDelete, target, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
 	common.SuccessResp(c)
 }
 
+// managedMeta responds the error if the meta is managed by the storages file
+func managedMeta(c *gin.Context, id uint) bool {
+	if meta, err := op.GetMetaById(id); err == nil && meta.Managed {
+		common.ErrorResp(c, errs.ManagedByFile, 403)
+		return true
+	}
+	return false
+}
+
 func GetMeta(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
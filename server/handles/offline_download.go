@@ -1,10 +1,12 @@
 package handles
 
 import (
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/offline_download/tool"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
 	"github.com/alist-org/alist/v3/pkg/tache"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -98,6 +100,10 @@ func AddOfflineDownload(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if err := quota.Check(c, reqPath, 0, 1); err != nil {
+		common.ErrorResp(c, err, 413)
+		return
+	}
 	var tasks []tache.TaskWithInfo
 	for _, url := range req.Urls {
 		t, err := tool.AddURL(c, &tool.AddURLArgs{
@@ -106,6 +112,7 @@ func AddOfflineDownload(c *gin.Context) {
 			Tool:         req.Tool,
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
 		})
+		audit.Fs(c, model.AuditOfflineDownload, url, reqPath, err)
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -0,0 +1,101 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type S3KeyCreateReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type S3KeyCreateResp struct {
+	model.S3Key
+	// SecretAccessKey is only responded when the key is created
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+func CreateMyS3Key(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req S3KeyCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	k, err := op.CreateS3Key(userObj.ID, req.Name)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, S3KeyCreateResp{S3Key: *k, SecretAccessKey: k.SecretAccessKey})
+}
+
+func ListMyS3Keys(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listS3Keys(c, userObj.ID)
+}
+
+func DeleteMyS3Key(c *gin.Context) {
+	userObj, ok := c.Value("user").(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	deleteS3Key(c, userObj.ID)
+}
+
+func ListS3Keys(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	listS3Keys(c, uint(userId))
+}
+
+func DeleteS3Key(c *gin.Context) {
+	deleteS3Key(c, 0)
+}
+
+func listS3Keys(c *gin.Context, userId uint) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	keys, total, err := op.GetS3KeysByUserId(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: keys,
+		Total:   total,
+	})
+}
+
+func deleteS3Key(c *gin.Context, userId uint) {
+	keyId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err := op.DeleteS3Key(uint(keyId), userId); err != nil {
+		common.ErrorStrResp(c, "failed to get s3 key", 404)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/search"
@@ -44,6 +45,10 @@ func Search(c *gin.Context) {
 		return
 	}
 	nodes, total, err := search.Search(c, req.SearchReq)
+	if errors.Is(err, errs.ContentSearchNotSupported) || errors.Is(err, errs.SearchIndexOutdated) {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -53,6 +58,10 @@ func Search(c *gin.Context) {
 		if !strings.HasPrefix(node.Parent, user.BasePath) {
 			continue
 		}
+		// the objs indexed before they were moved to the trash
+		if fs.IsTrashPath(path.Join(node.Parent, node.Name)) {
+			continue
+		}
 		meta, err := op.GetNearestMeta(node.Parent)
 		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
 			continue
@@ -0,0 +1,91 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/search"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func ListSearchIndexes(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	indexes, total, err := search.GetIndexConfs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: indexes,
+		Total:   total,
+	})
+}
+
+func GetSearchIndex(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	index, err := search.GetIndexConf(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, index)
+}
+
+func searchIndexErrResp(c *gin.Context, err error) {
+	if errors.Is(err, errs.InvalidSearchIndex) || errors.Is(err, errs.SearchIndexRunning) {
+		common.ErrorResp(c, err, 400)
+	} else {
+		common.ErrorResp(c, err, 500, true)
+	}
+}
+
+func CreateSearchIndex(c *gin.Context) {
+	var req model.SearchIndex
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := search.CreateIndex(&req); err != nil {
+		searchIndexErrResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{"id": req.ID})
+}
+
+func UpdateSearchIndex(c *gin.Context) {
+	var req model.SearchIndex
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := search.UpdateIndex(&req); err != nil {
+		searchIndexErrResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteSearchIndex(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := search.DeleteIndex(uint(id)); err != nil {
+		searchIndexErrResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
@@ -52,7 +53,13 @@ func SaveSettings(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.SaveSettingItems(req); err != nil {
+	keys := make([]string, 0, len(req))
+	for _, item := range req {
+		keys = append(keys, item.Key)
+	}
+	err := op.SaveSettingItems(req)
+	audit.Admin(c, model.AuditSettingSave, strings.Join(keys, ","), err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		if req[0].Group == 10 {
@@ -100,7 +107,9 @@ func ListSettings(c *gin.Context) {
 
 func DeleteSetting(c *gin.Context) {
 	key := c.Query("key")
-	if err := op.DeleteSettingItemByKey(key); err != nil {
+	err := op.DeleteSettingItemByKey(key)
+	audit.Admin(c, model.AuditSettingDelete, key, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -0,0 +1,191 @@
+package handles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ShareReq struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	// Password is kept when updating if it's empty, unless RemovePassword is set
+	Password       string     `json:"password"`
+	RemovePassword bool       `json:"remove_password"`
+	Expires        *time.Time `json:"expires"`
+	MaxDownloads   int64      `json:"max_downloads"`
+	Browse         bool       `json:"browse"`
+	Remark         string     `json:"remark"`
+	// MetaPassword is the password of the meta which the path belongs to
+	MetaPassword string `json:"meta_password"`
+}
+
+type ShareResp struct {
+	model.Share
+	HasPassword bool   `json:"has_password"`
+	URL         string `json:"url"`
+}
+
+func toShareResp(c *gin.Context, s model.Share) ShareResp {
+	return ShareResp{
+		Share:       s,
+		HasPassword: s.HasPassword(),
+		URL:         fmt.Sprintf("%s/s/%s", common.GetApiUrl(c.Request), s.ID),
+	}
+}
+
+func ListShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	userId := user.ID
+	if user.IsAdmin() {
+		userId = 0
+	}
+	shares, total, err := op.GetShares(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	content := make([]ShareResp, 0, len(shares))
+	for _, s := range shares {
+		content = append(content, toShareResp(c, s))
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: content,
+		Total:   total,
+	})
+}
+
+// getOwnShare returns the share if it is created by the current user or the current user is admin
+func getOwnShare(c *gin.Context, id string) (*model.Share, bool) {
+	user := c.MustGet("user").(*model.User)
+	s, err := op.GetShareById(id)
+	if err != nil || (!user.IsAdmin() && s.UserId != user.ID) {
+		common.ErrorResp(c, errs.ShareNotFound, 404)
+		return nil, false
+	}
+	return s, true
+}
+
+func GetShare(c *gin.Context) {
+	s, ok := getOwnShare(c, c.Query("id"))
+	if !ok {
+		return
+	}
+	common.SuccessResp(c, toShareResp(c, *s))
+}
+
+// checkSharePath checks whether the user can share the path and returns the real path
+func checkSharePath(c *gin.Context, user *model.User, req *ShareReq) (string, bool) {
+	if !user.CanShare() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", false
+	}
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return "", false
+	}
+	if !common.CanAccess(user, meta, reqPath, req.MetaPassword) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return "", false
+	}
+	if _, err := fs.Get(c, reqPath, &fs.GetArgs{NoLog: true}); err != nil {
+		common.ErrorResp(c, err, 400)
+		return "", false
+	}
+	return reqPath, true
+}
+
+func CreateShare(c *gin.Context) {
+	var req ShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, ok := checkSharePath(c, user, &req)
+	if !ok {
+		return
+	}
+	s := model.Share{
+		UserId:       user.ID,
+		Path:         reqPath,
+		Expires:      req.Expires,
+		MaxDownloads: req.MaxDownloads,
+		Browse:       req.Browse,
+		Remark:       req.Remark,
+	}
+	s.SetPassword(req.Password)
+	if err := op.CreateShare(&s); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toShareResp(c, s))
+}
+
+func UpdateShare(c *gin.Context) {
+	var req ShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	s, ok := getOwnShare(c, req.ID)
+	if !ok {
+		return
+	}
+	if req.Path != "" {
+		// the path is relative to the base path of the owner, the admin may update the shares of others
+		owner, err := op.GetUserById(s.UserId)
+		if err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		reqPath, ok := checkSharePath(c, owner, &req)
+		if !ok {
+			return
+		}
+		s.Path = reqPath
+	}
+	if req.Password != "" || req.RemovePassword {
+		s.SetPassword(req.Password)
+	}
+	s.Expires = req.Expires
+	s.MaxDownloads = req.MaxDownloads
+	s.Browse = req.Browse
+	s.Remark = req.Remark
+	if err := op.UpdateShare(s); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toShareResp(c, *s))
+}
+
+func DeleteShare(c *gin.Context) {
+	s, ok := getOwnShare(c, c.Query("id"))
+	if !ok {
+		return
+	}
+	if err := op.DeleteShareById(s.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,313 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// shareCookieMaxAge is how long the passwords of a share are remembered
+	shareCookieMaxAge = 24 * time.Hour
+	// shareLinkExpiration is how long the signed /d/ links of the shared files are valid
+	shareLinkExpiration = time.Hour
+)
+
+type ShareObjResp struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"is_dir"`
+	Modified time.Time `json:"modified"`
+	URL      string    `json:"url"`
+}
+
+type ShareListResp struct {
+	ID      string         `json:"id"`
+	Path    string         `json:"path"`
+	Expires *time.Time     `json:"expires"`
+	Content []ShareObjResp `json:"content"`
+}
+
+func shareCookieName(s *model.Share) string {
+	return "alist_share_" + s.ID
+}
+
+func shareCookieData(s *model.Share) string {
+	return fmt.Sprintf("share/%s/%s", s.ID, s.PwdHash)
+}
+
+func shareMetaCookieName(s *model.Share) string {
+	return "alist_share_meta_" + s.ID
+}
+
+func shareMetaCookieData(s *model.Share, meta *model.Meta) string {
+	return fmt.Sprintf("share/%s/meta/%s/%s", s.ID, meta.Path, meta.Password)
+}
+
+func wantsJSON(c *gin.Context) bool {
+	return c.Query("format") == "json" || strings.Contains(c.GetHeader("Accept"), "application/json")
+}
+
+// checkSharePassword checks the password posted in the form or the cookie set by a previous successful check,
+// the password isn't taken from the query so it doesn't end up in the logs and the history
+func checkSharePassword(c *gin.Context, s *model.Share) bool {
+	if !s.HasPassword() {
+		return true
+	}
+	if pwd, ok := c.GetPostForm("pwd"); ok {
+		if !s.ValidatePassword(pwd) {
+			return false
+		}
+		c.SetCookie(shareCookieName(s), sign.WithDuration(shareCookieData(s), shareCookieMaxAge),
+			int(shareCookieMaxAge.Seconds()), "", "", false, true)
+		return true
+	}
+	cookie, err := c.Cookie(shareCookieName(s))
+	return err == nil && sign.Verify(shareCookieData(s), cookie) == nil
+}
+
+// checkShareMetaPassword checks the password of the meta of reqPath posted in the form or the cookie,
+// the password of the meta of the shared path was given when the share was created,
+// but the metas under the shared path still ask the visitors for their passwords
+func checkShareMetaPassword(c *gin.Context, s *model.Share, meta *model.Meta, reqPath string) bool {
+	if meta == nil || meta.Password == "" || !utils.IsSubPath(s.Path, meta.Path) || utils.PathEqual(s.Path, meta.Path) {
+		return true
+	}
+	if !utils.PathEqual(meta.Path, reqPath) && !meta.PSub {
+		return true
+	}
+	if pwd, ok := c.GetPostForm("meta_pwd"); ok {
+		if pwd != meta.Password {
+			return false
+		}
+		c.SetCookie(shareMetaCookieName(s), sign.WithDuration(shareMetaCookieData(s, meta), shareCookieMaxAge),
+			int(shareCookieMaxAge.Seconds()), "", "", false, true)
+		return true
+	}
+	cookie, err := c.Cookie(shareMetaCookieName(s))
+	return err == nil && sign.Verify(shareMetaCookieData(s, meta), cookie) == nil
+}
+
+// shareVisitor is the user whom the visitors of the share act as, it has the base path and limits of the owner,
+// but it can't see the hidden objects or skip the meta passwords as the owner may do
+func shareVisitor(owner *model.User) *model.User {
+	visitor := *owner
+	visitor.Role = model.GENERAL
+	visitor.Permission &^= 1 | 1<<1
+	return &visitor
+}
+
+func shareErrorResp(c *gin.Context, err error, code int) {
+	if wantsJSON(c) {
+		common.ErrorResp(c, err, code)
+		return
+	}
+	c.Status(code)
+	renderSharePage(c, sharePageData{Error: err.Error()})
+	c.Abort()
+}
+
+// ShareView serves the public /s/:id/*path route, folders are listed if browsing is enabled
+// and files are redirected to their signed /d/ links after the downloads are counted.
+// The passwords are posted to the same route.
+func ShareView(c *gin.Context) {
+	s, owner, err := op.GetValidShare(c.Param("id"))
+	if err != nil {
+		code := 404
+		if errors.Is(err, errs.ShareExpired) {
+			code = 410
+		}
+		shareErrorResp(c, errors.Cause(err), code)
+		return
+	}
+	if !checkSharePassword(c, s) {
+		if wantsJSON(c) {
+			common.ErrorResp(c, errs.WrongSharePassword, 401)
+			return
+		}
+		data := sharePageData{ID: s.ID, NeedPassword: "pwd"}
+		if _, ok := c.GetPostForm("pwd"); ok {
+			data.Error = errs.WrongSharePassword.Error()
+		}
+		renderSharePage(c, data)
+		return
+	}
+	subPath := utils.FixAndCleanPath(c.Param("path"))
+	reqPath := stdpath.Join(s.Path, subPath)
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		shareErrorResp(c, err, 500)
+		return
+	}
+	visitor := shareVisitor(owner)
+	// the shared path itself is chosen by the owner, so only the objects under it may be hidden
+	if !utils.PathEqual(reqPath, s.Path) && meta != nil && !common.CanAccess(visitor, meta, reqPath, meta.Password) {
+		shareErrorResp(c, errs.ObjectNotFound, 404)
+		return
+	}
+	if !checkShareMetaPassword(c, s, meta, reqPath) {
+		if wantsJSON(c) {
+			common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+			return
+		}
+		data := sharePageData{ID: s.ID, NeedPassword: "meta_pwd"}
+		if _, ok := c.GetPostForm("meta_pwd"); ok {
+			data.Error = "password is incorrect"
+		}
+		renderSharePage(c, data)
+		return
+	}
+	ctx := context.WithValue(context.WithValue(c.Request.Context(), "user", visitor), "meta", meta)
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		shareErrorResp(c, errs.ObjectNotFound, 404)
+		return
+	}
+	if err := op.IncreaseShareAccessed(s.ID); err != nil {
+		log.Errorf("failed increase accessed count of share %s: %+v", s.ID, err)
+	}
+	if !obj.IsDir() {
+		serveShareFile(c, s, reqPath)
+		return
+	}
+	if !s.Browse {
+		shareErrorResp(c, errs.ShareBrowseDisabled, 403)
+		return
+	}
+	objs, err := fs.List(ctx, reqPath, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		shareErrorResp(c, err, 500)
+		return
+	}
+	base := fmt.Sprintf("%s/s/%s", common.GetApiUrl(c.Request), s.ID)
+	content := make([]ShareObjResp, 0, len(objs))
+	for _, o := range objs {
+		content = append(content, ShareObjResp{
+			Name:     o.GetName(),
+			Size:     o.GetSize(),
+			IsDir:    o.IsDir(),
+			Modified: o.ModTime(),
+			URL:      base + utils.EncodePath(stdpath.Join(subPath, o.GetName()), true),
+		})
+	}
+	if wantsJSON(c) {
+		common.SuccessResp(c, ShareListResp{
+			ID:      s.ID,
+			Path:    subPath,
+			Expires: s.Expires,
+			Content: content,
+		})
+		return
+	}
+	data := sharePageData{ID: s.ID, Path: subPath, Content: content}
+	if subPath != "/" {
+		data.Parent = base + utils.EncodePath(stdpath.Dir(subPath), true)
+	}
+	renderSharePage(c, data)
+}
+
+// serveShareFile counts the download and redirects to the signed /d/ link of the file. The range requests
+// which don't start at the beginning are not counted, so a resumed download is only counted once.
+func serveShareFile(c *gin.Context, s *model.Share, reqPath string) {
+	if isFirstRange(c.Request) {
+		if err := op.IncreaseShareDownloads(s.ID); err != nil {
+			shareErrorResp(c, errors.Cause(err), 403)
+			return
+		}
+	}
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
+	c.Redirect(http.StatusFound, fmt.Sprintf("%s/d%s?sign=%s", common.GetApiUrl(c.Request),
+		utils.EncodePath(reqPath, true), sign.WithDuration(reqPath, shareLinkExpiration)))
+}
+
+// isFirstRange reports whether the request downloads the file from the beginning
+func isFirstRange(r *http.Request) bool {
+	rangeHeader := r.Header.Get("Range")
+	return rangeHeader == "" || strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(rangeHeader, "bytes=")), "0-")
+}
+
+type sharePageData struct {
+	ID      string
+	Path    string
+	Parent  string
+	Content []ShareObjResp
+	// NeedPassword is the name of the password asked for, pwd of the share or meta_pwd of the meta
+	NeedPassword string
+	Error        string
+}
+
+func renderSharePage(c *gin.Context, data sharePageData) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := sharePageTmpl.Execute(c.Writer, data); err != nil {
+		log.Errorf("failed render share page: %+v", err)
+	}
+}
+
+var sharePageTmpl = template.Must(template.New("share").Funcs(template.FuncMap{
+	"size": func(size int64) string {
+		if size < 1024 {
+			return fmt.Sprintf("%d B", size)
+		}
+		units := []string{"B", "KB", "MB", "GB", "TB"}
+		f := float64(size)
+		i := 0
+		for f >= 1024 && i < len(units)-1 {
+			f /= 1024
+			i++
+		}
+		return fmt.Sprintf("%.1f %s", f, units[i])
+	},
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Path}}{{.Path}}{{else}}Share{{end}}</title>
+<style>
+body{font-family:sans-serif;max-width:960px;margin:2em auto;padding:0 1em;color:#333}
+table{width:100%;border-collapse:collapse}
+td,th{padding:.4em;text-align:left;border-bottom:1px solid #eee}
+a{color:#1890ff;text-decoration:none}
+.error{color:#e53e3e}
+</style>
+</head>
+<body>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if .NeedPassword}}
+<form method="post">
+<input type="password" name="{{.NeedPassword}}" placeholder="Password" autofocus>
+<button type="submit">OK</button>
+</form>
+{{else if .ID}}
+<h3>{{.Path}}</h3>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{if .Parent}}<tr><td><a href="{{.Parent}}">..</a></td><td></td><td></td></tr>{{end}}
+{{range .Content}}
+<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{size .Size}}{{end}}</td><td>{{time .Modified}}</td></tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
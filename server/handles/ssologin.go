@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -264,6 +265,7 @@ func OIDCLoginCallback(c *gin.Context) {
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 		}
+		audit.Login(user.Username, c.ClientIP(), "sso", err)
 		if useCompatibility {
 			c.Redirect(302, common.GetApiUrl(c.Request)+"/@login?token="+token)
 			return
@@ -430,6 +432,7 @@ func SSOLoginCallback(c *gin.Context) {
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 	}
+	audit.Login(user.Username, c.ClientIP(), "sso", err)
 	if usecompatibility {
 		c.Redirect(302, common.GetApiUrl(c.Request)+"/@login?token="+token)
 		return
@@ -4,8 +4,10 @@ import (
 	"context"
 	"strconv"
 
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -38,7 +40,14 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if id, err := op.CreateStorage(c, req); err != nil {
+	req.Managed = false
+	if err := op.ValidateStorage(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := op.CreateStorage(c, req)
+	audit.Admin(c, model.AuditStorageCreate, req.MountPath, err)
+	if err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
 		}, true)
@@ -56,7 +65,9 @@ func CopyStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if _, err := op.CopyStorageById(c, uint(id)); err != nil {
+	_, err = op.CopyStorageById(c, uint(id))
+	audit.Admin(c, model.AuditStorageCreate, storageTarget(uint(id)), err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -69,7 +80,17 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.UpdateStorage(c, req); err != nil {
+	if managedStorage(c, req.ID) {
+		return
+	}
+	req.Managed = false
+	if err := op.ValidateStorage(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err := op.UpdateStorage(c, req)
+	audit.Admin(c, model.AuditStorageUpdate, req.MountPath, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -83,7 +104,13 @@ func DeleteStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteStorageById(c, uint(id)); err != nil {
+	if managedStorage(c, uint(id)) {
+		return
+	}
+	target := storageTarget(uint(id))
+	err = op.DeleteStorageById(c, uint(id))
+	audit.Admin(c, model.AuditStorageDelete, target, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -97,7 +124,12 @@ func DisableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DisableStorage(c, uint(id)); err != nil {
+	if managedStorage(c, uint(id)) {
+		return
+	}
+	err = op.DisableStorage(c, uint(id))
+	audit.Admin(c, model.AuditStorageDisable, storageTarget(uint(id)), err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -111,13 +143,35 @@ func EnableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.EnableStorage(c, uint(id)); err != nil {
+	if managedStorage(c, uint(id)) {
+		return
+	}
+	err = op.EnableStorage(c, uint(id))
+	audit.Admin(c, model.AuditStorageEnable, storageTarget(uint(id)), err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
 	common.SuccessResp(c)
 }
 
+// managedStorage responds the error if the storage is managed by the storages file
+func managedStorage(c *gin.Context, id uint) bool {
+	if storage, err := db.GetStorageById(id); err == nil && storage.Managed {
+		common.ErrorResp(c, errs.ManagedByFile, 403)
+		return true
+	}
+	return false
+}
+
+// storageTarget returns the mount path of the storage for audit logs
+func storageTarget(id uint) string {
+	if storage, err := db.GetStorageById(id); err == nil {
+		return storage.MountPath
+	}
+	return strconv.Itoa(int(id))
+}
+
 func GetStorage(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
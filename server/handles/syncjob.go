@@ -0,0 +1,138 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/syncjob"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func ListSyncJobs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	jobs, total, err := syncjob.GetJobs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: jobs,
+		Total:   total,
+	})
+}
+
+func GetSyncJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	job, err := syncjob.GetJob(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, job)
+}
+
+func syncJobErrResp(c *gin.Context, err error) {
+	if errors.Is(err, errs.SyncPathsOverlap) || errors.Is(err, errs.InvalidSyncPeriod) || errors.Is(err, errs.SyncJobRunning) ||
+		errors.Is(err, errs.InvalidSyncJob) {
+		common.ErrorResp(c, err, 400)
+	} else {
+		common.ErrorResp(c, err, 500, true)
+	}
+}
+
+func CreateSyncJob(c *gin.Context) {
+	var req model.SyncJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := syncjob.CreateJob(&req); err != nil {
+		syncJobErrResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{"id": req.ID})
+}
+
+func UpdateSyncJob(c *gin.Context) {
+	var req model.SyncJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := syncjob.UpdateJob(&req); err != nil {
+		syncJobErrResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteSyncJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := syncjob.DeleteJob(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RunSyncJob starts the job in background, the result can be got from the last_run of the job
+func RunSyncJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if _, err := syncjob.GetJob(uint(id)); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := syncjob.Start(uint(id)); err != nil {
+		syncJobErrResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// DryRunSyncJob returns the actions which the job would do
+func DryRunSyncJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	summary, actions, err := syncjob.DryRun(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"summary": summary,
+		"actions": actions,
+	})
+}
+
+func StopSyncJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	syncjob.Stop(uint(id))
+	common.SuccessResp(c)
+}
@@ -0,0 +1,13 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/throttle"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListThrottles lists the bandwidth limits and the current throughput of the global,
+// storage and user buckets, the buckets of a scope are only listed after it transferred something
+func ListThrottles(c *gin.Context) {
+	common.SuccessResp(c, throttle.Stats())
+}
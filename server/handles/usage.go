@@ -0,0 +1,99 @@
+package handles
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListUserUsages lists the users with their quotas and total usages
+func ListUserUsages(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	users, total, err := op.GetUsers(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	usages, err := db.GetUsagesGroupByUser(ids)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	usageMap := make(map[uint]model.UserUsage, len(usages))
+	for _, u := range usages {
+		usageMap[u.UserId] = u
+	}
+	content := make([]model.UserUsage, len(users))
+	for i, u := range users {
+		usage := usageMap[u.ID]
+		content[i] = model.UserUsage{
+			UserId:     u.ID,
+			Username:   u.Username,
+			QuotaBytes: u.QuotaBytes,
+			QuotaFiles: u.QuotaFiles,
+			Bytes:      usage.Bytes,
+			Files:      usage.Files,
+		}
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: content,
+		Total:   total,
+	})
+}
+
+// ListMountUsages lists the total usages of all storages
+func ListMountUsages(c *gin.Context) {
+	usages, err := db.GetUsagesGroupByMount()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	usageMap := make(map[string]model.MountUsage, len(usages))
+	for _, u := range usages {
+		usageMap[u.MountPath] = u
+	}
+	for _, storage := range op.GetAllStorages() {
+		mountPath := storage.GetStorage().MountPath
+		usage := usageMap[mountPath]
+		usage.MountPath = mountPath
+		usage.QuotaBytes = storage.GetStorage().QuotaBytes
+		usageMap[mountPath] = usage
+	}
+	content := make([]model.MountUsage, 0, len(usageMap))
+	for _, u := range usageMap {
+		content = append(content, u)
+	}
+	sort.Slice(content, func(i, j int) bool {
+		return content[i].MountPath < content[j].MountPath
+	})
+	common.SuccessResp(c, content)
+}
+
+// GetUserUsage returns the usages of the user in each storage
+func GetUserUsage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	usages, err := db.GetUsagesByUserId(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, usages)
+}
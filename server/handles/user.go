@@ -3,6 +3,8 @@ package handles
 import (
 	"strconv"
 
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -42,7 +44,10 @@ func CreateUser(c *gin.Context) {
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
-	if err := op.CreateUser(&req); err != nil {
+	req.Managed = false
+	err := op.CreateUser(&req)
+	audit.Admin(c, model.AuditUserCreate, req.Username, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -60,6 +65,11 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	if user.Managed {
+		common.ErrorResp(c, errs.ManagedByFile, 403)
+		return
+	}
+	req.Managed = false
 	if user.Role != req.Role {
 		common.ErrorStrResp(c, "role can not be changed", 400)
 		return
@@ -78,7 +88,9 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
 	}
-	if err := op.UpdateUser(&req); err != nil {
+	err = op.UpdateUser(&req)
+	audit.Admin(c, model.AuditUserUpdate, req.Username, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -92,7 +104,17 @@ func DeleteUser(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteUserById(uint(id)); err != nil {
+	target := strconv.Itoa(id)
+	if user, err := op.GetUserById(uint(id)); err == nil {
+		if user.Managed {
+			common.ErrorResp(c, errs.ManagedByFile, 403)
+			return
+		}
+		target = user.Username
+	}
+	err = op.DeleteUserById(uint(id))
+	audit.Admin(c, model.AuditUserDelete, target, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/alist-org/alist/v3/internal/audit"
 	"github.com/alist-org/alist/v3/internal/authn"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
@@ -116,6 +117,7 @@ func FinishAuthnLogin(c *gin.Context) {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
+	audit.Login(user.Username, c.ClientIP(), "webauthn", nil)
 	common.SuccessResp(c, gin.H{"token": token})
 }
 
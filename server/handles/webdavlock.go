@@ -0,0 +1,67 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/alist-org/alist/v3/server/webdav"
+	"github.com/gin-gonic/gin"
+)
+
+type WebDAVLockResp struct {
+	Token     string     `json:"token"`
+	Root      string     `json:"root"`
+	Owner     string     `json:"owner"`
+	ZeroDepth bool       `json:"zero_depth"`
+	Temporary bool       `json:"temporary"`
+	Held      bool       `json:"held"`
+	Expiry    *time.Time `json:"expiry"`
+}
+
+func ListWebDAVLocks(c *gin.Context) {
+	lister, ok := webdav.GetLockSystem().(webdav.LockLister)
+	if !ok {
+		common.ErrorStrResp(c, "the lock system can't list the locks", 400)
+		return
+	}
+	locks, err := lister.Locks(time.Now())
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]WebDAVLockResp, 0, len(locks))
+	for _, l := range locks {
+		resp = append(resp, WebDAVLockResp{
+			Token:     l.Token,
+			Root:      l.Root,
+			Owner:     l.OwnerXML,
+			ZeroDepth: l.ZeroDepth,
+			Temporary: l.Temporary,
+			Held:      l.Held,
+			Expiry:    l.Expiry,
+		})
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   int64(len(resp)),
+	})
+}
+
+// ReleaseWebDAVLock force-releases the lock, the locks held by the requests being handled can't be released
+func ReleaseWebDAVLock(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		common.ErrorStrResp(c, "token is required", 400)
+		return
+	}
+	switch err := webdav.GetLockSystem().Unlock(time.Now(), token); err {
+	case nil:
+		common.SuccessResp(c)
+	case webdav.ErrNoSuchLock:
+		common.ErrorStrResp(c, "lock not found", 404)
+	case webdav.ErrLocked:
+		common.ErrorStrResp(c, "the lock is being used by a request, try again later", 409)
+	default:
+		common.ErrorResp(c, err, 500, true)
+	}
+}
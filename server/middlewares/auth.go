@@ -2,13 +2,16 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -45,6 +48,10 @@ func Auth(c *gin.Context) {
 		c.Next()
 		return
 	}
+	if strings.HasPrefix(token, model.ApiTokenPrefix) {
+		apiTokenAuth(c, token)
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -73,6 +80,52 @@ func Auth(c *gin.Context) {
 	c.Next()
 }
 
+// apiTokenAuth sets the user restricted by the api token, which needs the read, write or admin scope
+func apiTokenAuth(c *gin.Context, token string) {
+	t, user, err := op.GetUserByApiToken(token)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "Current user is disabled, replace please", 401)
+		c.Abort()
+		return
+	}
+	if !t.HasScope(model.ScopeRead) && !t.HasScope(model.ScopeWrite) && !t.HasScope(model.ScopeAdmin) {
+		common.ErrorResp(c, errors.Wrap(errs.ScopeRequired, model.ScopeRead), 403)
+		c.Abort()
+		return
+	}
+	c.Set("user", user)
+	c.Set("api_token", t)
+	log.Debugf("use api token %d: %+v", t.ID, user)
+	c.Next()
+}
+
+// NoApiToken rejects the requests authorized by the api tokens, it's used for the changes of the credentials
+func NoApiToken(c *gin.Context) {
+	if _, ok := c.Get("api_token"); ok {
+		common.ErrorStrResp(c, "Api token can't be used here, login please", 403)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// ApiTokenScope rejects the requests authorized by the api tokens without the scope
+func ApiTokenScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t, ok := c.Value("api_token").(*model.ApiToken); ok && !t.HasScope(scope) {
+			common.ErrorResp(c, errors.Wrap(errs.ScopeRequired, scope), 403)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 func Authn(c *gin.Context) {
 	token := c.GetHeader("Authorization")
 	if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
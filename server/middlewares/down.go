@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"crypto/subtle"
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -27,6 +28,9 @@ func Down(c *gin.Context) {
 		}
 	}
 	c.Set("meta", meta)
+	if user := downUser(c); user != nil {
+		c.Set("user", user)
+	}
 	// verify sign
 	if needSign(meta, rawPath) {
 		s := c.Query("sign")
@@ -40,6 +44,34 @@ func Down(c *gin.Context) {
 	c.Next()
 }
 
+// downUser returns the user of the token for the limits of the proxied downloads, the download isn't refused
+// by an invalid token since it's authorized by the sign. The signed links without the token are taken as
+// downloaded by the guest
+func downUser(c *gin.Context) *model.User {
+	token := c.GetHeader("Authorization")
+	if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
+		if admin, err := op.GetAdmin(); err == nil {
+			return admin
+		}
+	} else if strings.HasPrefix(token, model.ApiTokenPrefix) {
+		if _, user, err := op.GetUserByApiToken(token); err == nil && !user.Disabled {
+			return user
+		}
+	} else if token != "" {
+		if claims, err := common.ParseToken(token); err == nil {
+			user, err := op.GetUserByName(claims.Username)
+			if err == nil && claims.PwdTS == user.PwdTS && !user.Disabled {
+				return user
+			}
+		}
+	}
+	guest, err := op.GetGuest()
+	if err != nil {
+		return nil
+	}
+	return guest
+}
+
 // TODO: implement
 // path maybe contains # ? etc.
 func parsePath(path string) string {
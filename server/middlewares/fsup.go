@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -40,5 +41,15 @@ func FsUp(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	// the content length of form is a little larger than the file, it's good enough to check the quota
+	size := c.Request.ContentLength
+	if size < 0 {
+		size = 0
+	}
+	if err := quota.Check(c, stdpath.Dir(path), size, 1); err != nil {
+		common.ErrorResp(c, err, 413)
+		c.Abort()
+		return
+	}
 	c.Next()
 }
@@ -3,6 +3,7 @@ package middlewares
 import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/search"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
@@ -17,3 +18,13 @@ func SearchIndex(c *gin.Context) {
 		c.Next()
 	}
 }
+
+// Search checks whether there is any index to search in, the default or the named ones
+func Search(c *gin.Context) {
+	if !search.Available() {
+		common.ErrorResp(c, errs.SearchNotAvailable, 500)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
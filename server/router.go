@@ -7,6 +7,7 @@ import (
 	"github.com/alist-org/alist/v3/cmd/flags"
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/message"
+	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/alist-org/alist/v3/server/handles"
@@ -58,6 +59,11 @@ func Init(e *gin.Engine) {
 	WebDav(g.Group("/dav"))
 	S3(g.Group("/s3"))
 
+	g.GET("/s/:id", handles.ShareView)
+	g.GET("/s/:id/*path", handles.ShareView)
+	g.POST("/s/:id", handles.ShareView)
+	g.POST("/s/:id/*path", handles.ShareView)
+
 	g.GET("/d/*path", middlewares.Down, handles.Down)
 	g.GET("/p/*path", middlewares.Down, handles.Proxy)
 	g.HEAD("/d/*path", middlewares.Down, handles.Down)
@@ -71,9 +77,21 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	// the credentials can't be changed with the api tokens
+	auth.POST("/me/update", middlewares.NoApiToken, handles.UpdateCurrent)
+	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
+	auth.POST("/me/sshkey/add", middlewares.NoApiToken, handles.AddMyPublicKey)
+	auth.POST("/me/sshkey/delete", middlewares.NoApiToken, handles.DeleteMyPublicKey)
+	auth.GET("/me/tokens/list", handles.ListMyApiTokens)
+	auth.POST("/me/tokens/create", middlewares.NoApiToken, handles.CreateMyApiToken)
+	auth.POST("/me/tokens/revoke", middlewares.NoApiToken, handles.RevokeMyApiToken)
+	// the s3 keys are managed with the api tokens only with the s3 scope
+	s3keys := auth.Group("/me/s3keys", middlewares.ApiTokenScope(model.ScopeS3))
+	s3keys.GET("/list", handles.ListMyS3Keys)
+	s3keys.POST("/create", handles.CreateMyS3Key)
+	s3keys.POST("/delete", handles.DeleteMyS3Key)
+	auth.POST("/auth/2fa/generate", middlewares.NoApiToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.NoApiToken, handles.Verify2FA)
 
 	// auth
 	api.GET("/auth/sso", handles.SSOLoginRedirect)
@@ -100,6 +118,7 @@ func Init(e *gin.Engine) {
 	public.Any("/offline_download_tools", handles.OfflineDownloadTools)
 
 	_fs(auth.Group("/fs"))
+	share(auth.Group("/share"))
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
@@ -125,6 +144,16 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.GET("/usage", handles.GetUserUsage)
+	user.GET("/usage/list", handles.ListUserUsages)
+	user.GET("/sshkey/list", handles.ListPublicKeys)
+	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/s3keys/list", handles.ListS3Keys)
+	user.POST("/s3keys/delete", handles.DeleteS3Key)
+
+	webdavLock := g.Group("/webdav/lock")
+	webdavLock.GET("/list", handles.ListWebDAVLocks)
+	webdavLock.POST("/release", handles.ReleaseWebDAVLock)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -136,6 +165,8 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/usage", handles.ListMountUsages)
+	storage.GET("/health", handles.ListStorageHealth)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -151,9 +182,28 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_aria2", handles.SetAria2)
 	setting.POST("/set_qbit", handles.SetQbittorrent)
 
+	bundle := g.Group("/bundle")
+	bundle.POST("/export", handles.ExportBundle)
+	bundle.POST("/import", handles.ImportBundle)
+
 	task := g.Group("/task")
 	handles.SetupTaskRoute(task)
 
+	g.GET("/audit/list", handles.ListAuditLogs)
+	g.GET("/throttle/list", handles.ListThrottles)
+	g.GET("/cache/list", handles.ListCaches)
+	g.POST("/cache/purge", handles.PurgeCaches)
+
+	syncJob := g.Group("/sync")
+	syncJob.GET("/list", handles.ListSyncJobs)
+	syncJob.GET("/get", handles.GetSyncJob)
+	syncJob.POST("/create", handles.CreateSyncJob)
+	syncJob.POST("/update", handles.UpdateSyncJob)
+	syncJob.POST("/delete", handles.DeleteSyncJob)
+	syncJob.POST("/run", handles.RunSyncJob)
+	syncJob.POST("/dry_run", handles.DryRunSyncJob)
+	syncJob.POST("/stop", handles.StopSyncJob)
+
 	ms := g.Group("/message")
 	ms.POST("/get", message.HttpInstance.GetHandle)
 	ms.POST("/send", message.HttpInstance.SendHandle)
@@ -161,14 +211,28 @@ func admin(g *gin.RouterGroup) {
 	index := g.Group("/index")
 	index.POST("/build", middlewares.SearchIndex, handles.BuildIndex)
 	index.POST("/update", middlewares.SearchIndex, handles.UpdateIndex)
+	index.POST("/delta", middlewares.SearchIndex, handles.DeltaRefreshIndex)
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
+
+	// the named indexes share the handlers of the default index with the id in query
+	searchIndex := g.Group("/search_index")
+	searchIndex.GET("/list", handles.ListSearchIndexes)
+	searchIndex.GET("/get", handles.GetSearchIndex)
+	searchIndex.POST("/create", handles.CreateSearchIndex)
+	searchIndex.POST("/update", handles.UpdateSearchIndex)
+	searchIndex.POST("/delete", handles.DeleteSearchIndex)
+	searchIndex.POST("/build", handles.BuildIndex)
+	searchIndex.POST("/refresh", handles.UpdateIndex)
+	searchIndex.POST("/stop", handles.StopIndex)
+	searchIndex.POST("/clear", handles.ClearIndex)
+	searchIndex.GET("/progress", handles.GetProgress)
 }
 
 func _fs(g *gin.RouterGroup) {
 	g.Any("/list", handles.FsList)
-	g.Any("/search", middlewares.SearchIndex, handles.Search)
+	g.Any("/search", middlewares.Search, handles.Search)
 	g.Any("/get", handles.FsGet)
 	g.Any("/other", handles.FsOther)
 	g.Any("/dirs", handles.FsDirs)
@@ -182,6 +246,9 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy_item", handles.FsCopyItem)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.Any("/trash/list", handles.FsTrashList)
+	g.POST("/trash/restore", handles.FsTrashRestore)
+	g.POST("/trash/purge", handles.FsTrashPurge)
 	g.PUT("/put", middlewares.FsUp, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, handles.FsForm)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
@@ -190,6 +257,14 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
 }
 
+func share(g *gin.RouterGroup) {
+	g.GET("/list", handles.ListShares)
+	g.GET("/get", handles.GetShare)
+	g.POST("/create", handles.CreateShare)
+	g.POST("/update", handles.UpdateShare)
+	g.POST("/delete", handles.DeleteShare)
+}
+
 func Cors(r *gin.Engine) {
 	config := cors.DefaultConfig()
 	//config.AllowAllOrigins = true
@@ -20,6 +20,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/stream"
 	"github.com/alist-org/alist/v3/pkg/http_range"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
 	"github.com/ncw/swift/v2"
 )
 
@@ -32,29 +33,69 @@ var (
 // backend for gofakes3
 type s3Backend struct {
 	meta *sync.Map
+	// userId is the user the requests are served as
+	userId uint
 }
 
 // newBackend creates a new SimpleBucketBackend.
-func newBackend() gofakes3.Backend {
+func newBackend(userId uint) gofakes3.Backend {
 	return &s3Backend{
-		meta: new(sync.Map),
+		meta:   new(sync.Map),
+		userId: userId,
 	}
 }
 
-// ListBuckets always returns the default bucket.
+// user returns the user of the backend and the context with it
+func (b *s3Backend) user() (context.Context, *model.User, error) {
+	user, err := op.GetUserById(b.userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return context.WithValue(context.Background(), "user", user), user, nil
+}
+
+// objectPath returns the path of the object in the bucket for the user of the backend
+func (b *s3Backend) objectPath(bucketName, objectName string) (context.Context, *model.User, string, error) {
+	ctx, user, err := b.user()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	bucket, err := getBucketByName(user, bucketName)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	fp, err := user.JoinPath(path.Join(bucket.Path, objectName))
+	if err != nil {
+		return nil, nil, "", gofakes3.KeyNotFound(objectName)
+	}
+	return ctx, user, fp, nil
+}
+
+// ListBuckets returns the buckets the user can see.
 func (b *s3Backend) ListBuckets() ([]gofakes3.BucketInfo, error) {
+	ctx, user, err := b.user()
+	if err != nil {
+		return nil, err
+	}
 	buckets, err := getAndParseBuckets()
 	if err != nil {
 		return nil, err
 	}
 	var response []gofakes3.BucketInfo
-	ctx := context.Background()
 	for _, b := range buckets {
-		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		if !b.allowed(user) {
+			continue
+		}
+		var created time.Time
+		if fp, err := user.JoinPath(b.Path); err == nil {
+			if node, err := fs.Get(ctx, fp, &fs.GetArgs{}); err == nil {
+				created = node.ModTime()
+			}
+		}
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
 			Name:         b.Name,
-			CreationDate: gofakes3.NewContentTime(node.ModTime()),
+			CreationDate: gofakes3.NewContentTime(created),
 		})
 	}
 	return response, nil
@@ -62,11 +103,10 @@ func (b *s3Backend) ListBuckets() ([]gofakes3.BucketInfo, error) {
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	ctx, user, bucketPath, err := b.objectPath(bucketName, "")
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
 
 	if prefix == nil {
 		prefix = emptyPrefix
@@ -83,7 +123,7 @@ func (b *s3Backend) ListBucket(bucketName string, prefix *gofakes3.Prefix, page
 	response := gofakes3.NewObjectList()
 	path, remaining := prefixParser(prefix)
 
-	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
+	err = b.entryListR(ctx, user, bucketPath, path, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
 		// AWS just returns an empty list
 		response = gofakes3.NewObjectList()
@@ -98,15 +138,14 @@ func (b *s3Backend) ListBucket(bucketName string, prefix *gofakes3.Prefix, page
 //
 // Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(bucketName, objectName string) (*gofakes3.Object, error) {
-	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	ctx, user, fp, err := b.objectPath(bucketName, objectName)
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
-
-	fp := path.Join(bucketPath, objectName)
 	fmeta, _ := op.GetNearestMeta(fp)
+	if !common.CanAccess(user, fmeta, fp, "") {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
 		return nil, gofakes3.KeyNotFound(objectName)
@@ -142,15 +181,14 @@ func (b *s3Backend) HeadObject(bucketName, objectName string) (*gofakes3.Object,
 
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (obj *gofakes3.Object, err error) {
-	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	ctx, user, fp, err := b.objectPath(bucketName, objectName)
 	if err != nil {
 		return nil, err
 	}
-	bucketPath := bucket.Path
-
-	fp := path.Join(bucketPath, objectName)
 	fmeta, _ := op.GetNearestMeta(fp)
+	if !common.CanAccess(user, fmeta, fp, "") {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	node, err := fs.Get(context.WithValue(ctx, "meta", fmeta), fp, &fs.GetArgs{})
 	if err != nil {
 		return nil, gofakes3.KeyNotFound(objectName)
@@ -262,14 +300,10 @@ func (b *s3Backend) PutObject(
 	meta map[string]string,
 	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
-	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	ctx, _, fp, err := b.objectPath(bucketName, objectName)
 	if err != nil {
 		return result, err
 	}
-	bucketPath := bucket.Path
-
-	fp := path.Join(bucketPath, objectName)
 	reqPath := path.Dir(fp)
 	fmeta, _ := op.GetNearestMeta(fp)
 	_, err = fs.Get(context.WithValue(ctx, "meta", fmeta), reqPath, &fs.GetArgs{})
@@ -319,7 +353,7 @@ func (b *s3Backend) PutObject(
 func (b *s3Backend) DeleteMulti(bucketName string, objects ...string) (result gofakes3.MultiDeleteResult, rerr error) {
 	for _, object := range objects {
 		if err := b.deleteObject(bucketName, object); err != nil {
-			utils.Log.Errorf("serve s3", "delete object failed: %v", err)
+			utils.Log.Errorf("serve s3: delete object failed: %v", err)
 			result.Error = append(result.Error, gofakes3.ErrorResult{
 				Code:    gofakes3.ErrInternal,
 				Message: gofakes3.ErrInternal.Message(),
@@ -342,14 +376,10 @@ func (b *s3Backend) DeleteObject(bucketName, objectName string) (result gofakes3
 
 // deleteObject deletes the object from the filesystem.
 func (b *s3Backend) deleteObject(bucketName, objectName string) error {
-	ctx := context.Background()
-	bucket, err := getBucketByName(bucketName)
+	ctx, _, fp, err := b.objectPath(bucketName, objectName)
 	if err != nil {
 		return err
 	}
-	bucketPath := bucket.Path
-
-	fp := path.Join(bucketPath, objectName)
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -373,16 +403,12 @@ func (b *s3Backend) DeleteBucket(name string) error {
 
 // BucketExists checks if the bucket exists.
 func (b *s3Backend) BucketExists(name string) (exists bool, err error) {
-	buckets, err := getAndParseBuckets()
+	_, user, err := b.user()
 	if err != nil {
 		return false, err
 	}
-	for _, b := range buckets {
-		if b.Name == name {
-			return true, nil
-		}
-	}
-	return false, nil
+	_, err = getBucketByName(user, name)
+	return err == nil, nil
 }
 
 // CopyObject copy specified object from srcKey to dstKey.
@@ -392,16 +418,18 @@ func (b *s3Backend) CopyObject(srcBucket, srcKey, dstBucket, dstKey string, meta
 		return result, nil
 	}
 
-	ctx := context.Background()
-	srcB, err := getBucketByName(srcBucket)
+	ctx, user, srcFp, err := b.objectPath(srcBucket, srcKey)
 	if err != nil {
 		return result, err
 	}
-	srcBucketPath := srcB.Path
-
-	srcFp := path.Join(srcBucketPath, srcKey)
 	fmeta, _ := op.GetNearestMeta(srcFp)
+	if !common.CanAccess(user, fmeta, srcFp, "") {
+		return result, gofakes3.KeyNotFound(srcKey)
+	}
 	srcNode, err := fs.Get(context.WithValue(ctx, "meta", fmeta), srcFp, &fs.GetArgs{})
+	if err != nil {
+		return result, gofakes3.KeyNotFound(srcKey)
+	}
 
 	c, err := b.GetObject(srcBucket, srcKey, nil)
 	if err != nil {
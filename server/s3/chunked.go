@@ -0,0 +1,150 @@
+package s3
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Mikubill/gofakes3"
+	"github.com/pkg/errors"
+)
+
+const (
+	chunkAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+	// emptySha256 is the sha256 of the empty string
+	emptySha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var errChunkSignature = errors.New("the signature of the chunk does not match")
+
+// chunkSigner computes the signatures of the chunks of aws-chunked encoding, each chunk is signed
+// with the signature of the previous one, and the first one with the signature of the request
+type chunkSigner struct {
+	key      []byte
+	date     string
+	scope    string
+	previous string
+}
+
+func sumHMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// newChunkSigner returns the signer of the request signed by the secret, the signature of the request
+// must have been verified
+func newChunkSigner(r *http.Request, secret string) (*chunkSigner, error) {
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256")
+	var credential, signature string
+	for _, field := range strings.Split(auth, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch k {
+		case "Credential":
+			credential = v
+		case "Signature":
+			signature = v
+		}
+	}
+	// the credential is <access key>/<date>/<region>/<service>/aws4_request
+	parts := strings.Split(credential, "/")
+	date := r.Header.Get("X-Amz-Date")
+	if len(parts) != 5 || signature == "" || date == "" {
+		return nil, errChunkSignature
+	}
+	key := []byte("AWS4" + secret)
+	for _, p := range parts[1:] {
+		key = sumHMAC(key, p)
+	}
+	return &chunkSigner{
+		key:      key,
+		date:     date,
+		scope:    strings.Join(parts[1:], "/"),
+		previous: signature,
+	}, nil
+}
+
+// verify checks the signature of the chunk whose data has the sha256 sum
+func (s *chunkSigner) verify(sum []byte, signature string) error {
+	stringToSign := strings.Join([]string{chunkAlgorithm, s.date, s.scope, s.previous, emptySha256, hex.EncodeToString(sum)}, "\n")
+	expected := hex.EncodeToString(sumHMAC(s.key, stringToSign))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return errChunkSignature
+	}
+	s.previous = signature
+	return nil
+}
+
+// chunkedReader decodes the body of aws-chunked encoding, the signatures of the chunks are verified
+// if signer isn't nil, and the error is returned at the end of the chunk which doesn't match
+type chunkedReader struct {
+	r         *bufio.Reader
+	signer    *chunkSigner
+	hash      hash.Hash
+	signature string
+	remain    int64
+	eof       bool
+}
+
+func newChunkedReader(r io.Reader, signer *chunkSigner) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r), signer: signer, hash: sha256.New()}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// the CRLF after the data of the chunk
+			continue
+		}
+		sizeStr, ext, _ := strings.Cut(line, ";")
+		size, err := strconv.ParseInt(sizeStr, 16, 64)
+		if err != nil || size < 0 {
+			return 0, gofakes3.ErrIncompleteBody
+		}
+		c.signature = strings.TrimPrefix(ext, "chunk-signature=")
+		c.hash.Reset()
+		if size == 0 {
+			// the final chunk is signed too
+			if err := c.verify(); err != nil {
+				return 0, err
+			}
+			c.eof = true
+			continue
+		}
+		c.remain = size
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	c.hash.Write(p[:n])
+	if c.remain == 0 {
+		if verr := c.verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+func (c *chunkedReader) verify() error {
+	if c.signer == nil {
+		return nil
+	}
+	return c.signer.verify(c.hash.Sum(nil), c.signature)
+}
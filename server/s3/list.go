@@ -3,16 +3,18 @@
 package s3
 
 import (
+	"context"
 	"path"
 	"strings"
 
 	"github.com/Mikubill/gofakes3"
+	"github.com/alist-org/alist/v3/internal/model"
 )
 
-func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
+func (b *s3Backend) entryListR(ctx context.Context, user *model.User, bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
 	fp := path.Join(bucket, fdPath)
 
-	dirEntries, err := getDirEntries(fp)
+	dirEntries, err := getDirEntries(ctx, user, fp)
 	if err != nil {
 		return err
 	}
@@ -33,7 +35,7 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 				response.AddPrefix(objectPath)
 				continue
 			}
-			err := b.entryListR(bucket, path.Join(fdPath, object), "", false, response)
+			err := b.entryListR(ctx, user, bucket, path.Join(fdPath, object), "", false, response)
 			if err != nil {
 				return err
 			}
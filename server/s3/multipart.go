@@ -0,0 +1,459 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mikubill/gofakes3"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/quota"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/ncw/swift/v2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxUploadPartNumber = 10000
+	// uploadExpiration is how long the uncompleted uploads are kept
+	uploadExpiration = 7 * 24 * time.Hour
+	// uploadCleanInterval is how often the expired uploads are removed
+	uploadCleanInterval = time.Hour
+	streamingPayload    = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+)
+
+var (
+	errAccessDenied   = errors.New("Access Denied.")
+	errStagedTooLarge = errors.New("the parts of the uncompleted uploads exceed the max staged size")
+)
+
+// isMultipart tells whether the request is handled by the multipart uploads instead of gofakes3,
+// which keeps the parts in memory
+func isMultipart(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("uploads") || query.Get("uploadId") != ""
+}
+
+// multipart handles the multipart uploads of the user, the parts are staged in the temp dir
+// and persisted in the database, so the uploads are resumed after restarting
+type multipart struct {
+	user    *model.User
+	backend *s3Backend
+	// secret is the secret access key the request is signed with, empty for the anonymous requests
+	secret string
+}
+
+func (m *multipart) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	uploadId := r.URL.Query().Get("uploadId")
+	var err error
+	switch {
+	case uploadId == "" && object == "" && r.Method == http.MethodGet:
+		err = m.listUploads(w, r, bucket)
+	case uploadId == "" && object != "" && r.Method == http.MethodPost:
+		err = m.initiate(w, r, bucket, object)
+	case uploadId != "" && object != "":
+		var upload *model.S3Upload
+		upload, err = m.getUpload(bucket, object, uploadId)
+		if err != nil {
+			break
+		}
+		switch r.Method {
+		case http.MethodPut:
+			err = m.uploadPart(w, r, upload)
+		case http.MethodGet:
+			err = m.listParts(w, r, upload)
+		case http.MethodPost:
+			err = m.complete(w, r, upload)
+		case http.MethodDelete:
+			err = m.abort(w, upload)
+		default:
+			err = gofakes3.ErrMethodNotAllowed
+		}
+	default:
+		err = gofakes3.ErrNotImplemented
+	}
+	if err != nil {
+		code := gofakes3.ErrInternal
+		switch {
+		case errors.Is(err, errChunkSignature):
+			writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+			return
+		case errors.Is(err, errAccessDenied):
+			writeError(w, http.StatusForbidden, "AccessDenied", err.Error())
+			return
+		case errors.Is(err, errStagedTooLarge), errors.Is(err, errs.QuotaExceeded), errors.Is(err, errs.StorageQuotaExceeded):
+			writeError(w, http.StatusForbidden, "QuotaExceeded", err.Error())
+			return
+		}
+		if e, ok := err.(gofakes3.Error); ok {
+			code = e.ErrorCode()
+		} else {
+			log.Errorf("s3: failed multipart upload of %s: %+v", r.URL.Path, err)
+		}
+		writeError(w, code.Status(), string(code), code.Message())
+	}
+}
+
+// putPath returns the path to put the object for the current base path of the user,
+// the user must still be able to write and the parent dir must be accessible
+func (m *multipart) putPath(bucket, object string) (context.Context, string, error) {
+	ctx, user, fp, err := m.backend.objectPath(bucket, object)
+	if err != nil {
+		return nil, "", err
+	}
+	if !user.CanWrite() {
+		return nil, "", errAccessDenied
+	}
+	fmeta, _ := op.GetNearestMeta(fp)
+	if _, err := fs.Get(context.WithValue(ctx, "meta", fmeta), path.Dir(fp), &fs.GetArgs{}); err != nil {
+		return nil, "", gofakes3.KeyNotFound(object)
+	}
+	return ctx, fp, nil
+}
+
+func (m *multipart) initiate(w http.ResponseWriter, r *http.Request, bucket, object string) error {
+	_, fp, err := m.putPath(bucket, object)
+	if err != nil {
+		return err
+	}
+	upload := model.S3Upload{
+		ID:       uuid.NewString(),
+		UserId:   m.user.ID,
+		Bucket:   bucket,
+		Key:      object,
+		Path:     fp,
+		Mimetype: r.Header.Get("Content-Type"),
+		Created:  time.Now(),
+	}
+	if val := r.Header.Get("X-Amz-Meta-Mtime"); val != "" {
+		upload.Modified, _ = swift.FloatStringToTime(val)
+	}
+	if err := os.MkdirAll(uploadDir(upload.ID), 0o777); err != nil {
+		return err
+	}
+	if err := db.CreateS3Upload(&upload); err != nil {
+		_ = os.RemoveAll(uploadDir(upload.ID))
+		return err
+	}
+	return writeXML(w, gofakes3.InitiateMultipartUpload{
+		Bucket:   bucket,
+		Key:      object,
+		UploadID: gofakes3.UploadID(upload.ID),
+	})
+}
+
+func (m *multipart) getUpload(bucket, object, uploadId string) (*model.S3Upload, error) {
+	upload, err := db.GetS3UploadById(uploadId)
+	if err != nil || upload.UserId != m.user.ID || upload.Bucket != bucket || upload.Key != object {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	// the bucket may be removed or hidden from the user after the upload is initiated
+	if _, err := getBucketByName(m.user, bucket); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (m *multipart) uploadPart(w http.ResponseWriter, r *http.Request, upload *model.S3Upload) error {
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return gofakes3.ErrNotImplemented
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number <= 0 || number > maxUploadPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	size := r.ContentLength
+	var body io.Reader = r.Body
+	contentSha256 := r.Header.Get("X-Amz-Content-Sha256")
+	if contentSha256 == streamingPayload {
+		var signer *chunkSigner
+		if m.secret != "" {
+			if signer, err = newChunkSigner(r, m.secret); err != nil {
+				return err
+			}
+		}
+		body = newChunkedReader(r.Body, signer)
+		size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil {
+			return gofakes3.ErrMissingContentLength
+		}
+	}
+	if size < 0 {
+		return gofakes3.ErrMissingContentLength
+	}
+	if err := m.checkStaged(upload, number, size); err != nil {
+		return err
+	}
+
+	partPath := filepath.Join(uploadDir(upload.ID), strconv.Itoa(number))
+	tmp, err := os.CreateTemp(uploadDir(upload.ID), "part-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	md5Hash := md5.New()
+	hashes := []io.Writer{tmp, md5Hash}
+	var sha256Hash hash.Hash
+	if len(contentSha256) == sha256.Size*2 {
+		sha256Hash = sha256.New()
+		hashes = append(hashes, sha256Hash)
+	}
+	n, err := io.Copy(io.MultiWriter(hashes...), io.LimitReader(body, size+1))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	if contentMD5 := r.Header.Get("Content-MD5"); contentMD5 != "" &&
+		contentMD5 != base64.StdEncoding.EncodeToString(md5Hash.Sum(nil)) {
+		return gofakes3.ErrBadDigest
+	}
+	if sha256Hash != nil && !strings.EqualFold(contentSha256, hex.EncodeToString(sha256Hash.Sum(nil))) {
+		return gofakes3.ErrBadDigest
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), partPath); err != nil {
+		return err
+	}
+	etag := `"` + hex.EncodeToString(md5Hash.Sum(nil)) + `"`
+	err = db.SaveS3UploadPart(&model.S3UploadPart{
+		UploadId:   upload.ID,
+		PartNumber: number,
+		Size:       size,
+		ETag:       etag,
+		Modified:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag)
+	return nil
+}
+
+// checkStaged checks the part of size can be staged, the parts of all the uploads of the user
+// are limited by the max staged size, and they must fit in what's left of the quotas
+func (m *multipart) checkStaged(upload *model.S3Upload, number int, size int64) error {
+	staged, err := db.GetS3StagedSize(m.user.ID, upload.ID, number)
+	if err != nil {
+		return err
+	}
+	if maxSize := int64(setting.GetInt(conf.S3MaxStagedSize, 10240)) << 20; maxSize > 0 && staged+size > maxSize {
+		return errors.WithStack(errStagedTooLarge)
+	}
+	ctx := context.WithValue(context.Background(), "user", m.user)
+	return quota.Check(ctx, path.Dir(upload.Path), staged+size, 0)
+}
+
+func (m *multipart) listParts(w http.ResponseWriter, r *http.Request, upload *model.S3Upload) error {
+	query := r.URL.Query()
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts, err := strconv.Atoi(query.Get("max-parts"))
+	if err != nil || maxParts <= 0 || maxParts > gofakes3.MaxUploadPartsLimit {
+		maxParts = gofakes3.DefaultMaxUploadParts
+	}
+	parts, err := db.GetS3UploadParts(upload.ID)
+	if err != nil {
+		return err
+	}
+	res := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           upload.Bucket,
+		Key:              upload.Key,
+		UploadID:         gofakes3.UploadID(upload.ID),
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	for _, p := range parts {
+		if p.PartNumber <= marker {
+			continue
+		}
+		if len(res.Parts) == maxParts {
+			res.IsTruncated = true
+			break
+		}
+		res.Parts = append(res.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   p.PartNumber,
+			LastModified: gofakes3.NewContentTime(p.Modified),
+			ETag:         p.ETag,
+			Size:         p.Size,
+		})
+		res.NextPartNumberMarker = p.PartNumber
+	}
+	return writeXML(w, res)
+}
+
+func (m *multipart) listUploads(w http.ResponseWriter, r *http.Request, bucket string) error {
+	if _, err := getBucketByName(m.user, bucket); err != nil {
+		return err
+	}
+	prefix := r.URL.Query().Get("prefix")
+	uploads, err := db.GetS3UploadsByBucket(m.user.ID, bucket, prefix)
+	if err != nil {
+		return err
+	}
+	res := gofakes3.ListMultipartUploadsResult{
+		Bucket:     bucket,
+		Prefix:     prefix,
+		MaxUploads: gofakes3.DefaultMaxUploads,
+	}
+	for _, u := range uploads {
+		if len(res.Uploads) == gofakes3.DefaultMaxUploads {
+			res.IsTruncated = true
+			break
+		}
+		res.Uploads = append(res.Uploads, gofakes3.ListMultipartUploadItem{
+			Key:       u.Key,
+			UploadID:  gofakes3.UploadID(u.ID),
+			Initiated: gofakes3.NewContentTime(u.Created),
+		})
+	}
+	return writeXML(w, res)
+}
+
+// complete puts the parts to the storage as the object, the upload is kept to be retried if it fails
+func (m *multipart) complete(w http.ResponseWriter, r *http.Request, upload *model.S3Upload) error {
+	var req gofakes3.CompleteMultipartUploadRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Parts) == 0 {
+		return gofakes3.ErrMalformedXML
+	}
+	staged, err := db.GetS3UploadParts(upload.ID)
+	if err != nil {
+		return err
+	}
+	stagedParts := make(map[int]model.S3UploadPart, len(staged))
+	for _, p := range staged {
+		stagedParts[p.PartNumber] = p
+	}
+	var (
+		parts []model.FilePart
+		size  int64
+		last  int
+		md5s  []byte
+	)
+	for _, p := range req.Parts {
+		if p.PartNumber <= last {
+			return gofakes3.ErrInvalidPartOrder
+		}
+		last = p.PartNumber
+		staged, ok := stagedParts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != strings.Trim(staged.ETag, `"`) {
+			return gofakes3.ErrInvalidPart
+		}
+		sum, _ := hex.DecodeString(strings.Trim(staged.ETag, `"`))
+		md5s = append(md5s, sum...)
+		parts = append(parts, model.FilePart{
+			Path: filepath.Join(uploadDir(upload.ID), strconv.Itoa(p.PartNumber)),
+			Size: staged.Size,
+		})
+		size += staged.Size
+	}
+
+	// the base path or the permissions of the user may be changed after the upload is initiated
+	ctx, fp, err := m.putPath(upload.Bucket, upload.Key)
+	if err != nil {
+		return err
+	}
+	modified := upload.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	obj := model.Object{
+		Name:     path.Base(fp),
+		Size:     size,
+		Modified: modified,
+		Ctime:    time.Now(),
+	}
+	s := stream.NewPartsStream(ctx, &obj, upload.Mimetype, parts)
+	if err := fs.PutDirectly(ctx, path.Dir(fp), s); err != nil {
+		return err
+	}
+	if err := removeUpload(upload.ID); err != nil {
+		log.Errorf("s3: failed remove completed upload %s: %+v", upload.ID, err)
+	}
+	sum := md5.Sum(md5s)
+	return writeXML(w, gofakes3.CompleteMultipartUploadResult{
+		Bucket: upload.Bucket,
+		Key:    upload.Key,
+		ETag:   fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(parts)),
+	})
+}
+
+func (m *multipart) abort(w http.ResponseWriter, upload *model.S3Upload) error {
+	if err := removeUpload(upload.ID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func uploadDir(uploadId string) string {
+	return filepath.Join(conf.Conf.TempDir, conf.S3MultipartDir, uploadId)
+}
+
+func removeUpload(uploadId string) error {
+	if err := db.DeleteS3UploadById(uploadId); err != nil {
+		return err
+	}
+	return os.RemoveAll(uploadDir(uploadId))
+}
+
+// cleanUploads removes the expired uploads and the staged parts of the removed uploads
+func cleanUploads() {
+	uploads, err := db.GetS3Uploads()
+	if err != nil {
+		log.Errorf("s3: failed get uploads: %+v", err)
+		return
+	}
+	kept := make(map[string]struct{}, len(uploads))
+	for _, u := range uploads {
+		if time.Since(u.Created) > uploadExpiration || !utils.Exists(uploadDir(u.ID)) {
+			if err := removeUpload(u.ID); err != nil {
+				log.Errorf("s3: failed remove upload %s: %+v", u.ID, err)
+			}
+			continue
+		}
+		kept[u.ID] = struct{}{}
+	}
+	entries, _ := os.ReadDir(filepath.Join(conf.Conf.TempDir, conf.S3MultipartDir))
+	for _, e := range entries {
+		if _, ok := kept[e.Name()]; !ok {
+			_ = os.RemoveAll(filepath.Join(conf.Conf.TempDir, conf.S3MultipartDir, e.Name()))
+		}
+	}
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/xml")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
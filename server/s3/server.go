@@ -4,24 +4,192 @@ package s3
 
 import (
 	"context"
+	"fmt"
+	"html"
 	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/Mikubill/gofakes3"
+	"github.com/Mikubill/gofakes3/signature"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	log "github.com/sirupsen/logrus"
 )
 
+// server serves the requests as the users of the access keys. The requests signed by the global
+// access key are served as the admin, the unsigned ones are served as the guest only if
+// conf.S3Anonymous is on and the guest is enabled
+type server struct {
+	// handlers are the s3 handlers of the users, the multipart uploads are kept in them
+	handlers sync.Map
+}
+
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
+	cleanUploads()
+	reloadKeys()
+	op.RegisterS3KeysDeleteHook(reloadKeys)
+	cleanCron := cron.NewCron(uploadCleanInterval)
+	cleanCron.Do(cleanUploads)
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			cleanCron.Stop()
+		}()
+	}
+	return &server{}, nil
+}
+
+// handler returns the s3 handler of the user, the signatures are verified if authPair isn't empty
+func (s *server) handler(user *model.User, authPair map[string]string) http.Handler {
+	key := fmt.Sprintf("%d-%t", user.ID, len(authPair) > 0)
+	if h, ok := s.handlers.Load(key); ok {
+		return h.(http.Handler)
+	}
 	var newLogger logger
-	faker := gofakes3.New(
-		newBackend(),
+	options := []gofakes3.Option{
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
+	}
+	if len(authPair) > 0 {
+		// the keys are verified with the key store of signature shared by the handlers,
+		// so the other keys of the user are accepted by the handler too
+		options = append(options, gofakes3.WithV4Auth(authPair))
+	}
+	h, _ := s.handlers.LoadOrStore(key, gofakes3.New(newBackend(user.ID), options...).Server())
+	return h.(http.Handler)
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		user *model.User
+		err  error
 	)
+	accessKeyId := parseAccessKeyId(r)
+	if accessKeyId == "" {
+		if r.Header.Get("Authorization") != "" {
+			writeError(w, http.StatusForbidden, "AccessDenied", "Only the signature version 4 is supported.")
+			return
+		}
+		if !setting.GetBool(conf.S3Anonymous) {
+			writeError(w, http.StatusForbidden, "AccessDenied", "Anonymous access is disabled.")
+			return
+		}
+		user, err = op.GetGuest()
+		if err != nil || user.Disabled {
+			writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied.")
+			return
+		}
+		s.serve(w, r, user, nil)
+		return
+	}
+	secret := ""
+	if accessKeyId == setting.GetStr(conf.S3AccessKeyId) {
+		secret = setting.GetStr(conf.S3SecretAccessKey)
+		user, err = op.GetAdmin()
+	} else if k, e := op.GetS3KeyByAccessKeyId(accessKeyId); e == nil {
+		secret = k.SecretAccessKey
+		user, err = op.GetUserById(k.UserId)
+	} else {
+		writeError(w, http.StatusForbidden, "InvalidAccessKeyId", "The access key ID you provided does not exist in our records.")
+		return
+	}
+	if err != nil || user.Disabled {
+		writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied.")
+		return
+	}
+	// the keys are checked above, the key store of signature is only used to verify the signatures,
+	// the deleted keys are removed from it by reloadKeys
+	authPair := map[string]string{accessKeyId: secret}
+	signature.StoreKeys(authPair)
+	s.serve(w, r, user, authPair)
+}
+
+// reloadKeys replaces the keys in the key store of signature with the global key and the keys of the users
+func reloadKeys() {
+	keys := make(map[string]string)
+	if id := setting.GetStr(conf.S3AccessKeyId); id != "" {
+		keys[id] = setting.GetStr(conf.S3SecretAccessKey)
+	}
+	s3Keys, err := op.GetAllS3Keys()
+	if err != nil {
+		log.Errorf("s3: failed reload keys: %+v", err)
+		return
+	}
+	for _, k := range s3Keys {
+		keys[k.AccessKeyId] = k.SecretAccessKey
+	}
+	signature.ReloadKeys(keys)
+}
+
+func (s *server) serve(w http.ResponseWriter, r *http.Request, user *model.User, authPair map[string]string) {
+	if allowed := requiredPermission(r); allowed != nil && !allowed(user) {
+		log.Debugf("s3: user %s has no permission of %s %s", user.Username, r.Method, r.URL.Path)
+		writeError(w, http.StatusForbidden, "AccessDenied", "Access Denied.")
+		return
+	}
+	if isMultipart(r) {
+		// the multipart uploads aren't passed to gofakes3, so the signatures are verified here
+		if len(authPair) > 0 {
+			if code := signature.V4SignVerify(r); code != signature.ErrNone {
+				resp := signature.GetAPIError(code)
+				writeError(w, resp.HTTPStatusCode, resp.Code, resp.Description)
+				return
+			}
+		}
+		m := &multipart{user: user, backend: &s3Backend{userId: user.ID}, secret: authPair[parseAccessKeyId(r)]}
+		m.ServeHTTP(w, r)
+		return
+	}
+	s.handler(user, authPair).ServeHTTP(w, r)
+}
+
+// requiredPermission returns the permission needed by the request, nil for reading
+func requiredPermission(r *http.Request) func(*model.User) bool {
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodPut:
+		return (*model.User).CanWrite
+	case http.MethodPost:
+		if query.Has("delete") {
+			return (*model.User).CanRemove
+		}
+		return (*model.User).CanWrite
+	case http.MethodDelete:
+		// aborting the multipart upload
+		if query.Has("uploadId") {
+			return (*model.User).CanWrite
+		}
+		return (*model.User).CanRemove
+	}
+	return nil
+}
+
+// parseAccessKeyId returns the access key id in the Credential of the signature version 4
+func parseAccessKeyId(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256") {
+		return ""
+	}
+	_, credential, ok := strings.Cut(auth, "Credential=")
+	if !ok {
+		return ""
+	}
+	accessKeyId, _, _ := strings.Cut(credential, "/")
+	return strings.TrimSpace(accessKeyId)
+}
 
-	return faker.Server(), nil
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<Error><Code>%s</Code><Message>%s</Message></Error>`,
+		code, html.EscapeString(message))
 }
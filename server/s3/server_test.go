@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Mikubill/gofakes3/signature"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+func signedRequest(t *testing.T, k *model.S3Key) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/b/", nil)
+	signer := v4.NewSigner(credentials.NewStaticCredentials(k.AccessKeyId, k.SecretAccessKey, ""))
+	if _, err := signer.Sign(r, nil, "s3", "us-east-1", time.Now()); err != nil {
+		t.Fatalf("failed sign request: %+v", err)
+	}
+	return r
+}
+
+func TestServerAuth(t *testing.T) {
+	m, _ := setupMultipart(t)
+	h, err := NewServer(context.Background())
+	if err != nil {
+		t.Fatalf("failed new server: %+v", err)
+	}
+	guest := &model.User{Username: "guest", BasePath: "/", Role: model.GUEST, Authn: "[]"}
+	if err := op.CreateUser(guest); err != nil {
+		t.Fatalf("failed create guest: %+v", err)
+	}
+	serve := func(r *http.Request) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(httptest.NewRequest(http.MethodGet, "/b/", nil)); code != http.StatusForbidden {
+		t.Errorf("anonymous request: got %d, want 403", code)
+	}
+	if err := op.SaveSettingItem(&model.SettingItem{Key: conf.S3Anonymous, Value: "true",
+		Type: conf.TypeBool, Group: model.S3, Flag: model.PRIVATE}); err != nil {
+		t.Fatalf("failed save setting: %+v", err)
+	}
+	if code := serve(httptest.NewRequest(http.MethodGet, "/b/", nil)); code != http.StatusOK {
+		t.Errorf("anonymous request with %s on: got %d, want 200", conf.S3Anonymous, code)
+	}
+
+	k, err := op.CreateS3Key(m.user.ID, "k")
+	if err != nil {
+		t.Fatalf("failed create key: %+v", err)
+	}
+	if code := serve(signedRequest(t, k)); code != http.StatusOK {
+		t.Errorf("signed request: got %d, want 200", code)
+	}
+	if err := op.DeleteS3Key(k.ID, m.user.ID); err != nil {
+		t.Fatalf("failed delete key: %+v", err)
+	}
+	if code := signature.V4SignVerify(signedRequest(t, k)); signature.GetAPIError(code).Code != "InvalidAccessKeyId" {
+		t.Errorf("deleted key should be removed from the key store, verify got %s", signature.GetAPIError(code).Code)
+	}
+	if code := serve(signedRequest(t, k)); code != http.StatusForbidden {
+		t.Errorf("request signed by the deleted key: got %d, want 403", code)
+	}
+}
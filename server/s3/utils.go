@@ -14,11 +14,20 @@ import (
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
 )
 
 type Bucket struct {
 	Name string `json:"name"`
+	// Path is under the base path of the user
 	Path string `json:"path"`
+	// Users are the usernames who can see and access the bucket, all users can if it's empty
+	Users []string `json:"users,omitempty"`
+}
+
+func (b Bucket) allowed(user *model.User) bool {
+	return user.IsAdmin() || len(b.Users) == 0 || utils.SliceContains(b.Users, user.Username)
 }
 
 func getAndParseBuckets() ([]Bucket, error) {
@@ -27,22 +36,25 @@ func getAndParseBuckets() ([]Bucket, error) {
 	return res, err
 }
 
-func getBucketByName(name string) (Bucket, error) {
+// getBucketByName returns the bucket which the user can access
+func getBucketByName(user *model.User, name string) (Bucket, error) {
 	buckets, err := getAndParseBuckets()
 	if err != nil {
 		return Bucket{}, err
 	}
 	for _, b := range buckets {
-		if b.Name == name {
+		if b.Name == name && b.allowed(user) {
 			return b, nil
 		}
 	}
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
 
-func getDirEntries(path string) ([]model.Obj, error) {
-	ctx := context.Background()
+func getDirEntries(ctx context.Context, user *model.User, path string) ([]model.Obj, error) {
 	meta, _ := op.GetNearestMeta(path)
+	if !common.CanAccess(user, meta, path, "") {
+		return nil, gofakes3.ErrNoSuchKey
+	}
 	fi, err := fs.Get(context.WithValue(ctx, "meta", meta), path, &fs.GetArgs{})
 	if errs.IsNotFoundError(err) {
 		return nil, gofakes3.ErrNoSuchKey
@@ -147,14 +159,3 @@ func prefixParser(p *gofakes3.Prefix) (path, remaining string) {
 // 		rmdirRecursive(dir, VFS)
 // 	}
 // }
-
-func authlistResolver() map[string]string {
-	s3accesskeyid := setting.GetStr(conf.S3AccessKeyId)
-	s3secretaccesskey := setting.GetStr(conf.S3SecretAccessKey)
-	if s3accesskeyid == "" && s3secretaccesskey == "" {
-		return nil
-	}
-	authList := make(map[string]string)
-	authList[s3accesskeyid] = s3secretaccesskey
-	return authList
-}
@@ -0,0 +1,274 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+)
+
+// handler serves the sftp requests of a user, paths of requests are
+// the paths that the user sees, the base path of user is not included
+type handler struct {
+	user *model.User
+	ip   string
+}
+
+func newHandlers(user *model.User, ip string) sftp.Handlers {
+	h := &handler{user: user, ip: ip}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+// realPath resolves the path of a request to the alist path,
+// the meta of the path is checked so that hidden or password protected objects can't be accessed
+func (h *handler) realPath(p string) (string, error) {
+	reqPath, err := h.user.JoinPath(utils.FixAndCleanPath(p))
+	if err != nil {
+		return "", err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return "", err
+	}
+	if !common.CanAccess(h.user, meta, reqPath, "") {
+		return "", errs.PermissionDenied
+	}
+	return reqPath, nil
+}
+
+func (h *handler) fsCtx(ctx context.Context, reqPath string) context.Context {
+	meta, _ := op.GetNearestMeta(reqPath)
+	ctx = context.WithValue(context.WithValue(ctx, "user", h.user), "meta", meta)
+	return context.WithValue(ctx, "ip", h.ip)
+}
+
+func (h *handler) canWrite(reqPath string) bool {
+	if !h.user.CanFTPManage() {
+		return false
+	}
+	if h.user.CanWrite() {
+		return true
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		return false
+	}
+	return common.CanWrite(meta, reqPath)
+}
+
+// toSftpErr converts errors of alist to the errors that can be understood by sftp clients
+func toSftpErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	log.Debugf("[sftp] %+v", err)
+	switch {
+	case errs.IsObjectNotFound(err), errs.IsNotFoundError(err):
+		return sftp.ErrSSHFxNoSuchFile
+	case errors.Is(err, errs.PermissionDenied):
+		return sftp.ErrSSHFxPermissionDenied
+	case errs.IsNotSupportError(err), errs.IsNotImplement(err):
+		return sftp.ErrSSHFxOpUnsupported
+	default:
+		return err
+	}
+}
+
+func (h *handler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return nil, toSftpErr(err)
+	}
+	ctx := h.fsCtx(context.Background(), reqPath)
+	link, obj, err := fs.Link(ctx, reqPath, model.LinkArgs{})
+	if err != nil {
+		return nil, toSftpErr(err)
+	}
+	if obj.IsDir() {
+		return nil, sftp.ErrSSHFxFailure
+	}
+	return newReaderAt(ctx, obj, link), nil
+}
+
+func (h *handler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return nil, toSftpErr(err)
+	}
+	if !h.canWrite(stdpath.Dir(reqPath)) {
+		return nil, sftp.ErrSSHFxPermissionDenied
+	}
+	ctx := h.fsCtx(context.Background(), reqPath)
+	var old model.Obj
+	if flags := r.Pflags(); !flags.Trunc {
+		obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+		if err == nil {
+			if obj.IsDir() {
+				return nil, sftp.ErrSSHFxFailure
+			}
+			if flags.Excl {
+				return nil, os.ErrExist
+			}
+			old = obj
+		}
+	}
+	w, err := newWriterAt(ctx, reqPath, old)
+	return w, toSftpErr(err)
+}
+
+func (h *handler) Filecmd(r *sftp.Request) error {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return toSftpErr(err)
+	}
+	ctx := h.fsCtx(context.Background(), reqPath)
+	switch r.Method {
+	case "Setstat":
+		// attributes can't be changed, but clients like to set them after uploading
+		return nil
+	case "Rename", "PosixRename":
+		return h.rename(ctx, reqPath, r.Target)
+	case "Rmdir", "Remove":
+		if !h.user.CanFTPManage() || !h.user.CanRemove() {
+			return sftp.ErrSSHFxPermissionDenied
+		}
+		obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return toSftpErr(err)
+		}
+		if obj.IsDir() != (r.Method == "Rmdir") {
+			return sftp.ErrSSHFxFailure
+		}
+		return toSftpErr(fs.Remove(ctx, reqPath))
+	case "Mkdir":
+		if !h.canWrite(stdpath.Dir(reqPath)) {
+			return sftp.ErrSSHFxPermissionDenied
+		}
+		return toSftpErr(fs.MakeDir(ctx, reqPath))
+	default:
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *handler) PosixRename(r *sftp.Request) error {
+	return h.Filecmd(r)
+}
+
+func (h *handler) rename(ctx context.Context, src, target string) error {
+	dst, err := h.realPath(target)
+	if err != nil {
+		return toSftpErr(err)
+	}
+	srcDir, dstDir := stdpath.Dir(src), stdpath.Dir(dst)
+	srcName, dstName := stdpath.Base(src), stdpath.Base(dst)
+	if !h.user.CanFTPManage() ||
+		(srcDir != dstDir && !h.user.CanMove()) ||
+		(srcName != dstName && !h.user.CanRename()) {
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	if srcDir == dstDir {
+		return toSftpErr(fs.Rename(ctx, src, dstName))
+	}
+	if err := fs.Move(ctx, src, dstDir); err != nil {
+		return toSftpErr(err)
+	}
+	if srcName != dstName {
+		return toSftpErr(fs.Rename(ctx, stdpath.Join(dstDir, srcName), dstName))
+	}
+	return nil
+}
+
+func (h *handler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	reqPath, err := h.realPath(r.Filepath)
+	if err != nil {
+		return nil, toSftpErr(err)
+	}
+	ctx := h.fsCtx(context.Background(), reqPath)
+	switch r.Method {
+	case "List":
+		objs, err := fs.List(ctx, reqPath, &fs.ListArgs{NoLog: true})
+		if err != nil {
+			return nil, toSftpErr(err)
+		}
+		infos := make(listerAt, 0, len(objs))
+		for _, obj := range objs {
+			infos = append(infos, &fileInfo{Obj: obj})
+		}
+		return infos, nil
+	case "Stat":
+		obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return nil, toSftpErr(err)
+		}
+		return listerAt{&fileInfo{Obj: obj}}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (h *handler) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	r.Method = "Stat"
+	return h.Filelist(r)
+}
+
+func (h *handler) RealPath(p string) (string, error) {
+	return utils.FixAndCleanPath(p), nil
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(f []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(f, l[offset:])
+	if n < len(f) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fileInfo implements os.FileInfo for model.Obj
+type fileInfo struct {
+	model.Obj
+}
+
+func (f *fileInfo) Name() string {
+	return f.GetName()
+}
+
+func (f *fileInfo) Size() int64 {
+	return f.GetSize()
+}
+
+func (f *fileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func (f *fileInfo) ModTime() time.Time {
+	return f.Obj.ModTime()
+}
+
+func (f *fileInfo) Sys() any {
+	return nil
+}
+
+var (
+	_ sftp.PosixRenameFileCmder = (*handler)(nil)
+	_ sftp.LstatFileLister      = (*handler)(nil)
+	_ sftp.RealPathFileLister   = (*handler)(nil)
+)
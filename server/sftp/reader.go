@@ -0,0 +1,124 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// readWindow is the size of data kept before the current position of the stream,
+// sftp clients send several read requests at once and they may arrive out of order,
+// so a request slightly behind or ahead of the stream should not reopen it
+const readWindow = 4 * utils.MB
+
+// readerAt implements io.ReaderAt on top of model.Link,
+// MFile is used directly if the driver provides it, otherwise
+// a range stream is opened and reused as long as reads are roughly sequential
+type readerAt struct {
+	ctx  context.Context
+	obj  model.Obj
+	link *model.Link
+
+	mu  sync.Mutex
+	rrc model.RangeReadCloserIF
+	rc  io.ReadCloser
+	// buf holds the data in [pos-len(buf), pos)
+	buf []byte
+	pos int64
+}
+
+func newReaderAt(ctx context.Context, obj model.Obj, link *model.Link) *readerAt {
+	return &readerAt{ctx: ctx, obj: obj, link: link}
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	if r.link.MFile != nil {
+		return r.link.MFile.ReadAt(p, off)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	size := r.obj.GetSize()
+	if off >= size {
+		return 0, io.EOF
+	}
+	bufStart := r.pos - int64(len(r.buf))
+	if r.rc == nil || off < bufStart || off > r.pos+readWindow {
+		if err := r.open(off); err != nil {
+			return 0, err
+		}
+		bufStart = off
+	}
+	end := utils.Min(off+int64(len(p)), size)
+	if end > r.pos {
+		chunk := make([]byte, end-r.pos)
+		n, err := io.ReadFull(r.rc, chunk)
+		r.buf = append(r.buf, chunk[:n]...)
+		r.pos += int64(n)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			r.closeStream()
+			return 0, err
+		}
+		end = utils.Min(end, r.pos)
+		if end <= off {
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, r.buf[off-bufStart:end-bufStart])
+	// drop the data that is out of the window
+	if keep := utils.Max(readWindow, r.pos-off); int64(len(r.buf)) > keep {
+		r.buf = r.buf[int64(len(r.buf))-keep:]
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *readerAt) open(off int64) error {
+	r.closeStream()
+	if r.rrc == nil {
+		if r.link.RangeReadCloser != nil {
+			r.rrc = r.link.RangeReadCloser
+		} else {
+			rrc, err := stream.GetRangeReadCloserFromLink(r.obj.GetSize(), r.link)
+			if err != nil {
+				return err
+			}
+			r.rrc = rrc
+		}
+	}
+	rc, err := r.rrc.RangeRead(r.ctx, http_range.Range{Start: off, Length: r.obj.GetSize() - off})
+	if err != nil {
+		return err
+	}
+	r.rc = rc
+	r.pos = off
+	return nil
+}
+
+func (r *readerAt) closeStream() {
+	if r.rc != nil {
+		_ = r.rc.Close()
+		r.rc = nil
+	}
+	r.buf = nil
+}
+
+// Close is called by the sftp server when the file is closed
+func (r *readerAt) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeStream()
+	if r.link.MFile != nil {
+		_ = r.link.MFile.Close()
+	}
+	if r.rrc != nil {
+		return r.rrc.Close()
+	}
+	return nil
+}
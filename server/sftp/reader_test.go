@@ -0,0 +1,56 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+)
+
+func TestReaderAt(t *testing.T) {
+	data := make([]byte, 10<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	opened := 0
+	link := &model.Link{RangeReadCloser: &model.RangeReadCloser{
+		RangeReader: func(ctx context.Context, r http_range.Range) (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(bytes.NewReader(data[r.Start : r.Start+r.Length])), nil
+		},
+	}}
+	r := newReaderAt(context.Background(), &model.Object{Size: int64(len(data))}, link)
+	defer r.Close()
+	check := func(off int64, n int) {
+		t.Helper()
+		p := make([]byte, n)
+		got, err := r.ReadAt(p, off)
+		want := data[off:]
+		if len(want) > n {
+			want = want[:n]
+		}
+		if got != len(want) || !bytes.Equal(p[:got], want) {
+			t.Fatalf("read %d bytes at %d: got %d bytes, err %v", n, off, got, err)
+		}
+		if got < n && err != io.EOF {
+			t.Fatalf("short read at %d should return io.EOF, got %v", off, err)
+		}
+	}
+	// out of order requests within the window reuse the stream
+	check(0, 32<<10)
+	check(64<<10, 32<<10)
+	check(32<<10, 32<<10)
+	check(96<<10, 32<<10)
+	if opened != 1 {
+		t.Fatalf("expected the stream to be opened once, got %d", opened)
+	}
+	// seeking far away reopens it
+	check(8<<20, 32<<10)
+	check(1<<20, 32<<10)
+	if opened != 3 {
+		t.Fatalf("expected the stream to be opened 3 times, got %d", opened)
+	}
+	check(int64(len(data))-100, 32<<10)
+}
@@ -0,0 +1,240 @@
+// Package sftp implements a ssh server which only serves the sftp subsystem for alist
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/audit"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+type Server struct {
+	Addr   string
+	config *ssh.ServerConfig
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer creates the sftp server from conf.Conf.SFTP,
+// the host key is generated if the file doesn't exist
+func NewServer() (*Server, error) {
+	signer, err := loadHostKey(conf.Conf.SFTP.HostKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Addr:  fmt.Sprintf("%s:%d", conf.Conf.Scheme.Address, conf.Conf.SFTP.Port),
+		conns: make(map[net.Conn]struct{}),
+	}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback:  s.passwordCallback,
+		PublicKeyCallback: s.publicKeyCallback,
+		ServerVersion:     "SSH-2.0-Alist",
+	}
+	s.config.AddHostKey(signer)
+	return s, nil
+}
+
+func loadHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return ssh.ParsePrivateKey(data)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed read ssh host key")
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, errors.Wrapf(err, "failed write ssh host key")
+	}
+	log.Infof("generated ssh host key %s", path)
+	return ssh.NewSignerFromKey(priv)
+}
+
+func permissions(user *model.User, method string) *ssh.Permissions {
+	return &ssh.Permissions{Extensions: map[string]string{"username": user.Username, "method": method}}
+}
+
+func remoteIP(addr net.Addr) string {
+	host, _, _ := net.SplitHostPort(addr.String())
+	return host
+}
+
+func (s *Server) passwordCallback(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	user, err := op.GetUserByName(meta.User())
+	if err == nil {
+		err = user.ValidateRawPassword(string(password))
+	}
+	if err == nil && (user.Disabled || !user.CanFTPAccess()) {
+		err = errors.New("permission denied")
+	}
+	if err != nil {
+		log.Warnf("[sftp] failed login of %s from %s", meta.User(), meta.RemoteAddr())
+		audit.Login(meta.User(), remoteIP(meta.RemoteAddr()), "sftp password", err)
+		return nil, err
+	}
+	return permissions(user, "password"), nil
+}
+
+func (s *Server) publicKeyCallback(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	user, err := op.GetUserBySSHPublicKey(key)
+	if err == nil && user.Username != meta.User() {
+		err = errors.New("key doesn't belong to the user")
+	}
+	if err == nil && (user.Disabled || !user.CanFTPAccess()) {
+		err = errors.New("permission denied")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the callback is called before the signature is verified, so the key is marked used in handleConn
+	perms := permissions(user, "publickey")
+	perms.Extensions["fingerprint"] = ssh.FingerprintSHA256(key)
+	return perms, nil
+}
+
+func (s *Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Shutdown stops accepting new connections and closes all connections
+func (s *Server) Shutdown() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		log.Debugf("[sftp] handshake with %s failed: %+v", conn.RemoteAddr(), err)
+		return
+	}
+	defer sconn.Close()
+	_ = conn.SetDeadline(time.Time{})
+	go ssh.DiscardRequests(reqs)
+	user, err := op.GetUserByName(sconn.Permissions.Extensions["username"])
+	if err != nil {
+		log.Errorf("[sftp] failed get user: %+v", err)
+		return
+	}
+	log.Debugf("[sftp] %s logged in from %s", user.Username, conn.RemoteAddr())
+	if fingerprint := sconn.Permissions.Extensions["fingerprint"]; fingerprint != "" {
+		if err := op.UpdateSSHPublicKeyLastUsed(fingerprint); err != nil {
+			log.Warnf("[sftp] failed update the last used time of the key: %+v", err)
+		}
+	}
+	ip := remoteIP(conn.RemoteAddr())
+	// failed public key attempts are not recorded since clients usually try several keys
+	audit.Login(user.Username, ip, "sftp "+sconn.Permissions.Extensions["method"], nil)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			log.Debugf("[sftp] failed accept channel: %+v", err)
+			continue
+		}
+		go s.handleSession(user, ip, ch, requests)
+	}
+}
+
+// handleSession waits for the sftp subsystem request and serves it,
+// shell and exec requests are rejected
+func (s *Server) handleSession(user *model.User, ip string, ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range requests {
+		ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+		_ = req.Reply(ok, nil)
+		if !ok {
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		server := sftp.NewRequestServer(ch, newHandlers(user, ip))
+		if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			log.Debugf("[sftp] session of %s: %+v", user.Username, err)
+		}
+		_ = server.Close()
+		return
+	}
+}
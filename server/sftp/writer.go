@@ -0,0 +1,106 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/stream"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// writerAt buffers the written data in a temp file and uploads it when the file is closed
+type writerAt struct {
+	ctx     context.Context
+	reqPath string
+
+	mu      sync.Mutex
+	tmpFile *os.File
+	failed  bool
+}
+
+// newWriterAt creates the temp file, the content of old is copied into it
+// so that the file can be partly overwritten or appended
+func newWriterAt(ctx context.Context, reqPath string, old model.Obj) (*writerAt, error) {
+	tmpFile, err := os.CreateTemp(conf.Conf.TempDir, "sftp-*")
+	if err != nil {
+		return nil, err
+	}
+	w := &writerAt{ctx: ctx, reqPath: reqPath, tmpFile: tmpFile}
+	if old != nil && old.GetSize() > 0 {
+		if err := w.download(old); err != nil {
+			w.cleanup()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *writerAt) download(old model.Obj) error {
+	link, _, err := fs.Link(w.ctx, w.reqPath, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	r := newReaderAt(w.ctx, old, link)
+	defer r.Close()
+	_, err = utils.CopyWithBuffer(w.tmpFile, io.NewSectionReader(r, 0, old.GetSize()))
+	return err
+}
+
+func (w *writerAt) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tmpFile.WriteAt(p, off)
+}
+
+// TransferError is called by the sftp server if the transfer failed,
+// the file won't be uploaded then
+func (w *writerAt) TransferError(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	log.Warnf("[sftp] transfer of %s failed: %+v", w.reqPath, err)
+	w.failed = true
+}
+
+// Close uploads the temp file
+func (w *writerAt) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer w.cleanup()
+	if w.failed {
+		return nil
+	}
+	info, err := w.tmpFile.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := w.tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	name := stdpath.Base(w.reqPath)
+	file := &stream.FileStream{
+		Ctx: w.ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: time.Now(),
+			Ctime:    time.Now(),
+		},
+		Reader:   w.tmpFile,
+		Mimetype: utils.GetMimeType(name),
+	}
+	defer file.Close()
+	return toSftpErr(fs.PutDirectly(w.ctx, stdpath.Dir(w.reqPath), file))
+}
+
+func (w *writerAt) cleanup() {
+	_ = w.tmpFile.Close()
+	_ = os.Remove(w.tmpFile.Name())
+}
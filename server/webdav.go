@@ -8,12 +8,14 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/webdav"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,7 +24,7 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: webdav.GetLockSystem(),
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
@@ -43,6 +45,7 @@ func WebDav(dav *gin.RouterGroup) {
 func ServeWebDAV(c *gin.Context) {
 	user := c.MustGet("user").(*model.User)
 	ctx := context.WithValue(c.Request.Context(), "user", user)
+	ctx = context.WithValue(ctx, "ip", c.ClientIP())
 	handler.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
 }
 
@@ -67,6 +70,12 @@ func WebDAVAuth(c *gin.Context) {
 				c.Next()
 				return
 			}
+			if strings.HasPrefix(bt, model.ApiTokenPrefix) {
+				if user, err := getUserByWebdavToken(bt); err == nil {
+					webdavAuthorize(c, user, guest)
+					return
+				}
+			}
 		}
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
@@ -78,8 +87,20 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidateRawPassword(password) != nil {
+	var (
+		user *model.User
+		err  error
+	)
+	// the api token can be used as the password for the clients only supporting basic auth
+	if strings.HasPrefix(password, model.ApiTokenPrefix) {
+		user, err = getUserByWebdavToken(password)
+		if err == nil && user.Username != username {
+			err = errs.InvalidApiToken
+		}
+	} else if user, err = op.GetUserByName(username); err == nil {
+		err = user.ValidateRawPassword(password)
+	}
+	if err != nil {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
 			c.Next()
@@ -89,6 +110,23 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	webdavAuthorize(c, user, guest)
+}
+
+// getUserByWebdavToken returns the user restricted by the api token with the webdav scope
+func getUserByWebdavToken(token string) (*model.User, error) {
+	t, user, err := op.GetUserByApiToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if !t.HasScope(model.ScopeWebdav) {
+		return nil, errors.Wrap(errs.ScopeRequired, model.ScopeWebdav)
+	}
+	return user, nil
+}
+
+// webdavAuthorize checks the webdav permissions of the authenticated user
+func webdavAuthorize(c *gin.Context, user, guest *model.User) {
 	if user.Disabled || !user.CanWebdavRead() {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
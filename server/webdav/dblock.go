@@ -0,0 +1,262 @@
+package webdav
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// heldLease is how long a held lock is kept held without being extended, the lock is extended while
+// the request holding it is being handled, so it's only released by the lease if the instance exits.
+// The temporary locks expire by the lease too, they're held while the request is handled and unlocked after it.
+const heldLease = time.Minute
+
+// NewDBLS returns a new LockSystem keeping the locks in the database, so the locks are kept
+// after restarting and shared by the alist instances using the same database. Every change of
+// the locks is made in a transaction serialized among the instances, and the locks held by the
+// requests are marked in the database too.
+func NewDBLS() LockSystem {
+	return &dbLS{}
+}
+
+var (
+	lockSystemOnce sync.Once
+	lockSystem     LockSystem
+)
+
+// GetLockSystem returns the lock system chosen by the config,
+// it's shared by the webdav server and the api managing the locks
+func GetLockSystem() LockSystem {
+	lockSystemOnce.Do(func() {
+		switch conf.Conf.Webdav.LockSystem {
+		case "db":
+			lockSystem = NewDBLS()
+			return
+		case "memory", "":
+		default:
+			log.Warnf("unknown webdav lock system %s, the locks are kept in memory", conf.Conf.Webdav.LockSystem)
+		}
+		lockSystem = NewMemLS()
+	})
+	return lockSystem
+}
+
+type dbLS struct{}
+
+func (m *dbLS) update(now time.Time, query db.WebdavLockQuery, fn func(locks map[string]*model.WebdavLock) (db.WebdavLockChanges, error)) error {
+	return db.UpdateWebdavLocks(now, query, fn)
+}
+
+func (m *dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	heldBy := uuid.NewString()
+	var tokens []string
+	for _, c := range conditions {
+		if c.Token != "" {
+			tokens = append(tokens, c.Token)
+		}
+	}
+	err := m.update(now, db.WebdavLockQuery{Tokens: tokens}, func(locks map[string]*model.WebdavLock) (db.WebdavLockChanges, error) {
+		var n0, n1 *model.WebdavLock
+		if name0 != "" {
+			if n0 = lookup(locks, now, slashClean(name0), conditions...); n0 == nil {
+				return db.WebdavLockChanges{}, ErrConfirmationFailed
+			}
+		}
+		if name1 != "" {
+			if n1 = lookup(locks, now, slashClean(name1), conditions...); n1 == nil {
+				return db.WebdavLockChanges{}, ErrConfirmationFailed
+			}
+		}
+
+		// Don't hold the same lock twice.
+		if n0 != nil && n1 != nil && n0.Token == n1.Token {
+			n1 = nil
+		}
+
+		var changes db.WebdavLockChanges
+		heldUntil := now.Add(heldLease)
+		for _, n := range []*model.WebdavLock{n0, n1} {
+			if n != nil {
+				n.HeldBy, n.HeldUntil = heldBy, &heldUntil
+				changes.Save = append(changes.Save, n)
+			}
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heldLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case t := <-ticker.C:
+				if err := db.ExtendWebdavLocksHeld(heldBy, t.Add(heldLease)); err != nil {
+					log.Errorf("failed extend held webdav locks: %+v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		if err := db.ReleaseWebdavLocks(heldBy); err != nil {
+			log.Errorf("failed release held webdav locks: %+v", err)
+		}
+	}, nil
+}
+
+// lookup returns the lock that locks the named resource, provided that it matches at least
+// one of the given conditions and that it isn't held by another party.
+func lookup(locks map[string]*model.WebdavLock, now time.Time, name string, conditions ...Condition) *model.WebdavLock {
+	// TODO: support Condition.Not and Condition.ETag.
+	for _, c := range conditions {
+		if c.Token == "" {
+			continue
+		}
+		n := locks[c.Token]
+		if n == nil || n.IsHeld(now) {
+			continue
+		}
+		if name == n.Root {
+			return n
+		}
+		if n.ZeroDepth {
+			continue
+		}
+		if n.Root == "/" || len(name) > len(n.Root) && name[:len(n.Root)+1] == n.Root+"/" {
+			return n
+		}
+	}
+	return nil
+}
+
+func (m *dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	details.Root = slashClean(details.Root)
+	l := model.WebdavLock{
+		Token:     "opaquelocktoken:" + uuid.NewString(),
+		Root:      details.Root,
+		OwnerXML:  details.OwnerXML,
+		ZeroDepth: details.ZeroDepth,
+		Temporary: details.Temporary,
+		Created:   now,
+	}
+	setLockDuration(&l, now, details.Duration)
+	if details.Temporary {
+		expiry := now.Add(heldLease)
+		l.Expiry = &expiry
+	}
+	err := m.update(now, db.WebdavLockQuery{Root: details.Root}, func(locks map[string]*model.WebdavLock) (db.WebdavLockChanges, error) {
+		if !canCreateLock(locks, details.Root, details.ZeroDepth) {
+			return db.WebdavLockChanges{}, ErrLocked
+		}
+		return db.WebdavLockChanges{Save: []*model.WebdavLock{&l}}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return l.Token, nil
+}
+
+// canCreateLock checks that the resource isn't locked by the locks, and that none of its
+// descendants is locked if the requested lock has infinite depth
+func canCreateLock(locks map[string]*model.WebdavLock, name string, zeroDepth bool) bool {
+	for _, l := range locks {
+		if l.Root == name {
+			return false
+		}
+		if !zeroDepth && (name == "/" || len(l.Root) > len(name) && l.Root[:len(name)+1] == name+"/") {
+			// a descendant of the target resource is locked
+			return false
+		}
+		if !l.ZeroDepth && (l.Root == "/" || len(name) > len(l.Root) && name[:len(l.Root)+1] == l.Root+"/") {
+			// an ancestor of the target resource is locked with infinite depth
+			return false
+		}
+	}
+	return true
+}
+
+func setLockDuration(l *model.WebdavLock, now time.Time, duration time.Duration) {
+	l.Duration, l.Expiry = -1, nil
+	if duration >= 0 {
+		expiry := now.Add(duration)
+		l.Duration, l.Expiry = int64(duration/time.Second), &expiry
+	}
+}
+
+func (m *dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	var ld LockDetails
+	err := m.update(now, db.WebdavLockQuery{Tokens: []string{token}}, func(locks map[string]*model.WebdavLock) (db.WebdavLockChanges, error) {
+		n := locks[token]
+		if n == nil {
+			return db.WebdavLockChanges{}, ErrNoSuchLock
+		}
+		if n.IsHeld(now) {
+			return db.WebdavLockChanges{}, ErrLocked
+		}
+		setLockDuration(n, now, duration)
+		ld = lockDetails(n)
+		return db.WebdavLockChanges{Save: []*model.WebdavLock{n}}, nil
+	})
+	return ld, err
+}
+
+func (m *dbLS) Unlock(now time.Time, token string) error {
+	return m.update(now, db.WebdavLockQuery{Tokens: []string{token}}, func(locks map[string]*model.WebdavLock) (db.WebdavLockChanges, error) {
+		n := locks[token]
+		if n == nil {
+			return db.WebdavLockChanges{}, ErrNoSuchLock
+		}
+		if n.IsHeld(now) {
+			return db.WebdavLockChanges{}, ErrLocked
+		}
+		return db.WebdavLockChanges{Delete: []string{token}}, nil
+	})
+}
+
+func (m *dbLS) Locks(now time.Time) ([]LockInfo, error) {
+	var res []LockInfo
+	err := m.update(now, db.WebdavLockQuery{All: true}, func(locks map[string]*model.WebdavLock) (db.WebdavLockChanges, error) {
+		res = make([]LockInfo, 0, len(locks))
+		for _, l := range locks {
+			res = append(res, LockInfo{
+				Token:       l.Token,
+				LockDetails: lockDetails(l),
+				Expiry:      l.Expiry,
+				Held:        l.IsHeld(now),
+			})
+		}
+		return db.WebdavLockChanges{}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Root < res[j].Root
+	})
+	return res, nil
+}
+
+func lockDetails(l *model.WebdavLock) LockDetails {
+	duration := time.Duration(infiniteTimeout)
+	if l.Duration >= 0 {
+		duration = time.Duration(l.Duration) * time.Second
+	}
+	return LockDetails{
+		Root:      l.Root,
+		Duration:  duration,
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+		Temporary: l.Temporary,
+	}
+}
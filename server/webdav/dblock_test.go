@@ -0,0 +1,172 @@
+package webdav
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func initTestDB(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestDBLSCanCreate(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemLS().(*memLS)
+	locks := make(map[string]*model.WebdavLock)
+	for _, name := range lockTestNames {
+		if _, err := m.Create(now, LockDetails{
+			Root:      name,
+			Duration:  infiniteTimeout,
+			ZeroDepth: lockTestZeroDepth(name),
+		}); err != nil {
+			t.Fatalf("creating lock for %q: %v", name, err)
+		}
+		locks[name] = &model.WebdavLock{Root: name, ZeroDepth: lockTestZeroDepth(name)}
+	}
+
+	var check func(int, string)
+	check = func(recursion int, name string) {
+		for _, zeroDepth := range []bool{false, true} {
+			got := canCreateLock(locks, name, zeroDepth)
+			want := m.canCreate(name, zeroDepth)
+			if got != want {
+				t.Errorf("canCreateLock name=%q zeroDepth=%t: got %t, want %t", name, zeroDepth, got, want)
+			}
+		}
+		if recursion == 4 {
+			return
+		}
+		if name != "/" {
+			name += "/"
+		}
+		for _, c := range "_iz" {
+			check(recursion+1, name+string(c))
+		}
+	}
+	check(0, "/")
+}
+
+func TestDBLS(t *testing.T) {
+	initTestDB(t)
+	now := time.Unix(0, 0)
+	m := NewDBLS()
+
+	token, err := m.Create(now, LockDetails{Root: "/a/b", Duration: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := m.Create(now, LockDetails{Root: "/a/b/c", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Fatalf("Create under an infinite depth lock: got %v, want %v", err, ErrLocked)
+	}
+	if _, err := m.Create(now, LockDetails{Root: "/a", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Fatalf("Create over a locked descendant: got %v, want %v", err, ErrLocked)
+	}
+	if sibling, err := m.Create(now, LockDetails{Root: "/a/bc", Duration: infiniteTimeout}); err != nil {
+		t.Fatalf("Create beside a lock: %v", err)
+	} else if err := m.Unlock(now, sibling); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	// another instance sharing the database sees the lock
+	other := NewDBLS()
+	release, err := other.Confirm(now, "/a/b/c", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if _, err := other.Confirm(now, "/a/b", "", Condition{Token: token}); err != ErrConfirmationFailed {
+		t.Fatalf("Confirm a held lock: got %v, want %v", err, ErrConfirmationFailed)
+	}
+	if err := other.Unlock(now, token); err != ErrLocked {
+		t.Fatalf("Unlock a held lock: got %v, want %v", err, ErrLocked)
+	}
+	release()
+
+	ld, err := m.Refresh(now.Add(5*time.Second), token, 20*time.Second)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if ld.Root != "/a/b" || ld.Duration != 20*time.Second || ld.ZeroDepth {
+		t.Fatalf("Refresh: got %+v", ld)
+	}
+	locks, err := m.(LockLister).Locks(now.Add(20 * time.Second))
+	if err != nil || len(locks) != 1 || locks[0].Token != token {
+		t.Fatalf("Locks before expiry: got %+v, %v", locks, err)
+	}
+	locks, err = m.(LockLister).Locks(now.Add(25 * time.Second))
+	if err != nil || len(locks) != 0 {
+		t.Fatalf("Locks after expiry: got %+v, %v", locks, err)
+	}
+	if err := m.Unlock(now.Add(25*time.Second), token); err != ErrNoSuchLock {
+		t.Fatalf("Unlock an expired lock: got %v, want %v", err, ErrNoSuchLock)
+	}
+
+	token, err = m.Create(now, LockDetails{Root: "/x", Duration: infiniteTimeout, ZeroDepth: true})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := m.Create(now, LockDetails{Root: "/x/y", Duration: infiniteTimeout}); err != nil {
+		t.Fatalf("Create under a zero depth lock: %v", err)
+	}
+
+	// the lock held by an instance which exited is released by the lease
+	release, err = other.Confirm(now, "/x", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	defer release()
+	release, err = m.Confirm(now.Add(heldLease), "/x", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm after the lease: %v", err)
+	}
+	release()
+	if err := other.Unlock(now.Add(time.Hour), token); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+}
+
+func TestDBLSTemporary(t *testing.T) {
+	initTestDB(t)
+	now := time.Unix(0, 0)
+	m := NewDBLS()
+
+	token, err := m.Create(now, LockDetails{Root: "/t", Duration: infiniteTimeout, ZeroDepth: true, Temporary: true})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	release, err := m.Confirm(now, "/t", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	// the temporary lock is kept while it's held
+	if err := db.ExtendWebdavLocksHeld(heldByOf(t, token), now.Add(3*heldLease)); err != nil {
+		t.Fatalf("ExtendWebdavLocksHeld: %v", err)
+	}
+	if _, err := m.Create(now.Add(2*heldLease), LockDetails{Root: "/t", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Fatalf("Create over a held temporary lock: got %v, want %v", err, ErrLocked)
+	}
+	release()
+
+	// the temporary lock left by an instance which exited expires by the lease
+	if _, err := m.Create(now.Add(2*heldLease), LockDetails{Root: "/t", Duration: infiniteTimeout}); err != nil {
+		t.Fatalf("Create after the temporary lock expired: %v", err)
+	}
+}
+
+func heldByOf(t *testing.T, token string) string {
+	var l model.WebdavLock
+	if err := db.GetDb().Where("token = ?", token).First(&l).Error; err != nil {
+		t.Fatalf("failed get lock: %v", err)
+	}
+	return l.HeldBy
+}
@@ -0,0 +1,148 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"path"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// the dead properties are kept in the database by the full path of the resource,
+// so they're available to all the users sharing the path and follow the moves of alist
+
+type deadPropsKey struct{}
+
+// deadPropsLoader loads the dead props of the resources walked by a PROPFIND,
+// the props of the children of a dir are loaded by one query
+type deadPropsLoader struct {
+	dirs map[string]map[string]map[xml.Name]Property
+}
+
+func withDeadPropsLoader(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deadPropsKey{}, &deadPropsLoader{
+		dirs: make(map[string]map[string]map[xml.Name]Property),
+	})
+}
+
+func (l *deadPropsLoader) get(name string) (map[xml.Name]Property, error) {
+	dir := path.Dir(name)
+	children, ok := l.dirs[dir]
+	if !ok {
+		items, err := db.GetWebdavPropsByParent(dir)
+		if err != nil {
+			return nil, err
+		}
+		children = make(map[string]map[xml.Name]Property)
+		for _, item := range items {
+			if children[item.Path] == nil {
+				children[item.Path] = make(map[xml.Name]Property)
+			}
+			addDeadProp(children[item.Path], item)
+		}
+		l.dirs[dir] = children
+	}
+	return children[name], nil
+}
+
+func addDeadProp(props map[xml.Name]Property, item model.WebdavProp) {
+	pn := xml.Name{Space: item.Namespace, Local: item.Name}
+	props[pn] = Property{
+		XMLName:  pn,
+		Lang:     item.Lang,
+		InnerXML: []byte(item.InnerXML),
+	}
+}
+
+// getDeadProps returns the dead props of the resource, they're taken from the loader of ctx if there's one
+func getDeadProps(ctx context.Context, name string) (map[xml.Name]Property, error) {
+	if l, ok := ctx.Value(deadPropsKey{}).(*deadPropsLoader); ok {
+		return l.get(name)
+	}
+	items, err := db.GetWebdavProps(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	props := make(map[xml.Name]Property, len(items))
+	for _, item := range items {
+		addDeadProp(props, item)
+	}
+	return props, nil
+}
+
+// patchDeadProps applies the patches in order and saves the result at once,
+// so either all or none of them succeed
+func patchDeadProps(name string, patches []Proppatch) ([]Propstat, error) {
+	items, err := db.GetWebdavProps(name)
+	if err != nil {
+		return nil, err
+	}
+	var order []xml.Name
+	props := make(map[xml.Name]model.WebdavProp, len(items))
+	for _, item := range items {
+		pn := xml.Name{Space: item.Namespace, Local: item.Name}
+		order = append(order, pn)
+		props[pn] = item
+	}
+	pstat := Propstat{Status: http.StatusOK}
+	for _, patch := range patches {
+		for _, p := range patch.Props {
+			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+			if patch.Remove {
+				// removing a property which doesn't exist is not an error
+				delete(props, p.XMLName)
+				continue
+			}
+			if _, ok := props[p.XMLName]; !ok {
+				order = append(order, p.XMLName)
+			}
+			props[p.XMLName] = model.WebdavProp{
+				Namespace: p.XMLName.Space,
+				Name:      p.XMLName.Local,
+				Lang:      p.Lang,
+				InnerXML:  string(p.InnerXML),
+			}
+		}
+	}
+	res := make([]model.WebdavProp, 0, len(props))
+	for _, pn := range order {
+		if item, ok := props[pn]; ok {
+			res = append(res, item)
+			// a property removed and set again is only appended once
+			delete(props, pn)
+		}
+	}
+	if err := db.SaveWebdavProps(name, res); err != nil {
+		return nil, err
+	}
+	return []Propstat{pstat}, nil
+}
+
+func onObjChange(change op.ObjChange) {
+	var err error
+	switch change.Type {
+	case op.ObjMove:
+		err = db.MoveWebdavProps(change.OldPath, change.Path)
+	case op.ObjCopy:
+		// RFC 4918 section 9.8.2 requires the dead properties to be copied
+		err = db.CopyWebdavProps(change.OldPath, change.Path)
+	case op.ObjRemove:
+		err = db.DeleteWebdavProps(change.Path)
+	default:
+		return
+	}
+	if err != nil {
+		log.Errorf("failed update webdav props of %s: %+v", change.Path, err)
+	}
+}
+
+func init() {
+	op.RegisterObjChangeHook(onObjChange)
+}
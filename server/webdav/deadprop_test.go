@@ -0,0 +1,106 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestDeadProps(t *testing.T) {
+	initTestDB(t)
+	author := xml.Name{Space: "urn:test", Local: "author"}
+	tag := xml.Name{Space: "urn:test", Local: "tag"}
+
+	set := func(name string, pn xml.Name, value string) {
+		pstats, err := patchDeadProps(name, []Proppatch{{Props: []Property{{XMLName: pn, InnerXML: []byte(value)}}}})
+		if err != nil {
+			t.Fatalf("patching %s: %v", name, err)
+		}
+		if len(pstats) != 1 || pstats[0].Status != http.StatusOK {
+			t.Fatalf("patching %s: got %v, want 200", name, pstats)
+		}
+	}
+	get := func(name string, pn xml.Name) string {
+		props, err := getDeadProps(context.Background(), name)
+		if err != nil {
+			t.Fatalf("getting props of %s: %v", name, err)
+		}
+		return string(props[pn].InnerXML)
+	}
+
+	set("/a/b", author, "alice")
+	set("/a/b/c", tag, "red")
+	set("/a/bc", tag, "blue")
+	if got := get("/a/b", author); got != "alice" {
+		t.Fatalf("author of /a/b: got %q, want %q", got, "alice")
+	}
+
+	// the patches are applied in order
+	if _, err := patchDeadProps("/a/b", []Proppatch{
+		{Props: []Property{{XMLName: tag, InnerXML: []byte("green")}}},
+		{Remove: true, Props: []Property{{XMLName: author}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("/a/b", author); got != "" {
+		t.Fatalf("author of /a/b after removing: got %q", got)
+	}
+	if got := get("/a/b", tag); got != "green" {
+		t.Fatalf("tag of /a/b: got %q, want %q", got, "green")
+	}
+
+	onObjChange(op.ObjChange{Type: op.ObjMove, OldPath: "/a/b", Path: "/x/y"})
+	for _, tc := range []struct {
+		name string
+		pn   xml.Name
+		want string
+	}{
+		{"/a/b", tag, ""},
+		{"/a/b/c", tag, ""},
+		{"/x/y", tag, "green"},
+		{"/x/y/c", tag, "red"},
+		{"/a/bc", tag, "blue"},
+	} {
+		if got := get(tc.name, tc.pn); got != tc.want {
+			t.Errorf("tag of %s after moving: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	onObjChange(op.ObjChange{Type: op.ObjRemove, Path: "/x"})
+	if got := get("/x/y/c", tag); got != "" {
+		t.Errorf("tag of /x/y/c after removing: got %q", got)
+	}
+	if got := get("/a/bc", tag); got != "blue" {
+		t.Errorf("tag of /a/bc after removing /x: got %q, want %q", got, "blue")
+	}
+
+	// the wildcards of LIKE in the paths are matched literally
+	set("/a_b/c", tag, "gray")
+	set("/axb/c", tag, "white")
+	onObjChange(op.ObjChange{Type: op.ObjRemove, Path: "/a_b"})
+	if got := get("/axb/c", tag); got != "white" {
+		t.Errorf("tag of /axb/c after removing /a_b: got %q, want %q", got, "white")
+	}
+
+	onObjChange(op.ObjChange{Type: op.ObjCopy, OldPath: "/a/bc", Path: "/a/bd"})
+	if got := get("/a/bd", tag); got != "blue" {
+		t.Errorf("tag of /a/bd after copying: got %q, want %q", got, "blue")
+	}
+	if got := get("/a/bc", tag); got != "blue" {
+		t.Errorf("tag of /a/bc after copying: got %q, want %q", got, "blue")
+	}
+
+	ctx := withDeadPropsLoader(context.Background())
+	for name, want := range map[string]string{"/a/bc": "blue", "/a/bd": "blue", "/a/be": ""} {
+		props, err := getDeadProps(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(props[tag].InnerXML); got != want {
+			t.Errorf("tag of %s from the loader: got %q, want %q", name, got, want)
+		}
+	}
+}
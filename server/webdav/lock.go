@@ -7,6 +7,7 @@ package webdav
 import (
 	"container/heap"
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -109,6 +110,25 @@ type LockDetails struct {
 	// ZeroDepth is whether the lock has zero depth. If it does not have zero
 	// depth, it has infinite depth.
 	ZeroDepth bool
+	// Temporary is whether the lock is created by the Handler to protect the
+	// resources while handling a request without locks.
+	Temporary bool
+}
+
+// LockInfo is a lock listed by a LockLister.
+type LockInfo struct {
+	Token string
+	LockDetails
+	// Expiry is nil if the lock never expires.
+	Expiry *time.Time
+	// Held is whether the lock is held by a Confirm call.
+	Held bool
+}
+
+// LockLister is implemented by the LockSystems which can list their locks,
+// the locks can be released by Unlock.
+type LockLister interface {
+	Locks(now time.Time) ([]LockInfo, error)
 }
 
 // NewMemLS returns a new in-memory LockSystem.
@@ -288,6 +308,24 @@ func (m *memLS) Unlock(now time.Time, token string) error {
 	return nil
 }
 
+func (m *memLS) Locks(now time.Time) ([]LockInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectExpiredNodes(now)
+
+	res := make([]LockInfo, 0, len(m.byToken))
+	for token, n := range m.byToken {
+		info := LockInfo{Token: token, LockDetails: n.details, Held: n.held}
+		if n.details.Duration >= 0 {
+			expiry := n.expiry
+			info.Expiry = &expiry
+		}
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Root < res[j].Root })
+	return res, nil
+}
+
 func (m *memLS) canCreate(name string, zeroDepth bool) bool {
 	return walkToRoot(name, func(name0 string, first bool) bool {
 		n := m.byName[name0]